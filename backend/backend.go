@@ -1,39 +1,40 @@
 package backend
 
 import (
+	"UppSpar/backend/domain"
 	"UppSpar/backend/journal"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log"
-
-	_ "github.com/mattn/go-sqlite3"
+	"os"
 )
 
 var b *Backend
 var subsec = "2006-01-02 15:04:05.999"
 
 type Backend struct {
-	db       *sql.DB
-	Items    *Items
-	Journal  *journal.Journal
-	Metadata *Metadata
-	Settings *Settings
-	Wishlist Wishlist
+	db         *sql.DB
+	history    *history
+	Repository domain.Repository
+	Items      *Items
+	Journal    *journal.Journal
+	Metadata   *Metadata
+	Settings   *Settings
+	Undo       *UndoStack
+	Wishlist   Wishlist
 }
 
 func NewBackend(file string) (*Backend, error) {
-	DB, err := sql.Open("sqlite3", file)
-
-	b = &Backend{
-		db: DB,
+	err := open(file, journal.NewJournal)
+	if err != nil {
+		return nil, err
 	}
 
-	b.Journal = journal.NewJournal(b.db)
-	b.createTables()
 	b.Items = NewItems()
 	b.Metadata = NewMetadata()
 	b.Settings = NewSettings()
+	b.Undo = NewUndoStack()
 	b.Wishlist = NewWishlist()
 
 	b.Items.GetItemIDs()
@@ -46,7 +47,46 @@ func NewBackend(file string) (*Backend, error) {
 	b.Metadata.getAllUnitIDs()
 	b.Metadata.getAllItemStatusIDs()
 
-	return b, err
+	return b, nil
+}
+
+/* Open the database without any of the data bindings used by the GUI, for use without a Fyne app. Items, Metadata, Settings, Undo and Wishlist are left nil, use Repository or the package level functions instead. */
+func NewHeadlessBackend(file string) (*Backend, error) {
+	err := open(file, journal.NewHeadlessJournal)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func open(file string, newJournal func(*sql.DB) (*journal.Journal, error)) error {
+	DB, err := sql.Open(domain.DriverName, file)
+	if err != nil {
+		return fmt.Errorf("NewBackend() error: %w", err)
+	}
+
+	b = &Backend{
+		db:         DB,
+		Repository: domain.NewRepository(DB),
+		history:    &history{},
+	}
+
+	if b.Journal, err = newJournal(b.db); err != nil {
+		DB.Close()
+		return fmt.Errorf("NewBackend() error: %w", err)
+	}
+	if err := b.createTables(); err != nil {
+		return err
+	}
+	if err := b.ensureSearchIndex(); err != nil {
+		log.Printf("NewBackend() search index error: %s", err)
+	}
+	if err := b.checkSchema(false, true); err != nil {
+		log.Printf("NewBackend() schema check error: %s", err)
+	}
+	purgeExpiredTrash()
+	expireReservations()
+	return nil
 }
 
 func (backend *Backend) Close() error {
@@ -58,6 +98,14 @@ func (backend *Backend) Close() error {
 
 func ItemIDWidth() int {
 	defaultWidth := 7
+	if b.Settings == nil {
+		s, err := b.Repository.Settings()
+		if err != nil {
+			log.Println(err)
+			return defaultWidth
+		}
+		return s.ItemIDWidth
+	}
 	i, err := b.Settings.ItemIDWidth.Get()
 	if err != nil {
 		log.Println(err)
@@ -65,6 +113,19 @@ func ItemIDWidth() int {
 	}
 	return i
 }
+
+/* Copy the whole database to a new file at path, which must not already exist */
+func (backend *Backend) Backup(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("Backend.Backup(%s) error: %w", path, os.ErrExist)
+	}
+	if _, err := backend.db.Exec(`VACUUM INTO @0`, path); err != nil {
+		backend.Journal.NewEntry(journal.Error, journal.SQL, fmt.Sprintf("Kunde inte säkerhetskopiera databasen till %s: %s", path, err))
+		return fmt.Errorf("Backend.Backup(%s) error: %w", path, err)
+	}
+	backend.Journal.NewEntry(journal.Message, journal.Log, fmt.Sprintf("Säkerhetskopierade databasen till %s.", path))
+	return nil
+}
 func CatIDFor(s string) (CatID, error) {
 	// TODO handle when database contains multiple rows with 's'
 	var i NullInt
@@ -103,10 +164,13 @@ func MfrIDFor(s string) (MfrID, error) {
 		return id, fmt.Errorf("MfrIDFor(%s) error: %w", s, err)
 	}
 	defer stmt.Close()
-	stmt.QueryRow(s).Scan(&id)
+	err = stmt.QueryRow(s).Scan(&i)
+	if err != nil {
+		return id, err
+	}
 
 	if !i.Valid {
-		return id, err
+		return id, sql.ErrNoRows
 	}
 
 	id = MfrID(i.Int)
@@ -2,28 +2,206 @@ package bridge
 
 import (
 	"UppSpar/backend"
+	"fmt"
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	midget "github.com/assholehoff/fyne-midget"
 )
 
-func NewExportExcelDialog(b *backend.Backend, w fyne.Window) *dialog.FileDialog {
+/* Asks where to save the export of the items ids. A file name ending with the extension of another format is written in that format instead. The built-in profile is validated first, the problems are shown before anything is written. */
+func NewExportExcelDialog(b *backend.Backend, w fyne.Window, profile *backend.ExportProfile, format backend.ExportFormat, ids []backend.ItemID) *dialog.FileDialog {
 	d := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
 		if writer != nil {
-			b.Items.ExportExcel(writer.URI().Path())
+			writer.Close()
+			p := writer.URI().Path()
+			if f, ok := backend.ExportFormatForPath(p); ok {
+				format = f
+			}
+			if !profile.BuiltIn {
+				if err := profile.ExportItems(p, format, ids); err != nil {
+					dialog.ShowError(err, w)
+				}
+				return
+			}
+			report, err := backend.ValidateItems(ids)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if report.Count(backend.SeverityError) == 0 {
+				if err := b.Items.Export(p, format, ids, false); err != nil {
+					dialog.ShowError(err, w)
+				}
+				return
+			}
+			NewValidationDialog(report, w, func() {
+				if err := b.Items.Export(p, format, ids, true); err != nil {
+					dialog.ShowError(err, w)
+				}
+			}).Show()
 		} else {
 			return
 		}
 	}, w)
 	d.Resize(fyne.NewSize(900, 600))
-	d.SetTitleText(lang.X("dialog.save.excel.title", "dialog.save.excel.title"))
+	d.SetTitleText(fmt.Sprintf(lang.X("dialog.save.export.title", "dialog.save.export.title"), format.LString()))
 	d.SetConfirmText(lang.L("Export"))
 	d.SetDismissText(lang.L("Close"))
-	d.SetFileName("UppSpar-" + time.Now().Format("20060102-150405") + ".xlsx")
-	d.SetFilter(storage.NewMimeTypeFileFilter([]string{"application/excel"}))
+	d.SetFileName("UppSpar-" + time.Now().Format("20060102-150405") + format.Extension())
+	d.SetFilter(storage.NewExtensionFileFilter([]string{format.Extension()}))
+	return d
+}
+
+/* Lists the problems found before an export and runs export if the user chooses to export anyway */
+func NewValidationDialog(report *backend.ValidationReport, w fyne.Window, export func()) *dialog.ConfirmDialog {
+	type line struct {
+		item  backend.ItemValidation
+		issue backend.Issue
+	}
+	var lines []line
+	for _, item := range report.Items {
+		for _, issue := range item.Issues {
+			lines = append(lines, line{item, issue})
+		}
+	}
+	issues := widget.NewList(
+		func() int { return len(lines) },
+		func() fyne.CanvasObject {
+			co := midget.NewLabel("Template issue message", "0000000", "")
+			co.SetTop()
+			return co
+		},
+		func(id widget.ListItemID, co fyne.CanvasObject) {
+			l := lines[id]
+			co.(*midget.Label).SetText(l.issue.String())
+			co.(*midget.Label).SetSubtext(fmt.Sprintf("%s : %s : %s", l.item.ItemID, strings.ToUpper(l.issue.Severity.LString()), l.item.Name))
+		},
+	)
+	summary := widget.NewLabel(fmt.Sprintf(lang.X("validation.summary", "validation.summary"),
+		report.Checked, report.Count(backend.SeverityError), report.Count(backend.SeverityWarning)))
+	content := container.NewBorder(summary, nil, nil, nil, issues)
+	d := dialog.NewCustomConfirm(lang.X("dialog.validation.title", "dialog.validation.title"),
+		lang.X("validation.export.anyway", "validation.export.anyway"), lang.L("Close"), content, func(ok bool) {
+			if ok {
+				export()
+			}
+		}, w)
+	d.Resize(fyne.NewSize(900, 600))
+	return d
+}
+
+func NewImportExcelDialog(b *backend.Backend, w fyne.Window) *dialog.FileDialog {
+	d := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if reader == nil {
+			return
+		}
+		reader.Close()
+		NewImportPreviewDialog(b, w, reader.URI().Path()).Show()
+	}, w)
+	d.Resize(fyne.NewSize(900, 600))
+	d.SetTitleText(lang.X("dialog.open.excel.title", "dialog.open.excel.title"))
+	d.SetConfirmText(lang.L("Open"))
+	d.SetDismissText(lang.L("Close"))
+	d.SetFilter(storage.NewExtensionFileFilter([]string{".xlsx"}))
+	return d
+}
+
+/* Shows what importing the spreadsheet at p would do, row by row, and imports it when confirmed */
+func NewImportPreviewDialog(b *backend.Backend, w fyne.Window, p string) *dialog.CustomDialog {
+	var d *dialog.CustomDialog
+	var report *backend.ImportReport
+	modes := []backend.ImportMode{backend.ImportCreate, backend.ImportUpdate, backend.ImportSkip}
+	modeLabel := func(m backend.ImportMode) string {
+		return lang.X("import.mode."+m.String(), "import.mode."+m.String())
+	}
+
+	summary := widget.NewLabel("")
+	rows := widget.NewList(
+		func() int {
+			if report == nil {
+				return 0
+			}
+			return len(report.Rows)
+		},
+		func() fyne.CanvasObject {
+			co := midget.NewLabel("Template item name", "0000000", "")
+			co.SetTop()
+			return co
+		},
+		func(id widget.ListItemID, co fyne.CanvasObject) {
+			row := report.Rows[id]
+			text := row.Name
+			if row.Err != nil {
+				text = row.Err.Error()
+			}
+			sub := fmt.Sprintf("%s %d : %s", lang.L("Row"), row.Row, strings.ToUpper(row.Action.LString()))
+			if row.ItemID != 0 {
+				sub += " : " + row.ItemID.String()
+			}
+			co.(*midget.Label).SetText(text)
+			co.(*midget.Label).SetSubtext(sub)
+		},
+	)
+
+	mode := backend.ImportCreate
+	preview := func() {
+		var err error
+		report, err = backend.ImportExcel(p, mode, true)
+		summary.SetText(fmt.Sprintf(lang.X("import.summary", "import.summary"),
+			report.Count(backend.ImportCreated), report.Count(backend.ImportUpdated),
+			report.Count(backend.ImportSkipped), report.Count(backend.ImportFailed)))
+		if err != nil && report.Count(backend.ImportFailed) == 0 {
+			summary.SetText(err.Error())
+		}
+		rows.Refresh()
+	}
+
+	var options []string
+	for _, m := range modes {
+		options = append(options, modeLabel(m))
+	}
+	radio := widget.NewRadioGroup(options, func(s string) {
+		for _, m := range modes {
+			if modeLabel(m) == s {
+				mode = m
+			}
+		}
+		preview()
+	})
+	radio.Horizontal = true
+	radio.Required = true
+
+	importButton := widget.NewButton(lang.L("Import"), func() {
+		report, err := backend.ImportExcel(p, mode, false)
+		if n := report.Count(backend.ImportFailed); n > 0 {
+			dialog.ShowError(fmt.Errorf(lang.X("import.failed", "import.failed"), n), w)
+		} else if err != nil {
+			/* The file itself could not be read */
+			dialog.ShowError(err, w)
+		}
+		b.Items.QueueItemIDs()
+		d.Hide()
+	})
+	importButton.Importance = widget.HighImportance
+	closeButton := widget.NewButton(lang.L("Close"), func() { d.Hide() })
+
+	content := container.NewBorder(
+		container.NewVBox(widget.NewLabel(p), radio, summary),
+		nil, nil, nil,
+		rows,
+	)
+	d = dialog.NewCustomWithoutButtons(lang.X("dialog.import.preview.title", "dialog.import.preview.title"), content, w)
+	d.SetButtons([]fyne.CanvasObject{closeButton, importButton})
+	d.Resize(fyne.NewSize(900, 600))
+	radio.SetSelected(modeLabel(mode))
 	return d
 }
 
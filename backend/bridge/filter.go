@@ -0,0 +1,167 @@
+package bridge
+
+import (
+	"UppSpar/backend"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+
+	midget "github.com/assholehoff/fyne-midget"
+	ttw "github.com/dweymouth/fyne-tooltip/widget"
+)
+
+/* A select of the values of a filter field with the number of items each gives. Its first option clears the field. */
+type facetSelect struct {
+	*ttw.Select
+	field  binding.String
+	values []string // By option, "" for the first
+	typed  bool     // The field is also typed in an entry, a value that is not an option leaves the select empty
+}
+
+func newFacetSelect(field binding.String, typed bool) *facetSelect {
+	s := &facetSelect{field: field, typed: typed, values: []string{""}}
+	s.Select = ttw.NewSelect([]string{filterAllLabel()}, func(label string) {
+		for i, option := range s.Options {
+			if option != label {
+				continue
+			}
+			if current, _ := s.field.Get(); current != s.values[i] {
+				s.field.Set(s.values[i])
+			}
+			return
+		}
+	})
+	s.Select.Selected = filterAllLabel()
+	field.AddListener(binding.NewDataListener(func() { fyne.Do(s.show) }))
+	return s
+}
+
+/* Replaces the options, leaving out those without items except the current value */
+func (s *facetSelect) setOptions(options []backend.FacetOption) {
+	current, _ := s.field.Get()
+	labels, values := []string{filterAllLabel()}, []string{""}
+	found := current == ""
+	for _, o := range options {
+		selected := strings.EqualFold(o.Value, current)
+		if o.Count == 0 && !selected {
+			continue
+		}
+		found = found || selected
+		labels = append(labels, strings.Repeat("    ", o.Depth)+fmt.Sprintf(lang.X("filter.option", "filter.option"), o.Label, o.Count))
+		values = append(values, o.Value)
+	}
+	if !found && !s.typed {
+		labels = append(labels, fmt.Sprintf(lang.X("filter.option", "filter.option"), current, 0))
+		values = append(values, current)
+	}
+	s.Options, s.values = labels, values
+	s.show()
+}
+
+/* Shows the option of the current value of the field without calling OnChanged */
+func (s *facetSelect) show() {
+	current, _ := s.field.Get()
+	s.Select.Selected = ""
+	for i, value := range s.values {
+		if strings.EqualFold(value, current) {
+			s.Select.Selected = s.Options[i]
+			break
+		}
+	}
+	s.Refresh()
+}
+
+func filterAllLabel() string {
+	return lang.X("filter.all", "filter.all")
+}
+
+/*
+The filter of the items list. Category, manufacturer and status offer the values that give items with the current search
+and the rest of the filter, with how many items each gives. Measurements are typed as ranges or chosen among buckets.
+*/
+func NewFilterPanel(b *backend.Backend, w fyne.Window) *Tools {
+	t := &Tools{
+		Entry:  make(Entries),
+		Select: make(Selects),
+	}
+	f := b.Items.Filter
+	facets := map[string]*facetSelect{
+		"Category":     newFacetSelect(f.Category, false),
+		"Manufacturer": newFacetSelect(f.Manufacturer, false),
+		"Status":       newFacetSelect(f.Status, false),
+	}
+	measurements := []struct {
+		key   string
+		field binding.String
+	}{
+		{"Width", f.Width},
+		{"Height", f.Height},
+		{"Depth", f.Depth},
+		{"Volume", f.Volume},
+		{"Weight", f.Weight},
+	}
+	form := widget.NewForm(
+		widget.NewFormItem(lang.X("item.form.label.category", "item.form.label.category"), facets["Category"]),
+		widget.NewFormItem(lang.X("item.form.label.manufacturer", "item.form.label.manufacturer"), facets["Manufacturer"]),
+		widget.NewFormItem(lang.X("item.form.label.status", "item.form.label.status"), facets["Status"]),
+	)
+	for _, m := range measurements {
+		facets[m.key] = newFacetSelect(m.field, true)
+		t.Entry[m.key] = midget.NewEntry()
+		t.Entry[m.key].Bind(m.field)
+		key := "item.form.label." + strings.ToLower(m.key)
+		form.Append(lang.X(key, key), container.NewGridWithColumns(2, t.Entry[m.key], facets[m.key]))
+	}
+	for key, s := range facets {
+		t.Select[key] = s.Select
+	}
+
+	var mu sync.Mutex
+	var cancel context.CancelFunc
+	reload := func() {
+		mu.Lock()
+		if cancel != nil {
+			cancel()
+		}
+		ctx, c := context.WithCancel(context.Background())
+		cancel = c
+		mu.Unlock()
+
+		v, err := b.Items.Facets(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Println(err)
+			}
+			return
+		}
+		fyne.Do(func() {
+			facets["Category"].setOptions(v.Categories)
+			facets["Manufacturer"].setOptions(v.Manufacturers)
+			facets["Status"].setOptions(v.Statuses)
+			for _, m := range measurements {
+				facets[m.key].setOptions(v.Buckets[m.key])
+			}
+		})
+	}
+
+	/* Counts are refreshed once the items list has settled */
+	var timer *time.Timer
+	b.Items.ItemIDList.AddListener(binding.NewDataListener(func() {
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.AfterFunc(300*time.Millisecond, reload)
+	}))
+
+	t.Container = container.NewBorder(nil, nil, nil, nil, container.NewVScroll(form))
+	return t
+}
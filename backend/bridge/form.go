@@ -9,6 +9,7 @@ import (
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
 	midget "github.com/assholehoff/fyne-midget"
@@ -23,6 +24,9 @@ type Form struct {
 	Radio     Radios
 	Select    Selects
 	Value     Labels
+
+	status *ttw.Button // Opens the status dialog of item
+	item   backend.ItemID
 }
 
 func NewItemForm(b *backend.Backend, w fyne.Window) *Form {
@@ -104,6 +108,14 @@ func NewItemForm(b *backend.Backend, w fyne.Window) *Form {
 	f.Value["AddDesc"].Wrapping = fyne.TextWrapWord
 	f.Value.Set(ItemFormValueStrings)
 
+	f.status = ttw.NewButtonWithIcon("", theme.MoreHorizontalIcon(), func() {
+		if f.item != 0 {
+			ShowStatusDialog(b, w, f.item)
+		}
+	})
+	f.status.SetToolTip(lang.X("status.title", "status.title"))
+	f.status.Disable()
+
 	f.Value["DateCreated"].Hide()
 	f.Value["DateModified"].Hide()
 	f.Value["AddDesc"].Hide()
@@ -214,6 +226,7 @@ func (f *Form) LoadItem(b *backend.Backend, id backend.ItemID) {
 	f.Value["DateModified"].Bind(id.Item().DateModified)
 	f.Value["AddDesc"].Bind(id.Item().AddDesc)
 	f.Value["LongDesc"].Bind(id.Item().LongDesc)
+	f.Value["StatusInfo"].Bind(id.Item().StatusInfo)
 
 	f.Value["DateCreated"].Show()
 	f.Value["DateModified"].Show()
@@ -245,6 +258,8 @@ func (f *Form) LoadItem(b *backend.Backend, id backend.ItemID) {
 
 	/* Select widgets */
 	f.Select["Status"].Bind(id.Item().ItemStatus)
+	f.item = id
+	f.status.Enable()
 	f.Select["Category"].Bind(id.Item().Category)
 	f.Select["Manufacturer"].Bind(id.Item().Manufacturer)
 	f.Select["ModelName"].Bind(id.Item().ModelName)
@@ -311,7 +326,7 @@ func (f *Form) itemContainer() *fyne.Container {
 		layout.NewFormLayout(),
 		layout.NewSpacer(), container.NewHBox(f.Label["DateCreated"], f.Value["DateCreated"]),
 		layout.NewSpacer(), container.NewHBox(f.Label["DateModified"], f.Value["DateModified"]),
-		f.Label["ItemID"], container.NewHBox(f.Value["ItemID"], f.Select["Status"]),
+		f.Label["ItemID"], container.NewHBox(f.Value["ItemID"], f.Select["Status"], f.status, f.Value["StatusInfo"]),
 		f.Label["Name"], f.Entry["Name"],
 		f.Label["Category"], f.Select["Category"],
 		f.Label["Manufacturer"], f.Select["Manufacturer"],
@@ -0,0 +1,86 @@
+package bridge
+
+import (
+	"UppSpar/backend"
+	"UppSpar/backend/journal"
+	"fmt"
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	midget "github.com/assholehoff/fyne-midget"
+	ttw "github.com/dweymouth/fyne-tooltip/widget"
+)
+
+/* The field changes of the item in the form, newest first, each of which can be set back to the value it had before */
+type History struct {
+	Container *fyne.Container
+	list      *widget.List
+	id        backend.ItemID
+	changes   []journal.Change
+}
+
+func NewHistoryPanel(b *backend.Backend, w fyne.Window) *History {
+	h := &History{}
+	h.list = widget.NewList(
+		func() int {
+			return len(h.changes)
+		},
+		func() fyne.CanvasObject {
+			label := midget.NewLabel("Template column: old value → new value", "2006-01-02 15:04:05", "")
+			label.SetTop()
+			restore := ttw.NewButtonWithIcon("", theme.HistoryIcon(), func() {})
+			restore.SetToolTip(lang.X("history.restore", "history.restore"))
+			return container.NewBorder(nil, nil, nil, restore, label)
+		},
+		func(id widget.ListItemID, co fyne.CanvasObject) {
+			c := h.changes[id]
+			label := co.(*fyne.Container).Objects[0].(*midget.Label)
+			restore := co.(*fyne.Container).Objects[1].(*ttw.Button)
+			label.SetText(fmt.Sprintf(lang.X("history.change", "history.change"), historyColumn(c.Column), journal.FormatValue(c.Old), journal.FormatValue(c.New)))
+			label.SetSubtext(c.Time.Format("2006-01-02 15:04:05"))
+			restore.OnTapped = func() {
+				if err := backend.Restore(c); err != nil {
+					log.Println(err)
+					dialog.ShowError(err, w)
+				}
+			}
+		},
+	)
+	b.Journal.Changes.AddListener(binding.NewDataListener(h.reload))
+	h.Container = container.NewBorder(nil, nil, nil, nil, h.list)
+	return h
+}
+
+/* Shows the changes of item id */
+func (h *History) LoadItem(id backend.ItemID) {
+	h.id = id
+	h.reload()
+}
+
+func (h *History) reload() {
+	if h.id == 0 {
+		return
+	}
+	changes, err := h.id.History()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	h.changes = changes
+	h.list.Refresh()
+}
+
+/* Returns the form label of a column, or the column name if the form has none */
+func historyColumn(column string) string {
+	if label, ok := ItemFormLabelStrings[column]; ok {
+		return label
+	}
+	return column
+}
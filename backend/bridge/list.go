@@ -42,11 +42,16 @@ func NewList(b *backend.Backend, w fyne.Window) *List {
 			}
 			ItemID := val.(backend.ItemID)
 			subtext := binding.NewString()
+			snippet := b.Items.Snippet(ItemID)
 
 			ItemID.Item().Category.AddListener(binding.NewDataListener(func() {
 				id, _ := ItemID.Item().ItemIDString.Get()
 				cat, _ := ItemID.Item().Category.Get()
-				subtext.Set(fmt.Sprintf("%s : %s", id, strings.TrimSpace(strings.ToUpper(cat))))
+				s := fmt.Sprintf("%s : %s", id, strings.TrimSpace(strings.ToUpper(cat)))
+				if snippet != "" {
+					s += " : " + snippet
+				}
+				subtext.Set(s)
 			}))
 
 			co.(*midget.Label).BindText(ItemID.Item().Name)
@@ -140,12 +145,22 @@ func NewList(b *backend.Backend, w fyne.Window) *List {
 			toolbar.Items[3].(*widget.ToolbarAction).Disable()
 			go func() {
 				fyne.Do(func() {
-					NewExportExcelDialog(b, w).Show()
+					NewExportDialog(b, w).Show()
 					time.Sleep(100 * time.Millisecond)
 					toolbar.Items[3].(*widget.ToolbarAction).Enable()
 				})
 			}()
 		}),
+		widget.NewToolbarAction(theme.UploadIcon(), func() {
+			toolbar.Items[4].(*widget.ToolbarAction).Disable()
+			go func() {
+				fyne.Do(func() {
+					NewImportExcelDialog(b, w).Show()
+					time.Sleep(100 * time.Millisecond)
+					toolbar.Items[4].(*widget.ToolbarAction).Enable()
+				})
+			}()
+		}),
 	)
 
 	statbar := widget.NewLabel("List/Tree statusbar")
@@ -0,0 +1,262 @@
+package bridge
+
+import (
+	"UppSpar/backend"
+	"fmt"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+/* Lets the user pick an export profile, a file format and which items to export, manage the stored profiles, and continue to the save dialog */
+func NewExportDialog(b *backend.Backend, w fyne.Window) *dialog.CustomDialog {
+	var d *dialog.CustomDialog
+	var profiles []*backend.ExportProfile
+	var selected *backend.ExportProfile
+
+	sel := widget.NewSelect([]string{}, func(string) {})
+	editButton := widget.NewButton(lang.L("Edit"), func() {})
+	deleteButton := widget.NewButton(lang.L("Delete"), func() {})
+
+	reload := func(name string) {
+		var err error
+		profiles, err = backend.ExportProfiles()
+		if err != nil {
+			dialog.ShowError(err, w)
+		}
+		var names []string
+		for _, p := range profiles {
+			names = append(names, p.Name)
+		}
+		sel.SetOptions(names)
+		if !slices.Contains(names, name) {
+			name = names[0]
+		}
+		sel.SetSelected(name)
+	}
+	sel.OnChanged = func(s string) {
+		for _, p := range profiles {
+			if p.Name == s {
+				selected = p
+			}
+		}
+		if selected.BuiltIn {
+			editButton.Disable()
+			deleteButton.Disable()
+		} else {
+			editButton.Enable()
+			deleteButton.Enable()
+		}
+	}
+	editButton.OnTapped = func() {
+		NewExportProfileDialog(w, selected, reload).Show()
+	}
+	newButton := widget.NewButton(lang.L("New"), func() {
+		name := fmt.Sprintf(lang.X("profile.copy.name", "profile.copy.name"), selected.Name)
+		NewExportProfileDialog(w, selected.Copy(name), reload).Show()
+	})
+	deleteButton.OnTapped = func() {
+		dialog.ShowConfirm(lang.X("profile.delete.title", "profile.delete.title"),
+			fmt.Sprintf(lang.X("profile.delete.confirm", "profile.delete.confirm"), selected.Name), func(ok bool) {
+				if !ok {
+					return
+				}
+				if err := selected.Delete(); err != nil {
+					dialog.ShowError(err, w)
+				}
+				reload("")
+			}, w)
+	}
+
+	format := backend.FormatXLSX
+	var formats []string
+	for _, f := range backend.ExportFormats {
+		formats = append(formats, f.LString())
+	}
+	formatSelect := widget.NewSelect(formats, func(s string) {
+		for _, f := range backend.ExportFormats {
+			if f.LString() == s {
+				format = f
+			}
+		}
+	})
+	formatSelect.SetSelected(format.LString())
+
+	scope := backend.ScopeProfile
+	scopeLabel := func(s backend.ExportScope) string {
+		if s == backend.ScopeProfile {
+			return s.LString()
+		}
+		ids, _ := b.Items.ScopeItemIDs(s, nil)
+		return fmt.Sprintf(s.LString(), len(ids))
+	}
+	var scopes []string
+	for _, s := range backend.ExportScopes {
+		scopes = append(scopes, scopeLabel(s))
+	}
+	scopeRadio := widget.NewRadioGroup(scopes, func(str string) {
+		for _, s := range backend.ExportScopes {
+			if scopeLabel(s) == str {
+				scope = s
+			}
+		}
+	})
+	scopeRadio.Required = true
+	scopeRadio.SetSelected(scopeLabel(scope))
+
+	exportButton := widget.NewButton(lang.L("Export"), func() {
+		ids, err := b.Items.ScopeItemIDs(scope, selected)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		d.Hide()
+		NewExportExcelDialog(b, w, selected, format, ids).Show()
+	})
+	exportButton.Importance = widget.HighImportance
+	closeButton := widget.NewButton(lang.L("Close"), func() { d.Hide() })
+
+	content := container.NewVBox(
+		widget.NewLabel(lang.L("Export profile")),
+		container.NewBorder(nil, nil, nil, container.NewHBox(newButton, editButton, deleteButton), sel),
+		widget.NewLabel(lang.L("Format")),
+		formatSelect,
+		widget.NewLabel(lang.L("Items")),
+		scopeRadio,
+	)
+	d = dialog.NewCustomWithoutButtons(lang.X("dialog.export.title", "dialog.export.title"), content, w)
+	d.SetButtons([]fyne.CanvasObject{closeButton, exportButton})
+	d.Resize(fyne.NewSize(600, 400))
+	reload("")
+	return d
+}
+
+/* Edits the name, sheet name, status filter and columns of a copy of profile. The copy is stored on save and saved is called with its name. */
+func NewExportProfileDialog(w fyne.Window, profile *backend.ExportProfile, saved func(string)) *dialog.CustomDialog {
+	var d *dialog.CustomDialog
+	p := *profile
+	p.Columns = slices.Clone(profile.Columns)
+
+	name := widget.NewEntry()
+	name.SetText(p.Name)
+	sheet := widget.NewEntry()
+	sheet.SetText(p.SheetName)
+
+	statuses := []backend.ItemStatusID{0, backend.ItemStatusAvailable, backend.ItemStatusReserved, backend.ItemStatusSold, backend.ItemStatusArchived}
+	statusLabel := func(s backend.ItemStatusID) string {
+		if s == 0 {
+			return lang.X("profile.status.all", "profile.status.all")
+		}
+		return s.LString()
+	}
+	var options []string
+	for _, s := range statuses {
+		options = append(options, statusLabel(s))
+	}
+	status := widget.NewSelect(options, func(str string) {
+		for _, s := range statuses {
+			if statusLabel(s) == str {
+				p.ItemStatusID = s
+			}
+		}
+	})
+	status.SetSelected(statusLabel(p.ItemStatusID))
+
+	sources, err := backend.ExportSources()
+	if err != nil {
+		dialog.ShowError(err, w)
+	}
+	var formats []string
+	for _, f := range backend.CellFormats {
+		formats = append(formats, f.LString())
+	}
+
+	rows := container.NewVBox()
+	scroll := container.NewVScroll(rows)
+	var rebuild func()
+	rebuild = func() {
+		rows.RemoveAll()
+		for i := range p.Columns {
+			c := &p.Columns[i]
+			header := widget.NewEntry()
+			header.SetText(c.Header)
+			header.OnChanged = func(s string) { c.Header = s }
+			source := widget.NewSelectEntry(sources)
+			source.SetText(c.Source)
+			source.OnChanged = func(s string) { c.Source = s }
+			format := widget.NewSelect(formats, func(s string) {
+				for _, f := range backend.CellFormats {
+					if f.LString() == s {
+						c.Format = f
+					}
+				}
+			})
+			format.SetSelected(c.Format.LString())
+			up := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+				if i > 0 {
+					p.Columns[i-1], p.Columns[i] = p.Columns[i], p.Columns[i-1]
+					rebuild()
+				}
+			})
+			down := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() {
+				if i < len(p.Columns)-1 {
+					p.Columns[i+1], p.Columns[i] = p.Columns[i], p.Columns[i+1]
+					rebuild()
+				}
+			})
+			remove := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				p.Columns = slices.Delete(p.Columns, i, i+1)
+				rebuild()
+			})
+			rows.Add(container.NewBorder(nil, nil, nil, container.NewHBox(format, up, down, remove),
+				container.NewGridWithColumns(2, header, source)))
+		}
+	}
+	rebuild()
+
+	addButton := widget.NewButtonWithIcon(lang.X("profile.column.add", "profile.column.add"), theme.ContentAddIcon(), func() {
+		p.Columns = append(p.Columns, backend.ExportColumn{})
+		rebuild()
+		scroll.ScrollToBottom()
+	})
+
+	form := widget.NewForm(
+		widget.NewFormItem(lang.X("profile.name", "profile.name"), name),
+		widget.NewFormItem(lang.X("profile.sheet", "profile.sheet"), sheet),
+		widget.NewFormItem(lang.X("profile.status", "profile.status"), status),
+	)
+	headings := container.NewGridWithColumns(2,
+		widget.NewLabel(lang.X("profile.column.header", "profile.column.header")),
+		widget.NewLabel(lang.X("profile.column.source", "profile.column.source")),
+	)
+	help := widget.NewLabel(lang.X("profile.source.help", "profile.source.help"))
+	help.Wrapping = fyne.TextWrapWord
+
+	saveButton := widget.NewButton(lang.L("Save"), func() {
+		p.Name = name.Text
+		p.SheetName = sheet.Text
+		if err := p.Save(); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		saved(p.Name)
+		d.Hide()
+	})
+	saveButton.Importance = widget.HighImportance
+	closeButton := widget.NewButton(lang.L("Close"), func() { d.Hide() })
+
+	content := container.NewBorder(
+		container.NewVBox(form, help, headings),
+		addButton, nil, nil,
+		scroll,
+	)
+	d = dialog.NewCustomWithoutButtons(lang.X("dialog.profile.title", "dialog.profile.title"), content, w)
+	d.SetButtons([]fyne.CanvasObject{closeButton, saveButton})
+	d.Resize(fyne.NewSize(900, 700))
+	return d
+}
@@ -0,0 +1,73 @@
+package bridge
+
+import (
+	"UppSpar/backend"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+/* The revenue, items sold per category and average time to sale of a period, the current month to begin with */
+type SalesReport struct {
+	Container *fyne.Container
+	from, to  *widget.Entry
+	text      *widget.RichText
+}
+
+func NewSalesReport(b *backend.Backend, w fyne.Window) *SalesReport {
+	r := &SalesReport{
+		from: widget.NewEntry(),
+		to:   widget.NewEntry(),
+		text: widget.NewRichText(),
+	}
+	now := time.Now()
+	r.from.SetText(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local).Format(time.DateOnly))
+	r.to.SetText(now.Format(time.DateOnly))
+	r.text.Wrapping = fyne.TextWrapWord
+
+	show := widget.NewButton(lang.X("report.show", "report.show"), func() {
+		if err := r.show(); err != nil {
+			dialog.ShowError(err, w)
+		}
+	})
+	period := container.NewHBox(
+		widget.NewLabel(lang.X("report.from", "report.from")), r.from,
+		widget.NewLabel(lang.X("report.to", "report.to")), r.to,
+		show,
+	)
+	r.Container = container.NewBorder(period, nil, nil, nil, container.NewVScroll(r.text))
+	return r
+}
+
+func (r *SalesReport) show() error {
+	from, err := time.ParseInLocation(time.DateOnly, strings.TrimSpace(r.from.Text), time.Local)
+	if err != nil {
+		return err
+	}
+	to, err := time.ParseInLocation(time.DateOnly, strings.TrimSpace(r.to.Text), time.Local)
+	if err != nil {
+		return err
+	}
+	report, err := backend.GetSalesReport(from, to)
+	if err != nil {
+		return err
+	}
+	var md strings.Builder
+	fmt.Fprintf(&md, "## %s\n\n", lang.X("report.revenue", "report.revenue"))
+	for _, v := range report.Revenue {
+		fmt.Fprintf(&md, "- "+lang.X("report.revenue.row", "report.revenue.row")+"\n", v.Amount, v.Currency, v.VatAmount, v.Currency)
+	}
+	fmt.Fprintf(&md, "\n## %s\n\n", lang.X("report.categories", "report.categories"))
+	for _, c := range report.Categories {
+		fmt.Fprintf(&md, "- %s: %s\n", c.Name, formatQuantity(c.Quantity))
+	}
+	fmt.Fprintf(&md, "\n"+lang.X("report.summary", "report.summary")+"\n", formatQuantity(report.Quantity), report.Sales, report.AverageDays)
+	r.text.ParseMarkdown(md.String())
+	return nil
+}
@@ -0,0 +1,242 @@
+package bridge
+
+import (
+	"UppSpar/backend"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	midget "github.com/assholehoff/fyne-midget"
+)
+
+/* The sales, returns and corrections of the item in the form, newest first, from where it is sold and sales are returned or corrected */
+type Sales struct {
+	Container *fyne.Container
+	list      *widget.List
+	toolbar   *widget.Toolbar
+	id        backend.ItemID
+	sales     []backend.Sale
+	selected  int
+}
+
+func NewSalesPanel(b *backend.Backend, w fyne.Window) *Sales {
+	s := &Sales{selected: -1}
+	s.list = widget.NewList(
+		func() int {
+			return len(s.sales)
+		},
+		func() fyne.CanvasObject {
+			co := midget.NewLabel("Template sale 1: 1 × 0.00 SEK = 0.00 SEK", "2006-01-02 15:04 : buyer : reason", "")
+			co.SetTop()
+			return co
+		},
+		func(id widget.ListItemID, co fyne.CanvasObject) {
+			sale := s.sales[id]
+			co.(*midget.Label).SetText(fmt.Sprintf(lang.X("sale.row", "sale.row"), saleKind(sale.Kind.String()), sale.SaleID,
+				formatQuantity(sale.Quantity), sale.Price, sale.Currency, sale.Amount, sale.VatAmount))
+			subtext := []string{sale.Time.Local().Format("2006-01-02 15:04")}
+			for _, v := range []string{sale.Buyer, sale.Reason} {
+				if v != "" {
+					subtext = append(subtext, v)
+				}
+			}
+			co.(*midget.Label).SetSubtext(strings.Join(subtext, " : "))
+		},
+	)
+	s.list.OnSelected = func(id widget.ListItemID) { s.selected = id }
+	s.list.OnUnselected = func(id widget.ListItemID) { s.selected = -1 }
+
+	selectedSale := func() (*backend.Sale, bool) {
+		if s.selected < 0 || s.selected >= len(s.sales) {
+			return nil, false
+		}
+		sale, err := backend.GetSale(s.sales[s.selected].SaleID)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return nil, false
+		}
+		return sale, true
+	}
+	s.toolbar = widget.NewToolbar(
+		widget.NewToolbarAction(theme.ContentAddIcon(), func() {
+			if s.id != 0 {
+				s.showSell(w)
+			}
+		}),
+		widget.NewToolbarAction(theme.ContentUndoIcon(), func() {
+			if sale, ok := selectedSale(); ok {
+				s.showReturn(w, sale)
+			}
+		}),
+		widget.NewToolbarAction(theme.DocumentCreateIcon(), func() {
+			if sale, ok := selectedSale(); ok {
+				s.showCorrect(w, sale)
+			}
+		}),
+	)
+
+	/* Every sale, return and correction is written to the journal, also those the status dialog makes */
+	b.Journal.List.AddListener(binding.NewDataListener(s.reload))
+
+	s.Container = container.NewBorder(container.NewBorder(nil, nil, nil, s.toolbar), nil, nil, nil, s.list)
+	return s
+}
+
+/* Shows the sales of item id */
+func (s *Sales) LoadItem(id backend.ItemID) {
+	s.id = id
+	s.reload()
+}
+
+func (s *Sales) reload() {
+	if s.id == 0 {
+		return
+	}
+	sales, err := s.id.Sales()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	s.sales = sales
+	s.selected = -1
+	s.list.UnselectAll()
+	s.list.Refresh()
+}
+
+/* Sells some of the stock of the item, at its price and VAT unless others are given */
+func (s *Sales) showSell(w fyne.Window) {
+	id := s.id
+	stock, _ := id.Stock()
+	price, _ := id.Price()
+	vat, _ := id.Vat()
+	e := newSaleEntries(1, price, vat, "")
+	e.date.SetText(time.Now().Format(time.DateOnly))
+	items := append(e.formItems(), widget.NewFormItem(lang.X("status.sold", "status.sold"), e.date))
+	title := fmt.Sprintf(lang.X("sale.sell.title", "sale.sell.title"), formatQuantity(stock))
+	dialog.ShowForm(title, lang.L("Save"), lang.L("Close"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		sale, err := e.sale()
+		if err == nil {
+			sale.Time, err = time.ParseInLocation(time.DateOnly, strings.TrimSpace(e.date.Text), time.Local)
+		}
+		if err == nil {
+			_, err = id.Sell(sale)
+		}
+		if err != nil {
+			dialog.ShowError(err, w)
+		}
+	}, w)
+}
+
+/* Returns some of what is left of sale */
+func (s *Sales) showReturn(w fyne.Window, sale *backend.Sale) {
+	quantity := widget.NewEntry()
+	quantity.SetText(formatQuantity(sale.Quantity))
+	reason := widget.NewEntry()
+	title := fmt.Sprintf(lang.X("sale.return.title", "sale.return.title"), sale.ID)
+	dialog.ShowForm(title, lang.L("Save"), lang.L("Close"), []*widget.FormItem{
+		widget.NewFormItem(lang.X("sale.quantity", "sale.quantity"), quantity),
+		widget.NewFormItem(lang.X("status.reason", "status.reason"), reason),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		q, err := parseNumber(quantity.Text)
+		if err == nil {
+			_, err = backend.ReturnSale(sale.ID, q, strings.TrimSpace(reason.Text))
+		}
+		if err != nil {
+			dialog.ShowError(err, w)
+		}
+	}, w)
+}
+
+/* Corrects the quantity, price, VAT and buyer of sale */
+func (s *Sales) showCorrect(w fyne.Window, sale *backend.Sale) {
+	e := newSaleEntries(sale.Quantity, sale.Price, sale.Vat, sale.Buyer)
+	title := fmt.Sprintf(lang.X("sale.correct.title", "sale.correct.title"), sale.ID)
+	dialog.ShowForm(title, lang.L("Save"), lang.L("Close"), e.formItems(), func(ok bool) {
+		if !ok {
+			return
+		}
+		c, err := e.sale()
+		if err == nil {
+			c.SaleID = sale.ID
+			_, err = backend.CorrectSale(c)
+		}
+		if err != nil {
+			dialog.ShowError(err, w)
+		}
+	}, w)
+}
+
+/* The entries of a sale in the sell and correct dialogs */
+type saleEntries struct {
+	quantity, price, vat, buyer, reason, date *widget.Entry
+}
+
+func newSaleEntries(quantity, price, vat float64, buyer string) *saleEntries {
+	e := &saleEntries{}
+	for _, entry := range []**widget.Entry{&e.quantity, &e.price, &e.vat, &e.buyer, &e.reason, &e.date} {
+		*entry = widget.NewEntry()
+	}
+	e.quantity.SetText(formatQuantity(quantity))
+	e.price.SetText(strconv.FormatFloat(price, 'f', 2, 64))
+	e.vat.SetText(formatQuantity(vat))
+	e.buyer.SetText(buyer)
+	return e
+}
+
+func (e *saleEntries) formItems() []*widget.FormItem {
+	return []*widget.FormItem{
+		widget.NewFormItem(lang.X("sale.quantity", "sale.quantity"), e.quantity),
+		widget.NewFormItem(lang.X("sale.price", "sale.price"), e.price),
+		widget.NewFormItem(lang.X("item.form.label.vat", "item.form.label.vat"), e.vat),
+		widget.NewFormItem(lang.X("sale.buyer", "sale.buyer"), e.buyer),
+		widget.NewFormItem(lang.X("status.reason", "status.reason"), e.reason),
+	}
+}
+
+func (e *saleEntries) sale() (backend.Sale, error) {
+	var s backend.Sale
+	var err error
+	if s.Quantity, err = parseNumber(e.quantity.Text); err != nil {
+		return s, err
+	}
+	if s.Price, err = parseNumber(e.price.Text); err != nil {
+		return s, err
+	}
+	if s.Vat, err = parseNumber(e.vat.Text); err != nil {
+		return s, err
+	}
+	s.Buyer, s.Reason = strings.TrimSpace(e.buyer.Text), strings.TrimSpace(e.reason.Text)
+	return s, nil
+}
+
+/* Parses a number written with a decimal point or comma */
+func parseNumber(s string) (float64, error) {
+	return strconv.ParseFloat(strings.Replace(strings.TrimSpace(s), ",", ".", 1), 64)
+}
+
+/* Formats a quantity without decimals unless it has them */
+func formatQuantity(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+/* Returns the localized name of a kind of ledger row, "sale", "return" or "correction" */
+func saleKind(kind string) string {
+	key := "sale.kind." + kind
+	return lang.X(key, key)
+}
@@ -0,0 +1,127 @@
+package bridge
+
+import (
+	"UppSpar/backend"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+type SavedSearchList struct {
+	Container *fyne.Container
+	list      *widget.List
+	toolbar   *widget.Toolbar
+}
+
+/* Lists the saved searches as smart lists with the number of items matching each, tapping one applies it to the items list and the search bar */
+func NewSavedSearchList(b *backend.Backend, w fyne.Window, bar *Tools) *SavedSearchList {
+	var searches []*backend.SavedSearch
+	var counts []string
+	var selectedID int
+
+	selected := func() *backend.SavedSearch {
+		for _, s := range searches {
+			if s.SearchID == selectedID {
+				return s
+			}
+		}
+		return nil
+	}
+
+	list := widget.NewList(
+		func() int { return len(counts) },
+		func() fyne.CanvasObject { return widget.NewLabel("Template saved search (000)") },
+		func(id widget.ListItemID, co fyne.CanvasObject) { co.(*widget.Label).SetText(counts[id]) },
+	)
+
+	reload := func() {
+		s, err := backend.SavedSearches()
+		if err != nil {
+			fyne.Do(func() { dialog.ShowError(err, w) })
+		}
+		c := make([]string, len(s))
+		for i, search := range s {
+			n, err := search.Count()
+			if err != nil {
+				c[i] = search.Name
+				continue
+			}
+			c[i] = fmt.Sprintf(lang.X("search.saved.count", "search.saved.count"), search.Name, n)
+		}
+		fyne.Do(func() {
+			searches, counts = s, c
+			if selected() == nil {
+				selectedID = 0
+				list.UnselectAll()
+			}
+			list.Refresh()
+		})
+	}
+
+	/* Counts are refreshed once the items list has settled */
+	var timer *time.Timer
+	b.Items.ItemIDList.AddListener(binding.NewDataListener(func() {
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.AfterFunc(300*time.Millisecond, reload)
+	}))
+
+	list.OnSelected = func(id widget.ListItemID) {
+		selectedID = searches[id].SearchID
+		b.Items.ApplySearch(searches[id])
+		bar.Select["Match"].SetSelected(searchMatchLabel(searches[id].Match))
+		bar.Label["Sort"].SetText(sortLabel(searches[id].Sort))
+	}
+
+	toolbar := widget.NewToolbar(
+		widget.NewToolbarAction(theme.DocumentSaveIcon(), func() {
+			name := widget.NewEntry()
+			if s := selected(); s != nil {
+				name.SetText(s.Name)
+			}
+			dialog.ShowForm(lang.X("dialog.search.save.title", "dialog.search.save.title"), lang.L("Save"), lang.L("Close"),
+				[]*widget.FormItem{widget.NewFormItem(lang.X("search.save.name", "search.save.name"), name)},
+				func(ok bool) {
+					if !ok {
+						return
+					}
+					if err := b.Items.CurrentSearch(name.Text).Save(); err != nil {
+						dialog.ShowError(err, w)
+					}
+					go reload()
+				}, w)
+		}),
+		widget.NewToolbarAction(theme.DeleteIcon(), func() {
+			s := selected()
+			if s == nil {
+				return
+			}
+			dialog.ShowConfirm(lang.X("search.delete.title", "search.delete.title"),
+				fmt.Sprintf(lang.X("search.delete.confirm", "search.delete.confirm"), s.Name), func(ok bool) {
+					if !ok {
+						return
+					}
+					if err := s.Delete(); err != nil {
+						dialog.ShowError(err, w)
+					}
+					go reload()
+				}, w)
+		}),
+	)
+
+	go reload()
+
+	return &SavedSearchList{
+		Container: container.NewBorder(container.NewBorder(nil, nil, widget.NewLabel(lang.L("Saved searches")), toolbar), nil, nil, nil, list),
+		list:      list,
+		toolbar:   toolbar,
+	}
+}
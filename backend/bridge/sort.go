@@ -0,0 +1,110 @@
+package bridge
+
+import (
+	"UppSpar/backend"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+/* Edits the sort of the items list, one key and direction per row with the first row sorting first. Applying it lists the items again and calls changed. */
+func NewSortDialog(b *backend.Backend, w fyne.Window, changed func(backend.SortSpec)) *dialog.CustomDialog {
+	var d *dialog.CustomDialog
+	spec := slices.Clone(b.Items.Search.Sort)
+
+	keys, err := backend.SortKeys()
+	if err != nil {
+		dialog.ShowError(err, w)
+	}
+	var labels []string
+	for _, key := range keys {
+		labels = append(labels, sortKeyLabel(key))
+	}
+	orders := []string{
+		lang.X("form.select.sortorder.ascending", "form.select.sortorder.ascending"),
+		lang.X("form.select.sortorder.descending", "form.select.sortorder.descending"),
+	}
+
+	rows := container.NewVBox()
+	var rebuild func()
+	rebuild = func() {
+		rows.RemoveAll()
+		for i := range spec {
+			k := &spec[i]
+			key := widget.NewSelect(labels, func(s string) {
+				if i := slices.Index(labels, s); i >= 0 {
+					k.Key = keys[i]
+				}
+			})
+			key.SetSelected(sortKeyLabel(k.Key))
+			order := widget.NewSelect(orders, func(s string) {
+				k.Order = backend.SortOrder(slices.Index(orders, s))
+			})
+			order.SetSelected(orders[k.Order])
+			up := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+				if i > 0 {
+					spec[i-1], spec[i] = spec[i], spec[i-1]
+					rebuild()
+				}
+			})
+			down := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() {
+				if i < len(spec)-1 {
+					spec[i+1], spec[i] = spec[i], spec[i+1]
+					rebuild()
+				}
+			})
+			remove := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				spec = slices.Delete(spec, i, i+1)
+				rebuild()
+			})
+			rows.Add(container.NewBorder(nil, nil, nil, container.NewHBox(order, up, down, remove), key))
+		}
+	}
+	rebuild()
+
+	addButton := widget.NewButtonWithIcon(lang.X("sort.key.add", "sort.key.add"), theme.ContentAddIcon(), func() {
+		spec = append(spec, backend.SortKey{Key: "Name"})
+		rebuild()
+	})
+	applyButton := widget.NewButton(lang.L("Apply"), func() {
+		if len(spec) == 0 {
+			spec = slices.Clone(backend.DefaultSort)
+		}
+		b.Items.Search.Sort = spec
+		b.Items.QueueItemIDs()
+		changed(spec)
+		d.Hide()
+	})
+	applyButton.Importance = widget.HighImportance
+	closeButton := widget.NewButton(lang.L("Close"), func() { d.Hide() })
+
+	content := container.NewBorder(nil, addButton, nil, nil, container.NewVScroll(rows))
+	d = dialog.NewCustomWithoutButtons(lang.X("dialog.sort.title", "dialog.sort.title"), content, w)
+	d.SetButtons([]fyne.CanvasObject{closeButton, applyButton})
+	d.Resize(fyne.NewSize(600, 400))
+	return d
+}
+
+/* Returns the name of a sort key, the column name for columns without a translation */
+func sortKeyLabel(key string) string {
+	return lang.X("form.select.sortby."+strings.ToLower(key), key)
+}
+
+/* Returns the keys of spec with an arrow for the direction of each, as in Category ↑, Price ↓ */
+func sortLabel(spec backend.SortSpec) string {
+	var keys []string
+	for _, k := range spec {
+		if k.Order == backend.SortDescending {
+			keys = append(keys, sortKeyLabel(k.Key)+" ↓")
+		} else {
+			keys = append(keys, sortKeyLabel(k.Key)+" ↑")
+		}
+	}
+	return strings.Join(keys, ", ")
+}
@@ -0,0 +1,124 @@
+package bridge
+
+import (
+	"UppSpar/backend"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+/*
+Shows a dialog that gives item id one of the statuses its status can go to, with a reason, who it is reserved for and
+until when, or what it was sold for and when, above the status changes it has had
+*/
+func ShowStatusDialog(b *backend.Backend, w fyne.Window, id backend.ItemID) {
+	current, _ := id.ItemStatusID()
+	var next []backend.ItemStatusID
+	var options []string
+	for _, s := range backend.NextStatuses(current) {
+		if s == backend.ItemStatusDeleted {
+			continue // items are deleted to and restored from the trash
+		}
+		next = append(next, s)
+		options = append(options, s.LString())
+	}
+
+	reason := widget.NewEntry()
+	contact := widget.NewEntry()
+	expires := widget.NewEntry()
+	expires.SetPlaceHolder(time.DateOnly)
+	price := widget.NewEntry()
+	if p, err := id.Price(); err == nil {
+		price.SetText(strconv.FormatFloat(p, 'f', 2, 64))
+	}
+	sold := widget.NewEntry()
+	sold.SetText(time.Now().Format(time.DateOnly))
+
+	reservation := widget.NewForm(
+		widget.NewFormItem(lang.X("status.contact", "status.contact"), contact),
+		widget.NewFormItem(lang.X("status.expires", "status.expires"), expires),
+	)
+	sale := widget.NewForm(
+		widget.NewFormItem(lang.X("status.price", "status.price"), price),
+		widget.NewFormItem(lang.X("status.sold", "status.sold"), sold),
+	)
+	reservation.Hide()
+	sale.Hide()
+
+	to := backend.ItemStatusID(0)
+	status := widget.NewSelect(options, func(s string) {
+		for i, option := range options {
+			if option == s {
+				to = next[i]
+			}
+		}
+		reservation.Hidden = to != backend.ItemStatusReserved
+		sale.Hidden = to != backend.ItemStatusSold
+		reservation.Refresh()
+		sale.Refresh()
+	})
+
+	changes, err := id.StatusChanges()
+	if err != nil {
+		log.Println(err)
+	}
+	var lines []string
+	for _, c := range changes {
+		line := fmt.Sprintf(lang.X("status.change", "status.change"), c.Time.Local().Format("2006-01-02 15:04"),
+			backend.ItemStatusID(c.From).LString(), backend.ItemStatusID(c.To).LString())
+		if c.Reason != "" {
+			line += ": " + c.Reason
+		}
+		lines = append(lines, line)
+	}
+	history := widget.NewLabel(strings.Join(lines, "\n"))
+	history.Wrapping = fyne.TextWrapWord
+
+	content := container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem(lang.X("item.form.label.status", "item.form.label.status"), status),
+			widget.NewFormItem(lang.X("status.reason", "status.reason"), reason),
+		),
+		reservation,
+		sale,
+	)
+	if len(lines) > 0 {
+		content.Add(widget.NewSeparator())
+		content.Add(history)
+	}
+
+	d := dialog.NewCustomConfirm(lang.X("status.title", "status.title"), lang.L("Save"), lang.L("Close"), content, func(ok bool) {
+		if !ok || to == 0 {
+			return
+		}
+		s := backend.StatusChange{To: int(to), Reason: strings.TrimSpace(reason.Text)}
+		var err error
+		switch to {
+		case backend.ItemStatusReserved:
+			s.Contact = strings.TrimSpace(contact.Text)
+			if text := strings.TrimSpace(expires.Text); text != "" {
+				s.Expires, err = time.ParseInLocation(time.DateOnly, text, time.Local)
+			}
+		case backend.ItemStatusSold:
+			if s.Price, err = parseNumber(price.Text); err == nil {
+				s.Sold, err = time.ParseInLocation(time.DateOnly, strings.TrimSpace(sold.Text), time.Local)
+			}
+		}
+		if err == nil {
+			err = id.ChangeStatus(s)
+		}
+		if err != nil {
+			dialog.ShowError(err, w)
+		}
+	}, w)
+	d.Resize(fyne.NewSize(480, 0))
+	d.Show()
+}
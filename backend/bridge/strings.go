@@ -106,6 +106,7 @@ var (
 		"LongDesc",
 		"DateCreated",
 		"DateModified",
+		"StatusInfo",
 	}
 
 	ManufacturerFormCheckKeys  = []string{}
@@ -210,6 +211,7 @@ func initItemStringMaps() {
 	ItemFormValueStrings["DateModified"] = time.DateTime
 	ItemFormValueStrings["AddDesc"] = lang.X("item.form.label.adddesc", "item.form.label.adddesc")
 	ItemFormValueStrings["LongDesc"] = lang.X("item.form.label.longdesc", "item.form.label.longdesc")
+	ItemFormValueStrings["StatusInfo"] = ""
 }
 
 func initProductStringMaps() {
@@ -2,9 +2,17 @@ package bridge
 
 import (
 	"UppSpar/backend"
+	"slices"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	xwidget "fyne.io/x/fyne/widget"
+	ttw "github.com/dweymouth/fyne-tooltip/widget"
 )
 
 type Tools struct {
@@ -21,15 +29,79 @@ func NewTools(b *backend.Backend, w fyne.Window) *Tools {
 	return t
 }
 
+/* The search term, how it is matched, why it could not be used and how the items are sorted */
 func NewSearchBar(b *backend.Backend, w fyne.Window) *Tools {
-	c := container.NewBorder(nil, nil, nil, nil)
 	t := &Tools{
-		Container: c,
-		Check:     make(Checks),
-		Entry:     make(Entries),
-		Label:     make(Labels),
-		Radio:     make(Radios),
-		Select:    make(Selects),
+		Check:  make(Checks),
+		Entry:  make(Entries),
+		Label:  make(Labels),
+		Radio:  make(Radios),
+		Select: make(Selects),
+	}
+
+	/* Completions are offered while the term is typed, not when a saved search sets it */
+	term := xwidget.NewCompletionEntry(nil)
+	term.Bind(b.Items.Search.Term)
+	var typed bool
+	var list fyne.Focusable // What the completions focus while shown
+	term.OnChanged = func(s string) {
+		focused := w.Canvas().Focused()
+		typed = focused != nil && (focused == term || focused == list) && !slices.Contains(term.Options, s)
+	}
+	b.Items.Search.Completions.AddListener(binding.NewDataListener(func() {
+		options, _ := b.Items.Search.Completions.Get()
+		fyne.Do(func() {
+			term.SetOptions(options)
+			if !typed {
+				term.HideCompletion()
+				return
+			}
+			term.ShowCompletion()
+			list = w.Canvas().Focused()
+		})
+	}))
+
+	var options []string
+	for _, m := range backend.SearchTermMatches {
+		options = append(options, searchMatchLabel(m))
 	}
+	t.Select["Match"] = ttw.NewSelect(options, func(s string) {
+		for _, m := range backend.SearchTermMatches {
+			if searchMatchLabel(m) == s && b.Items.Search.Match != m {
+				b.Items.Search.Match = m
+				b.Items.QueueItemIDs()
+			}
+		}
+	})
+	t.Select["Match"].SetSelected(searchMatchLabel(b.Items.Search.Match))
+
+	t.Label["Error"] = ttw.NewLabelWithData(b.Items.Search.Error)
+	t.Label["Error"].Importance = widget.DangerImportance
+	t.Label["Error"].Hide()
+	b.Items.Search.Error.AddListener(binding.NewDataListener(func() {
+		s, _ := b.Items.Search.Error.Get()
+		fyne.Do(func() {
+			if s == "" {
+				t.Label["Error"].Hide()
+			} else {
+				t.Label["Error"].Show()
+			}
+		})
+	}))
+
+	t.Label["Sort"] = ttw.NewLabel(sortLabel(b.Items.Search.Sort))
+	sortButton := widget.NewButtonWithIcon("", theme.MenuIcon(), func() {
+		NewSortDialog(b, w, func(spec backend.SortSpec) {
+			t.Label["Sort"].SetText(sortLabel(spec))
+		}).Show()
+	})
+
+	t.Container = container.NewBorder(nil, nil, nil,
+		container.NewHBox(t.Label["Error"], t.Select["Match"], t.Label["Sort"], sortButton),
+		term)
 	return t
 }
+
+func searchMatchLabel(m backend.SearchTermMatch) string {
+	return lang.X("form.select.search."+m.String(), "form.select.search."+m.String())
+}
@@ -0,0 +1,111 @@
+package bridge
+
+import (
+	"UppSpar/backend"
+	"fmt"
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	midget "github.com/assholehoff/fyne-midget"
+)
+
+/* The deleted items, the most recently deleted first, which can be restored to the status they had or purged for good */
+type Trash struct {
+	Container *fyne.Container
+	list      *widget.List
+	toolbar   *widget.Toolbar
+	items     []backend.TrashedItem
+	selected  int
+}
+
+func NewTrashList(b *backend.Backend, w fyne.Window) *Trash {
+	t := &Trash{selected: -1}
+	t.list = widget.NewList(
+		func() int {
+			return len(t.items)
+		},
+		func() fyne.CanvasObject {
+			co := midget.NewLabel("Template item name", "00000000 : 2006-01-02 15:04 : available", "")
+			co.SetTop()
+			return co
+		},
+		func(id widget.ListItemID, co fyne.CanvasObject) {
+			item := t.items[id]
+			co.(*midget.Label).SetText(item.Name)
+			co.(*midget.Label).SetSubtext(fmt.Sprintf(lang.X("trash.item", "trash.item"),
+				fmt.Sprintf("%0*d", backend.ItemIDWidth(), item.ID),
+				item.Deleted.Local().Format("2006-01-02 15:04"),
+				backend.ItemStatusID(item.PreviousStatus).LString()))
+		},
+	)
+	t.list.OnSelected = func(id widget.ListItemID) { t.selected = id }
+	t.list.OnUnselected = func(id widget.ListItemID) { t.selected = -1 }
+
+	purge := func(ids []backend.ItemID, confirm string) {
+		dialog.ShowConfirm(lang.X("trash.purge.title", "trash.purge.title"), confirm, func(ok bool) {
+			if !ok {
+				return
+			}
+			n, err := b.Items.PurgeItems(ids)
+			if err != nil {
+				dialog.ShowError(err, w)
+			} else if kept := len(ids) - n; kept > 0 {
+				dialog.ShowInformation(lang.X("trash.purge.title", "trash.purge.title"), fmt.Sprintf(lang.X("trash.purge.kept", "trash.purge.kept"), kept), w)
+			}
+			t.reload()
+		}, w)
+	}
+	t.toolbar = widget.NewToolbar(
+		widget.NewToolbarAction(theme.ContentUndoIcon(), func() {
+			if t.selected < 0 || t.selected >= len(t.items) {
+				return
+			}
+			if err := b.Items.RestoreItem(backend.ItemID(t.items[t.selected].ID)); err != nil {
+				dialog.ShowError(err, w)
+			}
+		}),
+		widget.NewToolbarAction(theme.DeleteIcon(), func() {
+			if t.selected < 0 || t.selected >= len(t.items) {
+				return
+			}
+			item := t.items[t.selected]
+			purge([]backend.ItemID{backend.ItemID(item.ID)}, fmt.Sprintf(lang.X("trash.purge.confirm", "trash.purge.confirm"), item.Name))
+		}),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarAction(theme.ContentClearIcon(), func() {
+			if len(t.items) == 0 {
+				return
+			}
+			var ids []backend.ItemID
+			for _, item := range t.items {
+				ids = append(ids, backend.ItemID(item.ID))
+			}
+			purge(ids, fmt.Sprintf(lang.X("trash.empty.confirm", "trash.empty.confirm"), len(ids)))
+		}),
+	)
+
+	/* Items are moved to and from the trash by edits of their status */
+	b.Journal.Changes.AddListener(binding.NewDataListener(t.reload))
+
+	t.Container = container.NewBorder(container.NewBorder(nil, nil, widget.NewLabel(lang.L("Trash")), t.toolbar), nil, nil, nil, t.list)
+	return t
+}
+
+func (t *Trash) reload() {
+	items, err := backend.Trash()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	t.items = items
+	t.selected = -1
+	t.list.UnselectAll()
+	t.list.Refresh()
+}
@@ -3,6 +3,7 @@ package backend
 import (
 	"database/sql"
 	"errors"
+	"log"
 
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/lang"
@@ -57,17 +58,18 @@ func (c *Category) makeConfigMap() {
 	addConfig("ShowWeight")
 }
 func (c *Category) getNameStrings() {
-	var Name sql.NullString
-	var ParentID CatID
-	query := `SELECT Name, ParentID FROM Category WHERE CatID = @0`
-	b.db.QueryRow(query, c.CatID).Scan(&Name, &ParentID)
-
-	c.Name.Set(Name.String)
+	cat, err := b.Repository.Category(c.CatID.Int())
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Category.getNameStrings() error: %s", err)
+		}
+		return
+	}
+	c.Name.Set(cat.Name)
 
-	if ParentID == 0 {
+	if cat.ParentID == 0 {
 		c.Parent.Set(lang.L("None"))
 		return
 	}
-	n, _ := ParentID.Name()
-	c.Parent.Set(n)
+	c.Parent.Set(cat.Parent)
 }
@@ -27,6 +27,9 @@ type CatID int
 func (id CatID) String() string {
 	return fmt.Sprintf("%d", id)
 }
+func (id CatID) Int() int {
+	return int(id)
+}
 
 /* Returns a tree-friendly identifying string */
 func (id CatID) TString() string {
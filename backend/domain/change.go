@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/* A column of a row with its value before and after a change */
+type FieldChange struct {
+	Key           string
+	Before, After any
+}
+
+/* The columns of one row an update changed, those changed by triggers included. Key is the column that was set. */
+type Change struct {
+	Table  string
+	ID     int
+	Key    string
+	Fields []FieldChange
+}
+
+/* Columns kept up to date by triggers, left out of changes */
+var untrackedColumns = map[string]bool{"DateCreated": true, "DateModified": true}
+
+/* The most times restore sets a row again when triggers have overwritten what it set */
+const restorePasses = 4
+
+/* Reports whether the change leaves every column as it was */
+func (c *Change) Empty() bool {
+	return len(c.Fields) == 0
+}
+
+/* Adds next, a later change of the same row, so that c goes from the values before c to those after next. Columns that end up as they were are dropped. */
+func (c *Change) Merge(next *Change) {
+	for _, f := range next.Fields {
+		i := 0
+		for i < len(c.Fields) && c.Fields[i].Key != f.Key {
+			i++
+		}
+		if i == len(c.Fields) {
+			c.Fields = append(c.Fields, f)
+		} else {
+			c.Fields[i].After = f.After
+		}
+	}
+	fields := c.Fields[:0]
+	for _, f := range c.Fields {
+		if !sameValue(f.Before, f.After) {
+			fields = append(fields, f)
+		}
+	}
+	c.Fields = fields
+}
+
+/* Compares column values, text read as []byte equals the same string */
+func sameValue(a, b any) bool {
+	if v, ok := a.([]byte); ok {
+		a = string(v)
+	}
+	if v, ok := b.([]byte); ok {
+		b = string(v)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+/* Returns the columns of row id of table in order and their values, or no columns if there is no such row */
+func readRow(q querier, table string, id int) ([]string, map[string]any, error) {
+	values := make(map[string]any)
+	rows, err := q.Query(`SELECT * FROM `+table+` WHERE `+primaryKeys[table]+` = ?`, id)
+	if err != nil {
+		return nil, values, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, values, err
+	}
+	if !rows.Next() {
+		return nil, values, rows.Err()
+	}
+	dest := make([]any, len(columns))
+	for i := range dest {
+		dest[i] = new(any)
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, values, err
+	}
+	for i, column := range columns {
+		v := *dest[i].(*any)
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		values[column] = v
+	}
+	return columns, values, rows.Err()
+}
+
+/* Returns the tracked columns that differ between two readings of a row */
+func diffRow(columns []string, before, after map[string]any) []FieldChange {
+	var fields []FieldChange
+	for _, column := range columns {
+		if !untrackedColumns[column] && !sameValue(before[column], after[column]) {
+			fields = append(fields, FieldChange{Key: column, Before: before[column], After: after[column]})
+		}
+	}
+	return fields
+}
+
+/*
+Sets the columns of fields to their values before the change, or after it, in row id of table. Setting an ID column can
+make a trigger overwrite other columns, so the row is read again and what differs is set again until it matches.
+*/
+func restore(q querier, table string, id int, fields []FieldChange, before bool) error {
+	want := make(map[string]any)
+	for _, f := range fields {
+		if before {
+			want[f.Key] = f.Before
+		} else {
+			want[f.Key] = f.After
+		}
+	}
+	pending := fields
+	for range restorePasses {
+		if len(pending) == 0 {
+			return nil
+		}
+		var sets []string
+		var args []any
+		for _, f := range pending {
+			sets = append(sets, f.Key+" = ?")
+			args = append(args, want[f.Key])
+		}
+		if _, err := q.Exec(`UPDATE `+table+` SET `+strings.Join(sets, ", ")+` WHERE `+primaryKeys[table]+` = ?`, append(args, id)...); err != nil {
+			return err
+		}
+		_, row, err := readRow(q, table, id)
+		if err != nil {
+			return err
+		}
+		pending = pending[:0:0]
+		for _, f := range fields {
+			if !sameValue(row[f.Key], want[f.Key]) {
+				pending = append(pending, f)
+			}
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%s %d: %d columns could not be restored", table, id, len(pending))
+	}
+	return nil
+}
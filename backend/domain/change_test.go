@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestUpdateRevert(t *testing.T) {
+	r := testRepository(t)
+	items := func(f Filter) []int {
+		t.Helper()
+		ids, err := r.ItemIDs(Search{Sort: byItemID}, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ids
+	}
+
+	c, err := r.Update("Item", 1, "Price", 800.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []FieldChange{{"Price", 500.0, 800.0}}; c == nil || !slices.Equal(c.Fields, want) {
+		t.Fatalf("Update = %v, want %v", c, want)
+	}
+	if c, err := r.Update("Item", 1, "Price", 800.0); c != nil || err != nil {
+		t.Errorf("Update to the same value = %v, %v", c, err)
+	}
+	next, _ := r.Update("Item", 1, "Price", 500.0)
+	c.Merge(next)
+	if !c.Empty() {
+		t.Errorf("merged change back to the start = %v", c.Fields)
+	}
+
+	/* The trigger changes ModelName and Width along with ModelID, DateModified is not tracked */
+	c, err = r.Update("Item", 1, "ModelID", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FieldChange{{"ModelID", int64(10), int64(12)}, {"ModelName", "Plus", "Modell 12"}, {"Width", 45.0, 0.0}}
+	if c == nil || !slices.Equal(c.Fields, want) {
+		t.Fatalf("Update = %v, want %v", c, want)
+	}
+	if err := r.Revert(c); err != nil {
+		t.Fatal(err)
+	}
+	if got := items(Filter{ModelID: 10, Model: "Plus", Width: Range{45, 45, ""}}); !slices.Equal(got, []int{1}) {
+		t.Errorf("after Revert got %v, want [1]", got)
+	}
+	if err := r.Reapply(c); err != nil {
+		t.Fatal(err)
+	}
+	if got := items(Filter{Model: "Modell 12"}); !slices.Equal(got, []int{1}) {
+		t.Errorf("after Reapply got %v, want [1]", got)
+	}
+	if _, err := r.Update("Nothing", 1, "Name", ""); !errors.Is(err, ErrUnknownTable) {
+		t.Errorf("got %v, want %v", err, ErrUnknownTable)
+	}
+	if _, err := r.Update("Item", 1, "Price = 0, Name", ""); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("got %v, want %v", err, ErrUnknownColumn)
+	}
+}
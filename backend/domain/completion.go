@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+/* A value the search term can be completed to and how many items that are not deleted have it */
+type Completion struct {
+	Value string
+	Field string // The query field the value is a condition on, as cat for a category, or "" for free text
+	Count int
+}
+
+/* Where completions come from, the values of column among the items that are not deleted */
+var completionSources = []struct {
+	field, column, from, group string
+}{
+	{"", "Name", "Item", "Name"},
+	{"", "Manufacturer", "Item", "Manufacturer"},
+	{"", "ModelName", "Item", "ModelName"},
+	{"cat", "Category.Name", "Category JOIN " + categoryTrees + " ON Tree.Root = Category.CatID JOIN Item ON Item.CatID = Tree.CatID", "Category.CatID"},
+	{"word", "WordString", "SearchWords_Vocabulary JOIN SearchWords_Association USING (WordID) JOIN Item USING (ItemID)", "WordID"},
+}
+
+/* Pairs every category with itself and its subcategories, as a condition on a category includes them */
+const categoryTrees = `(WITH RECURSIVE Tree(Root, CatID) AS (
+SELECT CatID, CatID FROM Category
+UNION SELECT Tree.Root, Category.CatID FROM Category JOIN Tree ON Category.ParentID = Tree.CatID)
+SELECT Root, CatID FROM Tree) AS Tree`
+
+/* Escapes the wildcards of LIKE, for use with ESCAPE '\' */
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+/*
+Values that begin with the prefix come before those where only a later word does, then the values of more items. The same
+value from several sources, as a name that is also a model name, is given once with the counts added.
+*/
+func (r *sqlRepository) Completions(ctx context.Context, prefix string, limit int) ([]Completion, error) {
+	var completions []Completion
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return completions, nil
+	}
+	begins, word := escapeLike(prefix)+"%", "% "+escapeLike(prefix)+"%"
+	var selects []string
+	var args []any
+	for _, source := range completionSources {
+		match := fmt.Sprintf(`(%[1]s LIKE ? ESCAPE '\' OR %[1]s LIKE ? ESCAPE '\')`, source.column)
+		selects = append(selects, "SELECT "+source.column+" AS Value, '"+source.field+"' AS Field, count(*) AS Count FROM "+source.from+
+			" WHERE ItemStatusID <> ? AND "+match+" GROUP BY "+source.group)
+		args = append(args, ItemStatusDeleted, begins, word)
+	}
+	query := "SELECT Value, Field, sum(Count) FROM (" + strings.Join(selects, "\nUNION ALL ") + `)
+WHERE coalesce(Value, '') <> '' GROUP BY Field, Value COLLATE NOCASE
+ORDER BY NOT Value LIKE ? ESCAPE '\', sum(Count) DESC, length(Value), Value COLLATE NOCASE LIMIT ?`
+	args = append(args, begins, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return completions, fmt.Errorf("Repository.Completions() error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c Completion
+		if err := rows.Scan(&c.Value, &c.Field, &c.Count); err != nil {
+			return completions, fmt.Errorf("Repository.Completions() error: %w", err)
+		}
+		completions = append(completions, c)
+	}
+	return completions, rows.Err()
+}
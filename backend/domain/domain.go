@@ -0,0 +1,155 @@
+/* Package domain holds the plain data types of UppSpar and a Repository that reads and writes them, without any dependency on Fyne. */
+package domain
+
+import "time"
+
+/* Item statuses, matching the ItemStatus table */
+const (
+	ItemStatusAvailable = iota + 1
+	ItemStatusSold
+	ItemStatusReserved
+	ItemStatusArchived
+	ItemStatusDeleted
+)
+
+type SearchTermMatch int
+
+const (
+	MatchBeginsWith SearchTermMatch = iota
+	MatchEndsWith
+	MatchContains
+	MatchEquals
+	RegExp
+)
+
+/* Returns the SearchTermMatch whose String() is s, and false if there is none */
+func SearchTermMatchFor(s string) (SearchTermMatch, bool) {
+	for _, m := range SearchTermMatches {
+		if m.String() == s {
+			return m, true
+		}
+	}
+	return MatchContains, false
+}
+
+var SearchTermMatches = []SearchTermMatch{MatchBeginsWith, MatchEndsWith, MatchContains, MatchEquals, RegExp}
+
+func (m SearchTermMatch) String() string {
+	switch m {
+	case MatchBeginsWith:
+		return "beginswith"
+	case MatchEndsWith:
+		return "endswith"
+	case MatchEquals:
+		return "equals"
+	case RegExp:
+		return "regexp"
+	default:
+		return "contains"
+	}
+}
+
+type SortOrder int
+
+const (
+	SortAscending SortOrder = iota
+	SortDescending
+)
+
+func (o SortOrder) String() string {
+	if o == SortAscending {
+		return "ASC"
+	}
+	return "DESC"
+}
+
+/* An Item as shown in the item form, with names resolved and empty fields filled in from its Model */
+type Item struct {
+	ItemID       int
+	Name         string
+	CatID        int
+	Category     string
+	Price        float64
+	Currency     string
+	Unit         string
+	Vat          float64
+	Priority     bool
+	Stock        float64
+	ImgURL1      string
+	ImgURL2      string
+	ImgURL3      string
+	ImgURL4      string
+	ImgURL5      string
+	SpecsURL     string
+	AddDesc      string
+	LongDesc     string
+	MfrID        int
+	Manufacturer string
+	ModelID      int
+	ModelName    string
+	ModelDesc    string
+	ModelURL     string
+	Notes        string
+	Width        float64
+	Height       float64
+	Depth        float64
+	Volume       float64
+	Weight       float64
+	LengthUnitID int
+	VolumeUnitID int
+	WeightUnitID int
+	LengthUnit   string
+	VolumeUnit   string
+	WeightUnit   string
+	ItemStatusID int
+	DateCreated  time.Time
+	DateModified time.Time
+}
+
+/* Note: "Model" should probably be called "Product" */
+type Model struct {
+	ModelID      int
+	Name         string
+	MfrID        int
+	Manufacturer string
+	CatID        int
+	Category     string
+	Desc         string
+	ImgURL1      string
+	ImgURL2      string
+	ImgURL3      string
+	ImgURL4      string
+	ImgURL5      string
+	SpecsURL     string
+	ModelURL     string
+	Width        float64
+	Height       float64
+	Depth        float64
+	Volume       float64
+	Weight       float64
+	LengthUnitID int
+	VolumeUnitID int
+	WeightUnitID int
+	LengthUnit   string
+	VolumeUnit   string
+	WeightUnit   string
+}
+
+type Manufacturer struct {
+	MfrID int
+	Name  string
+}
+
+/* A Category, Parent is empty for top level categories */
+type Category struct {
+	CatID    int
+	ParentID int
+	Name     string
+	Parent   string
+	Config   map[string]bool
+}
+
+type Settings struct {
+	ItemIDWidth int
+	TrashDays   int // How long deleted items are kept before they are purged, 0 keeps them
+}
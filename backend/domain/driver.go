@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+/* The sqlite3 driver with the SQL functions the queries need, regexp for X REGEXP Y, casefold for searches and convertunit for measurements */
+const DriverName = "sqlite3_uppspar"
+
+func init() {
+	sql.Register(DriverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := conn.RegisterFunc("regexp", regexpMatch, true); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("casefold", caseFold, true); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("convertunit", convertUnit, true)
+		},
+	})
+}
+
+/* Compiled patterns, a search runs the same pattern against every row */
+var regexpCache = struct {
+	sync.Mutex
+	m map[string]*regexp.Regexp
+}{m: make(map[string]*regexp.Regexp)}
+
+func regexpMatch(pattern string, val any) (bool, error) {
+	regexpCache.Lock()
+	re, ok := regexpCache.m[pattern]
+	if !ok {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			regexpCache.Unlock()
+			return false, err
+		}
+		if len(regexpCache.m) > 32 {
+			clear(regexpCache.m)
+		}
+		regexpCache.m[pattern] = re
+	}
+	regexpCache.Unlock()
+	switch v := val.(type) {
+	case nil:
+		return false, nil
+	case string:
+		return re.MatchString(v), nil
+	case []byte:
+		return re.Match(v), nil
+	default:
+		return re.MatchString(fmt.Sprint(v)), nil
+	}
+}
+
+/* The casefold SQL function, returns val in lower case. SQLite's own lower() and LIKE only fold ASCII, so Å and å differ. NULL stays NULL. */
+func caseFold(val any) any {
+	switch v := val.(type) {
+	case nil:
+		return nil
+	case string:
+		return strings.ToLower(v)
+	case []byte:
+		return strings.ToLower(string(v))
+	default:
+		return strings.ToLower(fmt.Sprint(v))
+	}
+}
+
+/* The units of the Metric table by UnitID, with what they measure and their size in mm, g or ml */
+var metricUnits = map[int]struct {
+	name string
+	kind int // 1 for length, 2 for weight, 3 for volume
+	size float64
+}{
+	1: {"mm", 1, 1}, 2: {"cm", 1, 10}, 3: {"dm", 1, 100}, 4: {"m", 1, 1000},
+	5: {"g", 2, 1}, 6: {"hg", 2, 100}, 7: {"kg", 2, 1000},
+	8: {"ml", 3, 1}, 9: {"cl", 3, 10}, 10: {"dl", 3, 100}, 11: {"l", 3, 1000},
+}
+
+/* Returns f in the unit with UnitID from converted to the unit with UnitID to, or f as it is if the units measure different things */
+func ConvertUnit(f float64, from, to int) float64 {
+	a, ok := metricUnits[from]
+	b, ok2 := metricUnits[to]
+	if !ok || !ok2 || a.kind != b.kind {
+		return f
+	}
+	if a.size >= b.size {
+		return f * (a.size / b.size)
+	}
+	return f / (b.size / a.size)
+}
+
+/* The convertunit SQL function, returns val in the unit with ID from converted to the unit named to. NULL stays NULL. */
+func convertUnit(val, from any, to string) any {
+	f, ok := val.(float64)
+	if i, isInt := val.(int64); isInt {
+		f, ok = float64(i), true
+	}
+	if !ok {
+		return val
+	}
+	unit, _ := from.(int64)
+	for id, u := range metricUnits {
+		if u.name == to {
+			return ConvertUnit(f, int(unit), id)
+		}
+	}
+	return f
+}
@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+/* How many items one value of a field gives */
+type FacetCount struct {
+	ID    int    // CatID or ItemStatusID
+	Name  string // Name of the manufacturer
+	Count int
+}
+
+/* How many items a measurement range gives */
+type BucketCount struct {
+	Range Range
+	Count int
+}
+
+/*
+Counts of the items matching a search and filter by the values of some fields. Every facet is counted as if the filter
+did not restrict its own field, so the counts say what choosing another value would give.
+*/
+type Facets struct {
+	Categories    []FacetCount // Per CatID, without the items of subcategories
+	Manufacturers []FacetCount
+	Statuses      []FacetCount
+	Buckets       map[string][]BucketCount // By measurement, Width, Height, Depth, Volume or Weight
+}
+
+/* The bounds of the ranges measurements are counted in, in the first unit of their kind */
+var (
+	LengthBuckets = []float64{25, 50, 100, 200}
+	VolumeBuckets = []float64{1, 10, 100}
+	WeightBuckets = []float64{1, 5, 20, 50}
+)
+
+/* The measurements with their unit column, units and buckets */
+var measurements = []struct {
+	column, unitColumn string
+	units              []string
+	buckets            []float64
+	field              func(*Filter) *Range
+}{
+	{"Width", "LengthUnitID", LengthUnits, LengthBuckets, func(f *Filter) *Range { return &f.Width }},
+	{"Height", "LengthUnitID", LengthUnits, LengthBuckets, func(f *Filter) *Range { return &f.Height }},
+	{"Depth", "LengthUnitID", LengthUnits, LengthBuckets, func(f *Filter) *Range { return &f.Depth }},
+	{"Volume", "VolumeUnitID", VolumeUnits, VolumeBuckets, func(f *Filter) *Range { return &f.Volume }},
+	{"Weight", "WeightUnitID", WeightUnits, WeightBuckets, func(f *Filter) *Range { return &f.Weight }},
+}
+
+/*
+Returns the ranges bounds divides measurements into, from ..<bounds[0] to bounds[n-1].. Each range ends just below the
+bound where the next one starts, as a < range does, so a measurement on a bound is counted once.
+*/
+func bucketRanges(bounds []float64, unit string) []Range {
+	var ranges []Range
+	min := 0.0
+	for _, max := range bounds {
+		ranges = append(ranges, Range{Min: min, Max: math.Nextafter(max, math.Inf(-1)), Unit: unit})
+		min = max
+	}
+	return append(ranges, Range{Min: min, Unit: unit})
+}
+
+func (r *sqlRepository) Facets(ctx context.Context, s Search, f Filter) (*Facets, error) {
+	facets := &Facets{Buckets: make(map[string][]BucketCount)}
+	var err error
+
+	g := f
+	g.CatID, g.Category = 0, ""
+	if facets.Categories, err = r.facet(ctx, s, g, "CatID"); err != nil {
+		return facets, fmt.Errorf("Repository.Facets() error: %w", err)
+	}
+	g = f
+	g.MfrID, g.Manufacturer = 0, ""
+	if facets.Manufacturers, err = r.facet(ctx, s, g, "Manufacturer"); err != nil {
+		return facets, fmt.Errorf("Repository.Facets() error: %w", err)
+	}
+	g = f
+	g.ItemStatusID = 0
+	if facets.Statuses, err = r.facet(ctx, s, g, "ItemStatusID"); err != nil {
+		return facets, fmt.Errorf("Repository.Facets() error: %w", err)
+	}
+
+	for _, m := range measurements {
+		g = f
+		*m.field(&g) = Range{}
+		ranges := bucketRanges(m.buckets, m.units[0])
+		var sums []string
+		var args []any
+		for _, rg := range ranges {
+			w := &where{}
+			w.measure(m.column, m.unitColumn, rg)
+			sums = append(sums, "coalesce(sum("+strings.Join(w.conds, " AND ")+"), 0)")
+			args = append(args, w.args...)
+		}
+		query, margs, _, err := r.matching(s, g)
+		if err != nil {
+			return facets, fmt.Errorf("Repository.Facets() error: %w", err)
+		}
+		counts := make([]int, len(ranges))
+		dest := make([]any, len(ranges))
+		for i := range counts {
+			dest[i] = &counts[i]
+		}
+		err = r.db.QueryRowContext(ctx, "SELECT "+strings.Join(sums, ", ")+" "+query, append(args, margs...)...).Scan(dest...)
+		if err != nil {
+			return facets, fmt.Errorf("Repository.Facets() error: %w", err)
+		}
+		for i, rg := range ranges {
+			facets.Buckets[m.column] = append(facets.Buckets[m.column], BucketCount{Range: rg, Count: counts[i]})
+		}
+	}
+	return facets, nil
+}
+
+/* Returns the number of items matching s and f per value of column, an ID for integer columns and a Name for text */
+func (r *sqlRepository) facet(ctx context.Context, s Search, f Filter, column string) ([]FacetCount, error) {
+	var counts []FacetCount
+	query, args, _, err := r.matching(s, f)
+	if err != nil {
+		return counts, err
+	}
+	rows, err := r.db.QueryContext(ctx, "SELECT "+column+", count(*) "+query+"GROUP BY "+column+" COLLATE NOCASE ORDER BY "+column+" COLLATE NOCASE", args...)
+	if err != nil {
+		return counts, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c FacetCount
+		var val any
+		if err := rows.Scan(&val, &c.Count); err != nil {
+			return counts, err
+		}
+		switch v := val.(type) {
+		case int64:
+			c.ID = int(v)
+		case string:
+			c.Name = v
+		case []byte:
+			c.Name = string(v)
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
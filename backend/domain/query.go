@@ -0,0 +1,415 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var ErrInvalidQuery = errors.New("invalid query")
+
+/* A search term that could not be parsed. Reason is one of the keys of queryReasons, Token is the part of the term it is about. */
+type QueryError struct {
+	Token  string
+	Reason string
+}
+
+var queryReasons = map[string]string{
+	"value":  "missing value",
+	"number": "invalid number or range",
+	"date":   "invalid date or range",
+	"status": "unknown status",
+	"quote":  "unterminated quote",
+	"flag":   "expected yes or no",
+	"unit":   "unknown unit",
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s: %s in %q", ErrInvalidQuery, queryReasons[e.Reason], e.Token)
+}
+
+func (e *QueryError) Is(target error) bool {
+	return target == ErrInvalidQuery
+}
+
+/* The fields of the query language, queryAliases holds their other names */
+var QueryFields = []string{"cat", "mfr", "model", "word", "status", "image", "width", "height", "depth", "volume", "weight", "created", "modified"}
+
+var queryAliases = map[string]string{
+	"kat":          "cat",
+	"kategori":     "cat",
+	"category":     "cat",
+	"tillv":        "mfr",
+	"tillverkare":  "mfr",
+	"manufacturer": "mfr",
+	"modell":       "model",
+	"sökord":       "word",
+	"bild":         "image",
+	"bredd":        "width",
+	"höjd":         "height",
+	"djup":         "depth",
+	"volym":        "volume",
+	"vikt":         "weight",
+	"skapad":       "created",
+	"ändrad":       "modified",
+}
+
+/* The names a status: condition accepts, in English and Swedish */
+var QueryStatuses = map[string]int{
+	"available":   ItemStatusAvailable,
+	"sold":        ItemStatusSold,
+	"reserved":    ItemStatusReserved,
+	"archived":    ItemStatusArchived,
+	"deleted":     ItemStatusDeleted,
+	"tillgänglig": ItemStatusAvailable,
+	"såld":        ItemStatusSold,
+	"reserverad":  ItemStatusReserved,
+	"arkiverad":   ItemStatusArchived,
+	"borttagen":   ItemStatusDeleted,
+}
+
+/* The values an image: condition accepts */
+var QueryFlags = map[string]int{"yes": 1, "no": -1, "ja": 1, "nej": -1}
+
+/* Returns the field named key, an alias or a field of QueryFields, and false if there is none */
+func QueryField(key string) (string, bool) {
+	key = strings.ToLower(key)
+	if field, ok := queryAliases[key]; ok {
+		return field, true
+	}
+	for _, field := range QueryFields {
+		if field == key {
+			return field, true
+		}
+	}
+	return "", false
+}
+
+/* Returns the names of the fields and aliases that begin with prefix, sorted */
+func QueryFieldsFor(prefix string) []string {
+	prefix = strings.ToLower(prefix)
+	var names []string
+	for _, field := range QueryFields {
+		if strings.HasPrefix(field, prefix) {
+			names = append(names, field)
+		}
+	}
+	for alias := range queryAliases {
+		if strings.HasPrefix(alias, prefix) {
+			names = append(names, alias)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+ParseQuery moves the field conditions in the term of s into f and leaves the free text as the term. A condition is a field
+name, a colon and a value, for example cat:Stolar, mfr:"Kinnarps AB", status:available, width:40..60, weight:<10 or
+created:>2025-01-01. Quoted text is kept as one word, and so is a word with a colon that does not start with a field
+name, such as Obs: or kl:14. Conditions override the same field in f. RegExp terms are not parsed.
+*/
+func ParseQuery(s Search, f Filter) (Search, Filter, error) {
+	if s.Match == RegExp {
+		return s, f, nil
+	}
+	tokens, err := queryTokens(s.Term)
+	if err != nil {
+		return s, f, err
+	}
+	var words []string
+	for _, token := range tokens {
+		key, val, ok := strings.Cut(token, ":")
+		if !ok || key == "" || strings.HasPrefix(key, `"`) || !isWord(key) {
+			words = append(words, unquote(token))
+			continue
+		}
+		field, ok := QueryField(key)
+		if !ok {
+			words = append(words, unquote(token))
+			continue
+		}
+		val = unquote(val)
+		if val == "" {
+			return s, f, &QueryError{Token: token, Reason: "value"}
+		}
+		if err := f.set(field, val); err != nil {
+			return s, f, &QueryError{Token: token, Reason: err.Error()}
+		}
+	}
+	s.Term = strings.Join(words, " ")
+	return s, f, nil
+}
+
+/* Splits term at spaces outside of quotes */
+func queryTokens(term string) ([]string, error) {
+	var tokens []string
+	var token strings.Builder
+	quoted := false
+	for _, r := range term {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			token.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+		default:
+			token.WriteRune(r)
+		}
+	}
+	if quoted {
+		return tokens, &QueryError{Token: token.String(), Reason: "quote"}
+	}
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+	return tokens, nil
+}
+
+func isWord(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func unquote(s string) string {
+	return strings.ReplaceAll(s, `"`, "")
+}
+
+/* Sets field of f from val, the error text is a key of queryReasons */
+func (f *Filter) set(field, val string) error {
+	var err error
+	switch field {
+	case "cat":
+		f.CatID = 0
+		f.Category = val
+	case "mfr":
+		f.MfrID = 0
+		f.ModelID = 0
+		f.Manufacturer = val
+	case "model":
+		f.ModelID = 0
+		f.Model = val
+	case "word":
+		f.Word = val
+	case "status":
+		id, ok := QueryStatuses[strings.ToLower(val)]
+		if !ok {
+			return errors.New("status")
+		}
+		f.ItemStatusID = id
+	case "image":
+		flag, ok := QueryFlags[strings.ToLower(val)]
+		if !ok {
+			return errors.New("flag")
+		}
+		f.Images = flag
+	case "width":
+		f.Width, err = parseRange(val, LengthUnits)
+	case "height":
+		f.Height, err = parseRange(val, LengthUnits)
+	case "depth":
+		f.Depth, err = parseRange(val, LengthUnits)
+	case "volume":
+		f.Volume, err = parseRange(val, VolumeUnits)
+	case "weight":
+		f.Weight, err = parseRange(val, WeightUnits)
+	case "created":
+		f.CreatedFrom, f.CreatedUntil, err = parseDateRange(val)
+	case "modified":
+		f.ModifiedFrom, f.ModifiedUntil, err = parseDateRange(val)
+	}
+	return err
+}
+
+/* Splits a range into its ends, from a..b, a-b, >a, >=a, <b or <=b. A single value is both ends. */
+func splitRange(s string, sep ...string) (op, from, to string) {
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if rest, ok := strings.CutPrefix(s, op); ok {
+			return op, rest, rest
+		}
+	}
+	for _, sep := range sep {
+		if i := strings.Index(s, sep); i > 0 || (i == 0 && sep == "..") {
+			return sep, s[:i], s[i+len(sep):]
+		}
+	}
+	return "", s, s
+}
+
+/* Returns the range written in s as for the query language, for one of LengthUnits, VolumeUnits or WeightUnits */
+func ParseRange(s string, units []string) (Range, error) {
+	r, err := parseRange(s, units)
+	if err != nil {
+		return r, &QueryError{Token: s, Reason: err.Error()}
+	}
+	return r, nil
+}
+
+/* Returns the range as ParseRange reads it, as in 25..50cm, ..25cm, >=200cm, or <25cm and 25..<50cm when Max ends just below a bound */
+func (r Range) String() string {
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	max := format(r.Max)
+	if bound := math.Nextafter(r.Max, math.Inf(1)); len(format(bound)) < len(max) {
+		max = "<" + format(bound)
+	}
+	switch {
+	case r.Min == 0 && r.Max == 0:
+		return ""
+	case r.Max == 0:
+		return ">=" + format(r.Min) + r.Unit
+	case r.Min == 0 && max[0] == '<':
+		return max + r.Unit
+	case r.Min == 0:
+		return ".." + max + r.Unit
+	}
+	return format(r.Min) + ".." + max + r.Unit
+}
+
+/*
+Returns the bounds of a number range, where 0 means unbounded as in Filter. Either end may be followed by one of units,
+40-60cm, 40cm..60cm, 40..<60cm and >2kg are ranges, a number without a unit is in the first of units.
+*/
+func parseRange(s string, units []string) (Range, error) {
+	r := Range{Unit: units[0]}
+	s = strings.ToLower(strings.Join(strings.Fields(strings.ReplaceAll(s, ",", ".")), ""))
+	op, from, to := splitRange(s, "..", "-")
+	below := false
+	if op == ".." {
+		to, below = strings.CutPrefix(to, "<")
+	}
+	unit := ""
+	parse := func(s string) (float64, error) {
+		if i := strings.IndexFunc(s, unicode.IsLetter); i >= 0 {
+			if unit != "" && unit != s[i:] {
+				return 0, errors.New("unit")
+			}
+			unit, s = s[i:], s[:i]
+			if s == "" {
+				return 0, errors.New("number")
+			}
+		}
+		if s == "" {
+			return 0, nil
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, errors.New("number")
+		}
+		return v, nil
+	}
+	a, err := parse(from)
+	if err != nil {
+		return r, err
+	}
+	z, err := parse(to)
+	if err != nil {
+		return r, err
+	}
+	if from == "" && to == "" {
+		return r, errors.New("number")
+	}
+	if unit != "" {
+		if !slices.Contains(units, unit) {
+			return r, errors.New("unit")
+		}
+		r.Unit = unit
+	}
+	switch op {
+	case ">":
+		r.Min = math.Nextafter(a, math.Inf(1))
+	case ">=":
+		r.Min = a
+	case "<":
+		r.Max = math.Nextafter(z, math.Inf(-1))
+	case "<=":
+		r.Max = z
+	default:
+		r.Min, r.Max = a, z
+	}
+	if below {
+		r.Max = math.Nextafter(z, math.Inf(-1))
+	}
+	return r, nil
+}
+
+/* Years, months and days in the units of a relative date */
+var relativeUnits = map[byte][3]int{'d': {0, 0, 1}, 'w': {0, 0, 7}, 'v': {0, 0, 7}, 'm': {0, 1, 0}, 'y': {1, 0, 0}}
+
+/*
+Returns the local start and end of a year, month or day written as 2025, 2025-01 or 2025-01-31, of today, or of the day a
+number of days, weeks, months or years ago written as -90d, -2w, -6m or -1y.
+*/
+func parsePeriod(s string) (time.Time, time.Time, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if s == "today" || s == "idag" {
+		return today, today.AddDate(0, 0, 1), nil
+	}
+	if len(s) > 2 && s[0] == '-' {
+		if u, ok := relativeUnits[s[len(s)-1]]; ok {
+			if n, err := strconv.Atoi(s[1 : len(s)-1]); err == nil {
+				day := today.AddDate(-n*u[0], -n*u[1], -n*u[2])
+				return day, day.AddDate(0, 0, 1), nil
+			}
+		}
+	}
+	for _, layout := range []struct {
+		layout  string
+		y, m, d int
+	}{{"2006-01-02", 0, 0, 1}, {"2006-01", 0, 1, 0}, {"2006", 1, 0, 0}} {
+		t, err := time.ParseInLocation(layout.layout, s, time.Local)
+		if err == nil {
+			return t, t.AddDate(layout.y, layout.m, layout.d), nil
+		}
+	}
+	return time.Time{}, time.Time{}, errors.New("date")
+}
+
+/* Returns the start, inclusive, and end, exclusive, of a date range. A zero time means unbounded. */
+func parseDateRange(s string) (time.Time, time.Time, error) {
+	var from, until time.Time
+	op, a, z := splitRange(s, "..")
+	if a != "" {
+		start, end, err := parsePeriod(a)
+		if err != nil {
+			return from, until, err
+		}
+		switch op {
+		case ">":
+			from = end
+		case ">=", "..", "":
+			from = start
+		}
+		if op == "" {
+			until = end
+		}
+	}
+	if z != "" && op != ">" && op != ">=" && op != "" {
+		start, end, err := parsePeriod(z)
+		if err != nil {
+			return from, until, err
+		}
+		if op == "<" {
+			until = start
+		} else {
+			until = end
+		}
+	}
+	if from.IsZero() && until.IsZero() {
+		return from, until, errors.New("date")
+	}
+	return from, until, nil
+}
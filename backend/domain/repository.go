@@ -0,0 +1,584 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownTable  = errors.New("unknown table")
+	ErrUnknownColumn = errors.New("unknown column")
+)
+
+/* Layout of the DateCreated and DateModified columns */
+const subsec = "2006-01-02 15:04:05.999"
+
+/* The primary key column of each table that Update may change */
+var primaryKeys = map[string]string{
+	"Item":         "ItemID",
+	"Model":        "ModelID",
+	"Manufacturer": "MfrID",
+	"Category":     "CatID",
+	"Metric":       "UnitID",
+}
+
+/* Reads and writes the domain types */
+type Repository interface {
+	/* Returns the IDs of all items that are not deleted, unless f asks for deleted ones, and match both s and f, sorted as s says */
+	ItemIDs(s Search, f Filter) ([]int, error)
+	/* Returns at most limit of the IDs ItemIDs returns, skipping the first offset. A limit of 0 returns them all. Cancelling ctx interrupts the query. */
+	ItemIDPage(ctx context.Context, s Search, f Filter, offset, limit int) ([]int, error)
+	/* Returns at most limit of the names, manufacturers, models, categories and search words of the items that are not deleted that begin with prefix or have a word that does, best first */
+	Completions(ctx context.Context, prefix string, limit int) ([]Completion, error)
+	/* Returns how many of the items ItemIDs returns have each category, manufacturer, status and measurement range */
+	Facets(ctx context.Context, s Search, f Filter) (*Facets, error)
+	/* Returns the keys a SortSpec can use, DerivedSortKeys first and then the Item columns */
+	SortKeys() ([]string, error)
+	/* Returns the text around the match of s in item id with the matching words between « and », or "" if s does not use the full-text index */
+	Snippet(s Search, id int) (string, error)
+	Item(id int) (*Item, error)
+	Model(id int) (*Model, error)
+	Manufacturer(id int) (*Manufacturer, error)
+	Category(id int) (*Category, error)
+	Settings() (*Settings, error)
+	Setting(key string) (string, error)
+	SetSetting(key, val string) error
+	/* Sets column key of row id in table to val, if it differs, and returns what changed in the row or nil if nothing did or there is no such row */
+	Update(table string, id int, key string, val any) (*Change, error)
+	/* Sets the columns c changed back to their values before it */
+	Revert(c *Change) error
+	/* Sets the columns c changed to their values after it again */
+	Reapply(c *Change) error
+	/* Returns the deleted items, the most recently deleted first */
+	Trash() ([]TrashedItem, error)
+	/* Returns the status deleted item id had before it was deleted, ErrNotInTrash if it is not deleted */
+	TrashedStatus(id int) (int, error)
+	/* Returns the IDs of the items deleted before before that have no sales */
+	ExpiredTrash(before time.Time) ([]int, error)
+	/* Removes the deleted items among ids for good, with the rows that belong to them, and returns the IDs of those removed. Items with sales are kept. */
+	PurgeItems(ids []int) ([]int, error)
+	/* Gives item s.ItemID status s.To if its status can go there, records s and returns what changed in the row, or nil if it already has the status. ErrInvalidTransition if it cannot. */
+	SetStatus(s StatusChange) (*Change, error)
+	/* Returns the status changes of item id, newest first */
+	StatusChanges(id int) ([]StatusChange, error)
+	/* Returns the reservation of item id, or nil if it is not reserved */
+	Reservation(id int) (*StatusChange, error)
+	/* Returns the change that made item id sold, or nil if it is not sold */
+	SoldStatus(id int) (*StatusChange, error)
+	/* Returns the IDs of the reserved items whose reservations ran out before now */
+	ExpiredReservations(now time.Time) ([]int, error)
+	/* Records the sale of s.Quantity of item s.ItemID at s.Price and s.Vat and takes it from the stock, which sells the item when none is left. A sale without a currency is in that of the item. */
+	Sell(s Sale) (*Sale, error)
+	/* Records the return of quantity of sale id and puts it back in stock, which makes a sold item available again */
+	ReturnSale(id int, quantity float64, reason string) (*Sale, error)
+	/* Records that sale c.SaleID should have been of c.Quantity at c.Price and c.Vat to c.Buyer, and changes the stock by the difference */
+	CorrectSale(c Sale) (*Sale, error)
+	/* Records the receipt of quantity of item itemID in the ledger and adds it to the stock, which makes a sold item available again */
+	Receive(itemID int, quantity float64, reason string) (*Sale, error)
+	/* Returns sale id with its returns and corrections added, ErrNoSale if there is none */
+	Sale(id int) (*Sale, error)
+	/* Returns the sales, returns, corrections and receipts of item id, newest first */
+	Sales(itemID int) ([]Sale, error)
+	/* Returns the revenue, items sold per category and average time to sale of the sales, returns and corrections made from from until to, receipts are left out */
+	SalesReport(from, to time.Time) (*SalesReport, error)
+}
+
+type sqlRepository struct {
+	db *sql.DB
+}
+
+/* Returns a Repository backed by db, which must already have the UppSpar tables. Searches without the full-text index need a casefold SQL function on the connection, RegExp searches a regexp function and measurement filters with a unit a convertunit function, as the driver DriverName registers. */
+func NewRepository(db *sql.DB) Repository {
+	return &sqlRepository{db: db}
+}
+
+func (r *sqlRepository) ItemIDs(s Search, f Filter) ([]int, error) {
+	return r.ItemIDPage(context.Background(), s, f, 0, 0)
+}
+
+func (r *sqlRepository) ItemIDPage(ctx context.Context, s Search, f Filter, offset, limit int) ([]int, error) {
+	var ids []int
+	query, args, rank, err := r.matching(s, f)
+	if err != nil {
+		return ids, fmt.Errorf("Repository.ItemIDPage() error: %w", err)
+	}
+	columns, err := r.itemColumns()
+	if err != nil {
+		return ids, fmt.Errorf("Repository.ItemIDPage() error: %w", err)
+	}
+	order, err := s.Sort.orderBy(columns, rank)
+	if err != nil {
+		return ids, fmt.Errorf("Repository.ItemIDPage() error: %w", err)
+	}
+	query = "SELECT ItemID " + query + order
+	if limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return ids, fmt.Errorf("Repository.ItemIDPage() error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		rows.Scan(&id)
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+/* Returns the FROM and WHERE clauses of the items that are not deleted, unless f asks for deleted ones, and match s and f, their arguments and what Relevance sorts by */
+func (r *sqlRepository) matching(s Search, f Filter) (string, []any, string, error) {
+	if err := s.check(); err != nil {
+		return "", nil, "", err
+	}
+	var args []any
+	w := &where{}
+	w.add("ItemID <> 0")
+	query := `FROM Item `
+	rank := "ItemID"
+	if s.fullText() && r.hasFullText() {
+		query += `JOIN (SELECT rowid AS MatchID, rank AS MatchRank FROM Item_FTS WHERE Item_FTS MATCH ?) ON MatchID = ItemID `
+		args = append(args, s.matchQuery())
+		rank = "MatchRank"
+	} else {
+		s.where(w)
+	}
+	f.where(w)
+	if f.ItemStatusID != ItemStatusDeleted {
+		w.add("ItemStatusID <> ?", ItemStatusDeleted)
+	}
+	query += w.String()
+	args = append(args, w.args...)
+	return query, args, rank, nil
+}
+
+/* Returns the Item columns by name, true for those with text affinity */
+func (r *sqlRepository) itemColumns() (map[string]bool, error) {
+	columns := make(map[string]bool)
+	rows, err := r.db.Query(`SELECT name, upper(type) FROM pragma_table_info('Item')`)
+	if err != nil {
+		return columns, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			return columns, err
+		}
+		columns[name] = strings.Contains(typ, "CHAR") || strings.Contains(typ, "CLOB") || strings.Contains(typ, "TEXT")
+	}
+	return columns, rows.Err()
+}
+
+func (r *sqlRepository) SortKeys() ([]string, error) {
+	keys := slices.Clone(DerivedSortKeys)
+	rows, err := r.db.Query(`SELECT name FROM pragma_table_info('Item') ORDER BY cid`)
+	if err != nil {
+		return keys, fmt.Errorf("Repository.SortKeys() error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return keys, fmt.Errorf("Repository.SortKeys() error: %w", err)
+		}
+		keys = append(keys, name)
+	}
+	return keys, rows.Err()
+}
+
+/* The full-text index is only kept in sync, and only usable, while its triggers exist */
+func (r *sqlRepository) hasFullText() bool {
+	var n int
+	r.db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type = 'trigger' AND name = 'Item_FTS_Insert'`).Scan(&n)
+	return n > 0
+}
+
+func (r *sqlRepository) Snippet(s Search, id int) (string, error) {
+	if !s.fullText() || !r.hasFullText() {
+		return "", nil
+	}
+	var snippet string
+	query := `SELECT snippet(Item_FTS, -1, '«', '»', '…', 12) FROM Item_FTS WHERE Item_FTS MATCH @0 AND rowid = @1`
+	err := r.db.QueryRow(query, s.matchQuery(), id).Scan(&snippet)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("Repository.Snippet(%d) error: %w", id, err)
+	}
+	return snippet, nil
+}
+
+func (r *sqlRepository) Item(id int) (*Item, error) {
+	var Name, Currency, Unit, ImgURL1, ImgURL2, ImgURL3, ImgURL4, ImgURL5, SpecsURL sql.NullString
+	var AddDesc, LongDesc, Manufacturer, ModelName, ModelDesc, ModelURL, Notes, DateCreated, DateModified sql.NullString
+	var Price, Vat, Stock, Width, Height, Depth, Volume, Weight sql.NullFloat64
+	var Priority sql.NullBool
+	var CatID, MfrID, ModelID, LengthUnitID, VolumeUnitID, WeightUnitID, ItemStatusID sql.NullInt64
+
+	query := `SELECT
+Name, CatID, Price, Currency, Unit, Vat,
+Priority, Stock, ImgURL1, ImgURL2, ImgURL3, ImgURL4, ImgURL5, SpecsURL,
+AddDesc, LongDesc, Manufacturer, MfrID, ModelID, ModelName, ModelDesc, ModelURL, Notes,
+Width, Height, Depth, Volume, Weight,
+LengthUnitID, VolumeUnitID, WeightUnitID,
+ItemStatusID, DateCreated, DateModified
+FROM Item WHERE ItemID = @0`
+	err := r.db.QueryRow(query, id).Scan(
+		&Name, &CatID, &Price, &Currency, &Unit, &Vat,
+		&Priority, &Stock, &ImgURL1, &ImgURL2, &ImgURL3, &ImgURL4, &ImgURL5, &SpecsURL,
+		&AddDesc, &LongDesc, &Manufacturer, &MfrID, &ModelID, &ModelName, &ModelDesc, &ModelURL, &Notes,
+		&Width, &Height, &Depth, &Volume, &Weight,
+		&LengthUnitID, &VolumeUnitID, &WeightUnitID,
+		&ItemStatusID, &DateCreated, &DateModified,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Repository.Item(%d) error: %w", id, err)
+	}
+
+	t := &Item{
+		ItemID:       id,
+		Name:         Name.String,
+		CatID:        int(CatID.Int64),
+		Price:        Price.Float64,
+		Currency:     Currency.String,
+		Unit:         Unit.String,
+		Vat:          Vat.Float64,
+		Priority:     Priority.Bool,
+		Stock:        Stock.Float64,
+		ImgURL1:      ImgURL1.String,
+		ImgURL2:      ImgURL2.String,
+		ImgURL3:      ImgURL3.String,
+		ImgURL4:      ImgURL4.String,
+		ImgURL5:      ImgURL5.String,
+		SpecsURL:     SpecsURL.String,
+		AddDesc:      AddDesc.String,
+		LongDesc:     LongDesc.String,
+		MfrID:        int(MfrID.Int64),
+		Manufacturer: Manufacturer.String,
+		ModelID:      int(ModelID.Int64),
+		ModelName:    ModelName.String,
+		ModelDesc:    ModelDesc.String,
+		ModelURL:     ModelURL.String,
+		Notes:        Notes.String,
+		Width:        Width.Float64,
+		Height:       Height.Float64,
+		Depth:        Depth.Float64,
+		Volume:       Volume.Float64,
+		Weight:       Weight.Float64,
+		LengthUnitID: int(LengthUnitID.Int64),
+		VolumeUnitID: int(VolumeUnitID.Int64),
+		WeightUnitID: int(WeightUnitID.Int64),
+		ItemStatusID: int(ItemStatusID.Int64),
+	}
+	if t.Category, err = r.name("Category", "Name", t.CatID); err != nil {
+		return nil, fmt.Errorf("Repository.Item(%d) error: %w", id, err)
+	}
+	if t.Manufacturer == "" && t.MfrID != 0 {
+		if t.Manufacturer, err = r.name("Manufacturer", "Name", t.MfrID); err != nil {
+			return nil, fmt.Errorf("Repository.Item(%d) error: %w", id, err)
+		}
+	}
+
+	/* Fill in what the item leaves empty from its model */
+	if t.ModelID != 0 {
+		mdl, err := r.Model(t.ModelID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("Repository.Item(%d) error: %w", id, err)
+		}
+		if mdl != nil {
+			if t.ModelName == "" {
+				t.ModelName = mdl.Name
+			}
+			if t.Category == "" {
+				t.Category = mdl.Category
+			}
+			if t.MfrID == 0 {
+				t.MfrID = mdl.MfrID
+			}
+			if t.Manufacturer == "" {
+				t.Manufacturer = mdl.Manufacturer
+			}
+			if t.ModelURL == "" {
+				t.ModelURL = mdl.ModelURL
+			}
+			if t.ModelDesc == "" {
+				t.ModelDesc = mdl.Desc
+			}
+			if t.Weight == 0 && t.Height == 0 && t.Depth == 0 {
+				t.Weight = mdl.Weight
+				t.Height = mdl.Height
+				t.Depth = mdl.Depth
+				t.LengthUnitID = mdl.LengthUnitID
+			}
+			if t.Volume == 0 {
+				t.Volume = mdl.Volume
+				t.VolumeUnitID = mdl.VolumeUnitID
+			}
+			if t.Weight == 0 {
+				t.Weight = mdl.Weight
+				t.WeightUnitID = mdl.WeightUnitID
+			}
+		}
+	}
+
+	if t.LengthUnit, err = r.name("Metric", "Text", t.LengthUnitID); err != nil {
+		return nil, fmt.Errorf("Repository.Item(%d) error: %w", id, err)
+	}
+	if t.VolumeUnit, err = r.name("Metric", "Text", t.VolumeUnitID); err != nil {
+		return nil, fmt.Errorf("Repository.Item(%d) error: %w", id, err)
+	}
+	if t.WeightUnit, err = r.name("Metric", "Text", t.WeightUnitID); err != nil {
+		return nil, fmt.Errorf("Repository.Item(%d) error: %w", id, err)
+	}
+
+	if DateCreated.Valid {
+		if t.DateCreated, err = time.Parse(subsec, DateCreated.String); err != nil {
+			return nil, fmt.Errorf("Repository.Item(%d) error parsing DateCreated: %w", id, err)
+		}
+	}
+	if DateModified.Valid {
+		if t.DateModified, err = time.Parse(subsec, DateModified.String); err != nil {
+			return nil, fmt.Errorf("Repository.Item(%d) error parsing DateModified: %w", id, err)
+		}
+	}
+	return t, nil
+}
+
+func (r *sqlRepository) Model(id int) (*Model, error) {
+	var Name, Desc, ImgURL1, ImgURL2, ImgURL3, ImgURL4, ImgURL5, SpecsURL, ModelURL, Manufacturer sql.NullString
+	var Width, Height, Depth, Volume, Weight sql.NullFloat64
+	var CatID, MfrID, LengthUnitID, VolumeUnitID, WeightUnitID sql.NullInt64
+
+	query := `SELECT Name, Manufacturer, MfrID, Desc, ImgURL1, ImgURL2, ImgURL3, ImgURL4, ImgURL5, SpecsURL, ModelURL,
+Width, Height, Depth, Volume, Weight, LengthUnitID, VolumeUnitID, WeightUnitID, CatID
+FROM Model WHERE ModelID = @0`
+	err := r.db.QueryRow(query, id).Scan(
+		&Name, &Manufacturer, &MfrID, &Desc, &ImgURL1, &ImgURL2, &ImgURL3, &ImgURL4, &ImgURL5, &SpecsURL, &ModelURL,
+		&Width, &Height, &Depth, &Volume, &Weight, &LengthUnitID, &VolumeUnitID, &WeightUnitID, &CatID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Repository.Model(%d) error: %w", id, err)
+	}
+
+	mdl := &Model{
+		ModelID:      id,
+		Name:         Name.String,
+		MfrID:        int(MfrID.Int64),
+		Manufacturer: Manufacturer.String,
+		CatID:        int(CatID.Int64),
+		Desc:         Desc.String,
+		ImgURL1:      ImgURL1.String,
+		ImgURL2:      ImgURL2.String,
+		ImgURL3:      ImgURL3.String,
+		ImgURL4:      ImgURL4.String,
+		ImgURL5:      ImgURL5.String,
+		SpecsURL:     SpecsURL.String,
+		ModelURL:     ModelURL.String,
+		Width:        Width.Float64,
+		Height:       Height.Float64,
+		Depth:        Depth.Float64,
+		Volume:       Volume.Float64,
+		Weight:       Weight.Float64,
+		LengthUnitID: int(LengthUnitID.Int64),
+		VolumeUnitID: int(VolumeUnitID.Int64),
+		WeightUnitID: int(WeightUnitID.Int64),
+	}
+	if mdl.MfrID != 0 {
+		if n, _ := r.name("Manufacturer", "Name", mdl.MfrID); n != "" {
+			mdl.Manufacturer = n
+		}
+	}
+	if mdl.Category, err = r.name("Category", "Name", mdl.CatID); err != nil {
+		return nil, fmt.Errorf("Repository.Model(%d) error: %w", id, err)
+	}
+	if mdl.LengthUnit, err = r.name("Metric", "Text", mdl.LengthUnitID); err != nil {
+		return nil, fmt.Errorf("Repository.Model(%d) error: %w", id, err)
+	}
+	if mdl.VolumeUnit, err = r.name("Metric", "Text", mdl.VolumeUnitID); err != nil {
+		return nil, fmt.Errorf("Repository.Model(%d) error: %w", id, err)
+	}
+	if mdl.WeightUnit, err = r.name("Metric", "Text", mdl.WeightUnitID); err != nil {
+		return nil, fmt.Errorf("Repository.Model(%d) error: %w", id, err)
+	}
+	return mdl, nil
+}
+
+func (r *sqlRepository) Manufacturer(id int) (*Manufacturer, error) {
+	var Name sql.NullString
+	query := `SELECT Name FROM Manufacturer WHERE MfrID = @0`
+	if err := r.db.QueryRow(query, id).Scan(&Name); err != nil {
+		return nil, fmt.Errorf("Repository.Manufacturer(%d) error: %w", id, err)
+	}
+	return &Manufacturer{MfrID: id, Name: Name.String}, nil
+}
+
+func (r *sqlRepository) Category(id int) (*Category, error) {
+	var Name sql.NullString
+	var ParentID sql.NullInt64
+	query := `SELECT Name, ParentID FROM Category WHERE CatID = @0`
+	if err := r.db.QueryRow(query, id).Scan(&Name, &ParentID); err != nil {
+		return nil, fmt.Errorf("Repository.Category(%d) error: %w", id, err)
+	}
+	c := &Category{
+		CatID:    id,
+		ParentID: int(ParentID.Int64),
+		Name:     Name.String,
+		Config:   make(map[string]bool),
+	}
+	var err error
+	if c.Parent, err = r.name("Category", "Name", c.ParentID); err != nil {
+		return nil, fmt.Errorf("Repository.Category(%d) error: %w", id, err)
+	}
+
+	query = `SELECT ConfigKey, ConfigVal FROM Category_Config WHERE CatID = @0`
+	rows, err := r.db.Query(query, id)
+	if err != nil {
+		return nil, fmt.Errorf("Repository.Category(%d) error: %w", id, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		var val sql.NullBool
+		if err := rows.Scan(&key, &val); err != nil {
+			return nil, fmt.Errorf("Repository.Category(%d) error: %w", id, err)
+		}
+		c.Config[key] = val.Bool
+	}
+	return c, rows.Err()
+}
+
+func (r *sqlRepository) Settings() (*Settings, error) {
+	s := &Settings{ItemIDWidth: 7}
+	val, err := r.Setting("ItemIDWidth")
+	if err != nil {
+		return s, err
+	}
+	if i, err := strconv.Atoi(val); err == nil {
+		s.ItemIDWidth = i
+	}
+	val, err = r.Setting("TrashDays")
+	if err != nil {
+		return s, err
+	}
+	if i, err := strconv.Atoi(val); err == nil {
+		s.TrashDays = i
+	}
+	return s, nil
+}
+
+func (r *sqlRepository) Setting(key string) (string, error) {
+	var val sql.NullString
+	query := `SELECT ConfigVal FROM Config WHERE ConfigKey = @0`
+	err := r.db.QueryRow(query, key).Scan(&val)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("Repository.Setting(%s) error: %w", key, err)
+	}
+	return val.String, nil
+}
+
+func (r *sqlRepository) SetSetting(key, val string) error {
+	query := `UPDATE Config SET ConfigVal = @0 WHERE ConfigKey = @1 AND ConfigVal <> @2`
+	if _, err := r.db.Exec(query, val, key, val); err != nil {
+		return fmt.Errorf("Repository.SetSetting(%s) error: %w", key, err)
+	}
+	return nil
+}
+
+func (r *sqlRepository) Update(table string, id int, key string, val any) (*Change, error) {
+	if _, ok := primaryKeys[table]; !ok {
+		return nil, fmt.Errorf("Repository.Update(%s) error: %w", table, ErrUnknownTable)
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("Repository.Update(%s, %d, %s) error: %w", table, id, key, err)
+	}
+	defer tx.Rollback()
+	c, err := updateRow(tx, table, id, key, val)
+	if err != nil {
+		return nil, fmt.Errorf("Repository.Update(%s, %d, %s) error: %w", table, id, key, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("Repository.Update(%s, %d, %s) error: %w", table, id, key, err)
+	}
+	return c, nil
+}
+
+/* Sets column key of row id in table to val, if it differs, and returns what changed in the row or nil if nothing did or there is no such row */
+func updateRow(q querier, table string, id int, key string, val any) (*Change, error) {
+	columns, before, err := readRow(q, table, id)
+	if err != nil || len(columns) == 0 {
+		return nil, err
+	}
+	if !slices.Contains(columns, key) {
+		return nil, fmt.Errorf("%s.%s: %w", table, key, ErrUnknownColumn)
+	}
+	query := `UPDATE ` + table + ` SET ` + key + ` = ? WHERE ` + primaryKeys[table] + ` = ? AND ` + key + ` IS NOT ?`
+	res, err := q.Exec(query, val, id, val)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, nil
+	}
+	_, after, err := readRow(q, table, id)
+	if err != nil {
+		return nil, err
+	}
+	c := &Change{Table: table, ID: id, Key: key, Fields: diffRow(columns, before, after)}
+	if c.Empty() {
+		return nil, nil
+	}
+	return c, nil
+}
+
+func (r *sqlRepository) Revert(c *Change) error {
+	if err := r.restore(c, true); err != nil {
+		return fmt.Errorf("Repository.Revert(%s, %d) error: %w", c.Table, c.ID, err)
+	}
+	return nil
+}
+
+func (r *sqlRepository) Reapply(c *Change) error {
+	if err := r.restore(c, false); err != nil {
+		return fmt.Errorf("Repository.Reapply(%s, %d) error: %w", c.Table, c.ID, err)
+	}
+	return nil
+}
+
+/* Restores the row of c in one transaction, see restore */
+func (r *sqlRepository) restore(c *Change, before bool) error {
+	if _, ok := primaryKeys[c.Table]; !ok {
+		return ErrUnknownTable
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := restore(tx, c.Table, c.ID, c.Fields, before); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+/* Returns the column col of row id in table, or an empty string if there is no such row */
+func (r *sqlRepository) name(table, col string, id int) (string, error) {
+	if id == 0 {
+		return "", nil
+	}
+	var s sql.NullString
+	query := `SELECT ` + col + ` FROM ` + table + ` WHERE ` + primaryKeys[table] + ` = @0`
+	err := r.db.QueryRow(query, id).Scan(&s)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+	return s.String, nil
+}
@@ -0,0 +1,428 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrNoSale          = errors.New("no such sale")
+	ErrNotForSale      = errors.New("item is not for sale")
+	ErrOutOfStock      = errors.New("not enough in stock")
+	ErrInvalidQuantity = errors.New("invalid quantity")
+)
+
+type SaleKind int
+
+const (
+	SaleSold SaleKind = iota + 1
+	SaleReturn
+	SaleCorrection
+	SaleReceipt
+)
+
+func (k SaleKind) String() string {
+	switch k {
+	case SaleReturn:
+		return "return"
+	case SaleCorrection:
+		return "correction"
+	case SaleReceipt:
+		return "receipt"
+	default:
+		return "sale"
+	}
+}
+
+/*
+A row of the sale ledger. Rows are never changed, a return or correction is a row of its own that refers to the sale it
+changes and holds the difference it makes, so that what a sale comes to is the sum of it and the rows that refer to it.
+Amount is Quantity times Price and VatAmount the VAT on it, both in Currency. A receipt of goods refers to no sale,
+its Quantity is the negative of what it puts in stock and it has no amount.
+*/
+type Sale struct {
+	ID        int
+	SaleID    int // The sale a return or correction changes, ID for a sale
+	ItemID    int
+	Kind      SaleKind
+	Quantity  float64
+	Price     float64 // Per unit, without VAT
+	Vat       float64 // Percent
+	Amount    float64
+	VatAmount float64
+	Currency  string
+	Buyer     string
+	Reason    string
+	Time      time.Time
+}
+
+/* What was sold and returned in a period, and how long the items sold had been in the database */
+type SalesReport struct {
+	From, To    time.Time
+	Revenue     []Revenue       // By currency
+	Categories  []CategorySales // Most sold first
+	Quantity    float64         // Sold less returned
+	Sales       int             // Sales made, not counting returns and corrections
+	AverageDays float64         // From DateCreated to the sale, over the sales made
+}
+
+type Revenue struct {
+	Currency  string
+	Amount    float64
+	VatAmount float64
+}
+
+type CategorySales struct {
+	CatID    int
+	Name     string
+	Quantity float64
+}
+
+/* Reasons given to the status changes sales make */
+const (
+	soldOutReason = "Slutsåld"
+	restockReason = "Åter i lager"
+	receiptReason = "Inleverans"
+)
+
+func (r *sqlRepository) Sell(s Sale) (*Sale, error) {
+	if s.Quantity <= 0 {
+		return nil, fmt.Errorf("Repository.Sell(%d) error: %w", s.ItemID, ErrInvalidQuantity)
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("Repository.Sell(%d) error: %w", s.ItemID, err)
+	}
+	defer tx.Rollback()
+	var status int
+	var stock float64
+	var currency sql.NullString
+	err = tx.QueryRow(`SELECT ItemStatusID, Stock, Currency FROM Item WHERE ItemID = ?`, s.ItemID).Scan(&status, &stock, &currency)
+	if err != nil {
+		return nil, fmt.Errorf("Repository.Sell(%d) error: %w", s.ItemID, err)
+	}
+	if status != ItemStatusAvailable && status != ItemStatusReserved {
+		return nil, fmt.Errorf("Repository.Sell(%d) error: %w", s.ItemID, ErrNotForSale)
+	}
+	if s.Quantity > stock {
+		return nil, fmt.Errorf("Repository.Sell(%d) error: %w", s.ItemID, ErrOutOfStock)
+	}
+	if s.Currency == "" {
+		s.Currency = currency.String
+	}
+	s.Kind, s.SaleID = SaleSold, 0
+	s.Amount = s.Quantity * s.Price
+	s.VatAmount = s.Amount * s.Vat / 100
+	if err := insertSale(tx, &s); err != nil {
+		return nil, fmt.Errorf("Repository.Sell(%d) error: %w", s.ItemID, err)
+	}
+	if err := restock(tx, s, stock-s.Quantity); err != nil {
+		return nil, fmt.Errorf("Repository.Sell(%d) error: %w", s.ItemID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("Repository.Sell(%d) error: %w", s.ItemID, err)
+	}
+	return &s, nil
+}
+
+func (r *sqlRepository) ReturnSale(id int, quantity float64, reason string) (*Sale, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("Repository.ReturnSale(%d) error: %w", id, err)
+	}
+	defer tx.Rollback()
+	sale, err := saleTotal(tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("Repository.ReturnSale(%d) error: %w", id, err)
+	}
+	if quantity <= 0 || quantity > sale.Quantity {
+		return nil, fmt.Errorf("Repository.ReturnSale(%d) error: %w", id, ErrInvalidQuantity)
+	}
+	share := quantity / sale.Quantity
+	s := Sale{
+		SaleID:    id,
+		ItemID:    sale.ItemID,
+		Kind:      SaleReturn,
+		Quantity:  -quantity,
+		Price:     sale.Amount / sale.Quantity,
+		Vat:       sale.Vat,
+		Amount:    -sale.Amount * share,
+		VatAmount: -sale.VatAmount * share,
+		Currency:  sale.Currency,
+		Buyer:     sale.Buyer,
+		Reason:    reason,
+	}
+	if err := insertSale(tx, &s); err != nil {
+		return nil, fmt.Errorf("Repository.ReturnSale(%d) error: %w", id, err)
+	}
+	stock, err := itemStock(tx, s.ItemID)
+	if err != nil {
+		return nil, fmt.Errorf("Repository.ReturnSale(%d) error: %w", id, err)
+	}
+	if err := restock(tx, s, stock+quantity); err != nil {
+		return nil, fmt.Errorf("Repository.ReturnSale(%d) error: %w", id, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("Repository.ReturnSale(%d) error: %w", id, err)
+	}
+	return &s, nil
+}
+
+func (r *sqlRepository) CorrectSale(c Sale) (*Sale, error) {
+	if c.Quantity <= 0 {
+		return nil, fmt.Errorf("Repository.CorrectSale(%d) error: %w", c.SaleID, ErrInvalidQuantity)
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("Repository.CorrectSale(%d) error: %w", c.SaleID, err)
+	}
+	defer tx.Rollback()
+	sale, err := saleTotal(tx, c.SaleID)
+	if err != nil {
+		return nil, fmt.Errorf("Repository.CorrectSale(%d) error: %w", c.SaleID, err)
+	}
+	stock, err := itemStock(tx, sale.ItemID)
+	if err != nil {
+		return nil, fmt.Errorf("Repository.CorrectSale(%d) error: %w", c.SaleID, err)
+	}
+	if c.Quantity-sale.Quantity > stock {
+		return nil, fmt.Errorf("Repository.CorrectSale(%d) error: %w", c.SaleID, ErrOutOfStock)
+	}
+	amount := c.Quantity * c.Price
+	s := Sale{
+		SaleID:    c.SaleID,
+		ItemID:    sale.ItemID,
+		Kind:      SaleCorrection,
+		Quantity:  c.Quantity - sale.Quantity,
+		Price:     c.Price,
+		Vat:       c.Vat,
+		Amount:    amount - sale.Amount,
+		VatAmount: amount*c.Vat/100 - sale.VatAmount,
+		Currency:  sale.Currency,
+		Buyer:     c.Buyer,
+		Reason:    c.Reason,
+	}
+	if err := insertSale(tx, &s); err != nil {
+		return nil, fmt.Errorf("Repository.CorrectSale(%d) error: %w", c.SaleID, err)
+	}
+	if err := restock(tx, s, stock-s.Quantity); err != nil {
+		return nil, fmt.Errorf("Repository.CorrectSale(%d) error: %w", c.SaleID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("Repository.CorrectSale(%d) error: %w", c.SaleID, err)
+	}
+	return &s, nil
+}
+
+func (r *sqlRepository) Receive(itemID int, quantity float64, reason string) (*Sale, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("Repository.Receive(%d) error: %w", itemID, ErrInvalidQuantity)
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("Repository.Receive(%d) error: %w", itemID, err)
+	}
+	defer tx.Rollback()
+	var stock float64
+	var currency sql.NullString
+	err = tx.QueryRow(`SELECT Stock, Currency FROM Item WHERE ItemID = ?`, itemID).Scan(&stock, &currency)
+	if err != nil {
+		return nil, fmt.Errorf("Repository.Receive(%d) error: %w", itemID, err)
+	}
+	if reason == "" {
+		reason = receiptReason
+	}
+	s := Sale{ItemID: itemID, Kind: SaleReceipt, Quantity: -quantity, Currency: currency.String, Reason: reason}
+	if err := insertSale(tx, &s); err != nil {
+		return nil, fmt.Errorf("Repository.Receive(%d) error: %w", itemID, err)
+	}
+	if err := restock(tx, s, stock+quantity); err != nil {
+		return nil, fmt.Errorf("Repository.Receive(%d) error: %w", itemID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("Repository.Receive(%d) error: %w", itemID, err)
+	}
+	return &s, nil
+}
+
+func (r *sqlRepository) Sale(id int) (*Sale, error) {
+	s, err := saleTotal(r.db, id)
+	if err != nil {
+		return nil, fmt.Errorf("Repository.Sale(%d) error: %w", id, err)
+	}
+	return s, nil
+}
+
+func (r *sqlRepository) Sales(itemID int) ([]Sale, error) {
+	sales, err := querySales(r.db, `WHERE ItemID = ? ORDER BY SaleID DESC`, itemID)
+	if err != nil {
+		return sales, fmt.Errorf("Repository.Sales(%d) error: %w", itemID, err)
+	}
+	return sales, nil
+}
+
+func (r *sqlRepository) SalesReport(from, to time.Time) (*SalesReport, error) {
+	report := &SalesReport{From: from, To: to}
+	period := []any{from.UTC().Format(subsec), to.UTC().Format(subsec)}
+	rows, err := r.db.Query(`SELECT Currency, total(Amount), total(VatAmount) FROM Sale
+WHERE Kind <> ? AND DateSold >= ? AND DateSold < ?
+GROUP BY Currency ORDER BY Currency`, append([]any{SaleReceipt}, period...)...)
+	if err != nil {
+		return nil, fmt.Errorf("Repository.SalesReport() error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v Revenue
+		var currency sql.NullString
+		if err := rows.Scan(&currency, &v.Amount, &v.VatAmount); err != nil {
+			return nil, fmt.Errorf("Repository.SalesReport() error: %w", err)
+		}
+		v.Currency = currency.String
+		report.Revenue = append(report.Revenue, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Repository.SalesReport() error: %w", err)
+	}
+
+	rows, err = r.db.Query(`SELECT i.CatID, c.Name, total(s.Quantity) AS n
+FROM Sale s LEFT JOIN Item i ON i.ItemID = s.ItemID LEFT JOIN Category c ON c.CatID = i.CatID
+WHERE s.Kind <> ? AND s.DateSold >= ? AND s.DateSold < ?
+GROUP BY i.CatID HAVING n <> 0 ORDER BY n DESC, c.Name`, append([]any{SaleReceipt}, period...)...)
+	if err != nil {
+		return nil, fmt.Errorf("Repository.SalesReport() error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v CategorySales
+		var id sql.NullInt64
+		var name sql.NullString
+		if err := rows.Scan(&id, &name, &v.Quantity); err != nil {
+			return nil, fmt.Errorf("Repository.SalesReport() error: %w", err)
+		}
+		v.CatID, v.Name = int(id.Int64), name.String
+		report.Categories = append(report.Categories, v)
+		report.Quantity += v.Quantity
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Repository.SalesReport() error: %w", err)
+	}
+
+	var days sql.NullFloat64
+	err = r.db.QueryRow(`SELECT count(*), avg(julianday(s.DateSold) - julianday(i.DateCreated))
+FROM Sale s JOIN Item i ON i.ItemID = s.ItemID
+WHERE s.Kind = ? AND s.DateSold >= ? AND s.DateSold < ?`, append([]any{SaleSold}, period...)...).Scan(&report.Sales, &days)
+	if err != nil {
+		return nil, fmt.Errorf("Repository.SalesReport() error: %w", err)
+	}
+	report.AverageDays = days.Float64
+	return report, nil
+}
+
+/* Writes s to the ledger and sets its ID, and its SaleID if it is a sale. A zero Time is now. */
+func insertSale(tx *sql.Tx, s *Sale) error {
+	if s.Time.IsZero() {
+		s.Time = time.Now()
+	}
+	res, err := tx.Exec(`INSERT INTO Sale (RefSaleID, ItemID, Kind, Quantity, Price, Vat, Amount, VatAmount, Currency, Buyer, Reason, DateSold)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.SaleID, s.ItemID, s.Kind, s.Quantity, s.Price, s.Vat, s.Amount, s.VatAmount, s.Currency, s.Buyer, s.Reason, s.Time.UTC().Format(subsec))
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	s.ID = int(id)
+	if s.Kind == SaleSold {
+		s.SaleID = s.ID
+		_, err = tx.Exec(`UPDATE Sale SET RefSaleID = SaleID WHERE SaleID = ?`, s.ID)
+	}
+	return err
+}
+
+/*
+Sets the stock of the item s changes, which is sold when none is left and available again when a sold item is back in
+stock. The status changes record the sale s, or the return or correction of it.
+*/
+func restock(tx *sql.Tx, s Sale, stock float64) error {
+	if _, err := updateRow(tx, "Item", s.ItemID, "Stock", stock); err != nil {
+		return err
+	}
+	var status int
+	if err := tx.QueryRow(`SELECT ItemStatusID FROM Item WHERE ItemID = ?`, s.ItemID).Scan(&status); err != nil {
+		return err
+	}
+	switch {
+	case stock <= 0 && (status == ItemStatusAvailable || status == ItemStatusReserved):
+		reason := s.Reason
+		if reason == "" {
+			reason = soldOutReason
+		}
+		_, err := setStatus(tx, StatusChange{ItemID: s.ItemID, To: ItemStatusSold, Time: s.Time, Reason: reason, Contact: s.Buyer, Price: s.Price, Sold: s.Time})
+		return err
+	case stock > 0 && status == ItemStatusSold:
+		_, err := setStatus(tx, StatusChange{ItemID: s.ItemID, To: ItemStatusAvailable, Time: s.Time, Reason: restockReason})
+		return err
+	}
+	return nil
+}
+
+func itemStock(q sqlQuerier, id int) (float64, error) {
+	var stock float64
+	err := q.QueryRow(`SELECT Stock FROM Item WHERE ItemID = ?`, id).Scan(&stock)
+	return stock, err
+}
+
+/*
+Returns sale id as it stands after its returns and corrections: the quantity, amount and VAT are the sums of them, the
+price, VAT rate and buyer those of the latest correction. ErrNoSale if there is no such sale.
+*/
+func saleTotal(q sqlQuerier, id int) (*Sale, error) {
+	sales, err := querySales(q, `WHERE RefSaleID = ? ORDER BY SaleID`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(sales) == 0 || sales[0].Kind != SaleSold {
+		return nil, ErrNoSale
+	}
+	total := sales[0]
+	for _, s := range sales[1:] {
+		total.Quantity += s.Quantity
+		total.Amount += s.Amount
+		total.VatAmount += s.VatAmount
+		if s.Kind == SaleCorrection {
+			total.Price, total.Vat, total.Buyer = s.Price, s.Vat, s.Buyer
+		}
+	}
+	return &total, nil
+}
+
+type sqlQuerier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func querySales(q sqlQuerier, where string, args ...any) ([]Sale, error) {
+	var sales []Sale
+	rows, err := q.Query(`SELECT SaleID, RefSaleID, ItemID, Kind, Quantity, Price, Vat, Amount, VatAmount, Currency, Buyer, Reason, DateSold
+FROM Sale `+where, args...)
+	if err != nil {
+		return sales, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s Sale
+		var currency, buyer, reason, sold sql.NullString
+		err := rows.Scan(&s.ID, &s.SaleID, &s.ItemID, &s.Kind, &s.Quantity, &s.Price, &s.Vat, &s.Amount, &s.VatAmount, &currency, &buyer, &reason, &sold)
+		if err != nil {
+			return sales, err
+		}
+		s.Currency, s.Buyer, s.Reason = currency.String, buyer.String, reason.String
+		s.Time, _ = time.Parse(subsec, sold.String)
+		sales = append(sales, s)
+	}
+	return sales, rows.Err()
+}
@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSales(t *testing.T) {
+	r := testRepository(t)
+	status := func(id int) (int, float64) {
+		t.Helper()
+		var status int
+		var stock float64
+		if err := r.(*sqlRepository).db.QueryRow(`SELECT ItemStatusID, Stock FROM Item WHERE ItemID = ?`, id).Scan(&status, &stock); err != nil {
+			t.Fatal(err)
+		}
+		return status, stock
+	}
+
+	/* Item 1 is available with 2 in stock, item 2 is sold */
+	if _, err := r.Sell(Sale{ItemID: 1, Quantity: 3}); !errors.Is(err, ErrOutOfStock) {
+		t.Errorf("selling more than the stock error = %v, want %v", err, ErrOutOfStock)
+	}
+	if _, err := r.Sell(Sale{ItemID: 2, Quantity: 1}); !errors.Is(err, ErrNotForSale) {
+		t.Errorf("selling a sold item error = %v, want %v", err, ErrNotForSale)
+	}
+	if _, err := r.Sell(Sale{ItemID: 1}); !errors.Is(err, ErrInvalidQuantity) {
+		t.Errorf("selling nothing error = %v, want %v", err, ErrInvalidQuantity)
+	}
+
+	first, err := r.Sell(Sale{ItemID: 1, Quantity: 1, Price: 500, Vat: 25, Buyer: "Anna", Time: date("2025-02-14")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Amount != 500 || first.VatAmount != 125 || first.Currency != "SEK" || first.SaleID != first.ID {
+		t.Errorf("Sell = %+v", first)
+	}
+	if s, n := status(1); s != ItemStatusAvailable || n != 1 {
+		t.Errorf("after selling 1 of 2 status %d, stock %g", s, n)
+	}
+	second, err := r.Sell(Sale{ItemID: 1, Quantity: 1, Price: 400, Time: date("2025-02-15")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, n := status(1); s != ItemStatusSold || n != 0 {
+		t.Errorf("after selling the last one status %d, stock %g", s, n)
+	}
+	if sold, _ := r.SoldStatus(1); sold == nil || sold.Price != 400 || sold.Reason != soldOutReason {
+		t.Errorf("SoldStatus after selling out = %+v", sold)
+	}
+
+	/* Returning the last one makes the item available again */
+	if _, err := r.ReturnSale(second.ID, 2, ""); !errors.Is(err, ErrInvalidQuantity) {
+		t.Errorf("returning more than was sold error = %v, want %v", err, ErrInvalidQuantity)
+	}
+	ret, err := r.ReturnSale(second.ID, 1, "Trasig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret.Kind != SaleReturn || ret.Quantity != -1 || ret.Amount != -400 || ret.SaleID != second.ID {
+		t.Errorf("ReturnSale = %+v", ret)
+	}
+	if s, n := status(1); s != ItemStatusAvailable || n != 1 {
+		t.Errorf("after the return status %d, stock %g", s, n)
+	}
+	if _, err := r.ReturnSale(second.ID, 1, ""); !errors.Is(err, ErrInvalidQuantity) {
+		t.Errorf("returning a returned sale error = %v, want %v", err, ErrInvalidQuantity)
+	}
+
+	if _, err := r.CorrectSale(Sale{SaleID: first.ID, Quantity: 1, Price: 450, Vat: 25, Buyer: "Bo"}); err != nil {
+		t.Fatal(err)
+	}
+	sale, err := r.Sale(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sale.Quantity != 1 || sale.Amount != 450 || sale.VatAmount != 112.5 || sale.Buyer != "Bo" || !sale.Time.Equal(date("2025-02-14")) {
+		t.Errorf("Sale after the correction = %+v", sale)
+	}
+	if _, err := r.Sale(ret.ID); !errors.Is(err, ErrNoSale) {
+		t.Errorf("Sale of a return error = %v, want %v", err, ErrNoSale)
+	}
+	if sales, _ := r.Sales(1); len(sales) != 4 || sales[0].Kind != SaleCorrection {
+		t.Errorf("Sales(1) = %+v", sales)
+	}
+
+	/* The return and the correction are made now, after February */
+	report, err := r.SalesReport(date("2025-02-01"), date("2025-03-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Revenue{{"SEK", 900, 125}}; !slices.Equal(report.Revenue, want) {
+		t.Errorf("Revenue = %v, want %v", report.Revenue, want)
+	}
+	if want := []CategorySales{{19, "Stolar", 2}}; !slices.Equal(report.Categories, want) {
+		t.Errorf("Categories = %v, want %v", report.Categories, want)
+	}
+	if days := (29.0 + 14.0/24 + 30.0 + 14.0/24) / 2; report.Sales != 2 || math.Abs(report.AverageDays-days) > 0.001 {
+		t.Errorf("Sales = %d, AverageDays = %g, want 2, %g", report.Sales, report.AverageDays, days)
+	}
+	report, err = r.SalesReport(date("2025-01-01"), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Revenue{{"SEK", 450, 112.5}}; report.Quantity != 1 || !slices.Equal(report.Revenue, want) {
+		t.Errorf("whole report Quantity = %g, Revenue = %v, want 1, %v", report.Quantity, report.Revenue, want)
+	}
+
+	/* An item with sales stays in the trash */
+	if _, err := r.SetStatus(StatusChange{ItemID: 1, To: ItemStatusDeleted}); err != nil {
+		t.Fatal(err)
+	}
+	if ids, err := r.ExpiredTrash(time.Now().Add(time.Hour)); err != nil || slices.Contains(ids, 1) {
+		t.Errorf("ExpiredTrash = %v, %v, want no item 1", ids, err)
+	}
+	if purged, err := r.PurgeItems([]int{1}); err != nil || len(purged) != 0 {
+		t.Errorf("PurgeItems of an item with sales = %v, %v, want none", purged, err)
+	}
+}
+
+func TestReceive(t *testing.T) {
+	r := testRepository(t)
+	if _, err := r.Receive(1, 0, ""); !errors.Is(err, ErrInvalidQuantity) {
+		t.Errorf("receiving nothing error = %v, want %v", err, ErrInvalidQuantity)
+	}
+	if _, err := r.Receive(99, 1, ""); err == nil {
+		t.Error("receiving a missing item gave no error")
+	}
+
+	/* Item 2 is sold with 4 in stock, a receipt makes it available again */
+	s, err := r.Receive(2, 2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Kind != SaleReceipt || s.Quantity != -2 || s.Amount != 0 || s.SaleID != 0 || s.Reason != receiptReason {
+		t.Errorf("Receive = %+v", s)
+	}
+	var status int
+	var stock float64
+	if err := r.(*sqlRepository).db.QueryRow(`SELECT ItemStatusID, Stock FROM Item WHERE ItemID = 2`).Scan(&status, &stock); err != nil {
+		t.Fatal(err)
+	}
+	if status != ItemStatusAvailable || stock != 6 {
+		t.Errorf("after Receive status %d, stock %g, want %d, 6", status, stock, ItemStatusAvailable)
+	}
+	if sales, _ := r.Sales(2); len(sales) != 1 || sales[0].Kind != SaleReceipt {
+		t.Errorf("Sales(2) = %+v", sales)
+	}
+	report, err := r.SalesReport(date("2025-01-01"), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Quantity != 0 || len(report.Revenue) != 0 || len(report.Categories) != 0 {
+		t.Errorf("report with only a receipt = %+v", report)
+	}
+}
@@ -0,0 +1,214 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrInvalidPattern = errors.New("invalid regular expression")
+
+/* A RegExp search term that does not compile, Reason says why */
+type PatternError struct {
+	Term   string
+	Reason string
+}
+
+func (e *PatternError) Error() string {
+	return fmt.Sprintf("%s %q: %s", ErrInvalidPattern, e.Term, e.Reason)
+}
+
+func (e *PatternError) Is(target error) bool {
+	return target == ErrInvalidPattern
+}
+
+/* The columns a Search can look in, all of them are in the full-text index */
+var SearchColumns = []string{"Name", "Manufacturer", "ModelName", "ModelDesc", "Notes", "LongDesc", "AddDesc"}
+
+/* A search term and where and how to look for it */
+type Search struct {
+	Term  string
+	Scope map[string]bool
+	Match SearchTermMatch
+	Sort  SortSpec
+}
+
+/* Returns the columns in scope */
+func (e Search) columns() []string {
+	var keys []string
+	for _, column := range SearchColumns {
+		if e.Scope[column] {
+			keys = append(keys, column)
+		}
+	}
+	return keys
+}
+
+/* The fewest characters the trigram full-text index can match */
+const fullTextMinLength = 3
+
+/*
+Reports whether the search can use the full-text index. Ends with, equals and regexp matches need the whole column
+value, and terms shorter than a trigram match nothing in the index, so those use LIKE.
+*/
+func (e Search) fullText() bool {
+	if utf8.RuneCountInString(e.Term) < fullTextMinLength || len(e.columns()) == 0 {
+		return false
+	}
+	return e.Match == MatchBeginsWith || e.Match == MatchContains
+}
+
+/*
+Returns the FTS5 query for the term, limited to the columns in scope. The index is of trigrams, so the term as a
+phrase matches anywhere in a column as LIKE '%term%' does, or at its start with MatchBeginsWith.
+*/
+func (e Search) matchQuery() string {
+	match := `"` + strings.ReplaceAll(e.Term, `"`, `""`) + `"`
+	if e.Match == MatchBeginsWith {
+		match = "^" + match
+	}
+	return "{" + strings.Join(e.columns(), " ") + "} : (" + match + ")"
+}
+
+/* Returns the pattern a RegExp search matches with. Like the other match modes it ignores case. */
+func (e Search) pattern() string {
+	return "(?i)" + e.Term
+}
+
+/* Returns a *PatternError if the term of a RegExp search does not compile */
+func (e Search) check() error {
+	if e.Match != RegExp || e.Term == "" {
+		return nil
+	}
+	if _, err := regexp.Compile(e.pattern()); err != nil {
+		reason := err.Error()
+		var serr *syntax.Error
+		if errors.As(err, &serr) {
+			reason = serr.Code.String()
+		}
+		return &PatternError{Term: e.Term, Reason: reason}
+	}
+	return nil
+}
+
+/* Adds the condition that the term matches one of the columns in scope, unless the term is empty. This is how searches run without the full-text index. LIKE compares through the casefold function, so å matches Å. */
+func (e Search) where(w *where) {
+	keys := e.columns()
+	if e.Term == "" || len(keys) < 1 {
+		return
+	}
+	op := "LIKE"
+	var term string
+	switch e.Match {
+	case RegExp:
+		op = "REGEXP"
+		term = e.pattern()
+	case MatchBeginsWith:
+		term = e.Term + "%"
+	case MatchEndsWith:
+		term = "%" + e.Term
+	case MatchContains:
+		term = "%" + e.Term + "%"
+	default:
+		// MatchEquals
+		term = e.Term
+	}
+	conds := make([]string, len(keys))
+	args := make([]any, len(keys))
+	for i, key := range keys {
+		conds[i] = key + " " + op + " ?"
+		if op == "LIKE" {
+			conds[i] = "casefold(" + key + ") LIKE casefold(?)"
+		}
+		args[i] = term
+	}
+	w.add("("+strings.Join(conds, " OR ")+")", args...)
+}
+
+/* Restrictions on the items listed, zero values mean no restriction */
+type Filter struct {
+	CatID                int
+	MfrID                int
+	ModelID              int
+	Category             string // Name of a category, its subcategories are included
+	Manufacturer         string
+	Model                string
+	ItemStatusID         int
+	Word                 string // A search word of the item
+	Images               int    // 1 for items with an image URL, -1 for items without
+	Width, Height, Depth Range
+	Volume, Weight       Range
+	CreatedFrom          time.Time // Inclusive
+	CreatedUntil         time.Time // Exclusive
+	ModifiedFrom         time.Time
+	ModifiedUntil        time.Time
+}
+
+/* Bounds of a measurement, 0 means unbounded. Unit is the Metric text of the bounds, stored values in other units are converted to it, "" compares them as they are. */
+type Range struct {
+	Min, Max float64
+	Unit     string
+}
+
+/* The units of the Metric table by what they measure, the first is the unit of a number without one */
+var (
+	LengthUnits = []string{"cm", "mm", "dm", "m"}
+	VolumeUnits = []string{"l", "ml", "cl", "dl"}
+	WeightUnits = []string{"kg", "g", "hg"}
+)
+
+/* Selects the categories named by the argument and their subcategories */
+const categoryTree = `CatID IN (WITH RECURSIVE Tree(CatID) AS (
+SELECT CatID FROM Category WHERE Name = ? COLLATE NOCASE
+UNION SELECT Category.CatID FROM Category JOIN Tree ON Category.ParentID = Tree.CatID)
+SELECT CatID FROM Tree)`
+
+/* Selects the items that have the search word of the argument */
+const searchWord = `ItemID IN (SELECT ItemID FROM SearchWords_Association JOIN SearchWords_Vocabulary USING (WordID)
+WHERE WordString = ? COLLATE NOCASE)`
+
+/* Adds a condition for every restriction of f */
+func (f Filter) where(w *where) {
+	if f.CatID != 0 {
+		w.add("CatID = ?", f.CatID)
+	} else if f.Category != "" {
+		w.add(categoryTree, f.Category)
+	}
+	if f.MfrID != 0 {
+		w.add("MfrID = ?", f.MfrID)
+	} else if f.Manufacturer != "" {
+		w.add("Manufacturer = ? COLLATE NOCASE", f.Manufacturer)
+	}
+	if f.ModelID != 0 {
+		w.add("ModelID = ?", f.ModelID)
+	} else if f.Model != "" {
+		w.add("ModelName = ? COLLATE NOCASE", f.Model)
+	}
+	if f.ItemStatusID != 0 {
+		w.add("ItemStatusID = ?", f.ItemStatusID)
+	}
+	if f.Word != "" {
+		w.add(searchWord, f.Word)
+	}
+	if f.Images != 0 {
+		images := "coalesce(ImgURL1, '') || coalesce(ImgURL2, '') || coalesce(ImgURL3, '') || coalesce(ImgURL4, '') || coalesce(ImgURL5, '')"
+		if f.Images > 0 {
+			w.add(images + " <> ''")
+		} else {
+			w.add(images + " = ''")
+		}
+	}
+	w.measure("Width", "LengthUnitID", f.Width)
+	w.measure("Height", "LengthUnitID", f.Height)
+	w.measure("Depth", "LengthUnitID", f.Depth)
+	w.measure("Volume", "VolumeUnitID", f.Volume)
+	w.measure("Weight", "WeightUnitID", f.Weight)
+	w.from("DateCreated", f.CreatedFrom)
+	w.until("DateCreated", f.CreatedUntil)
+	w.from("DateModified", f.ModifiedFrom)
+	w.until("DateModified", f.ModifiedUntil)
+}
@@ -0,0 +1,570 @@
+package domain
+
+import (
+	"UppSpar/backend/schema"
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*
+Returns a repository on an in-memory database built by the migrations and seeds of the real one, with the full-text index
+if this build has FTS5, and these items:
+
+	1 Stol   Kinnarps  Plus  cat 19 (Stolar, under Kontor)  w 45 cm   image  available  created 2025-01-15
+	2 Bord   O'Brien   Oval  cat 8 (Bord, under Hushåll)    w 120 cm         sold       created 2025-03-01
+	3 Pall   Kinnarps  Oval  cat 19                         w 300 mm         available  created 2025-06-30
+	4 Soffa  IKEA      Plus  cat 18                         w 200 cm  image  deleted    created 2025-02-01
+
+Item 3 has its measurements in mm and g, the others in cm and kg. The prices are 500, 1000, 200 and 700, the stock 2, 4, 5 and 5.
+Items 1, 3 and 4 have the search word stapelbar, item 2 matt svart and item 4 Soffbord.
+Model 10 is item 1 as it is and model 12 is named Modell 12 with no width, for the model data trigger.
+Item 4 was deleted 2025-02-01 when it was available, and has a condition and a function as item 1 has.
+*/
+func testRepository(t *testing.T) Repository {
+	t.Helper()
+	db, err := sql.Open(DriverName, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	if err := schema.NewMigrator(db, "main", Migrations).Migrate(nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		`INSERT INTO Manufacturer (MfrID, Name) VALUES (4, 'O''Brien')`,
+		`INSERT INTO Model (ModelID, Name, MfrID, CatID, Width, Height, Depth, Weight) VALUES
+(10, 'Plus', 3, 19, 45, 80, 50, 7), (12, 'Modell 12', 3, 19, 0, 80, 50, 7)`,
+		`INSERT INTO Item (ItemID, Name, CatID, MfrID, Manufacturer, ModelID, ModelName, ImgURL1, ImgURL2,
+Width, Height, Depth, Volume, Weight, LengthUnitID, VolumeUnitID, WeightUnitID, ItemStatusID, Price, Stock, DateCreated, DateModified) VALUES
+(1, 'Stol', 19, 3, 'Kinnarps', 10, 'Plus', 'http://a', '', 45, 80, 50, 0, 7, 2, 11, 7, 1, 500, 2, '2025-01-15 10:00:00', '2025-04-01 10:00:00'),
+(2, 'Bord', 8, 4, 'O''Brien', 11, 'Oval', '', '', 120, 72, 80, 0, 30, 2, 11, 7, 2, 1000, 4, '2025-03-01 10:00:00', '2025-03-01 10:00:00'),
+(3, 'Pall', 19, 3, 'Kinnarps', 12, 'Oval', '', '', 300, 450, 300, 0, 3000, 1, 11, 5, 1, 200, 5, '2025-06-30 23:59:59', '2025-07-01 10:00:00'),
+(4, 'Soffa', 18, 2, 'IKEA', 13, 'Plus', '', 'http://b', 200, 90, 90, 0, 60, 2, 11, 7, 5, 700, 5, '2025-02-01 10:00:00', '2025-02-01 10:00:00')`,
+		`INSERT INTO SearchWords_Vocabulary VALUES (1, 'stapelbar'), (2, 'matt svart'), (3, 'Soffbord')`,
+		`INSERT INTO SearchWords_Association VALUES (1, 1), (3, 1), (4, 1), (2, 2), (4, 3)`,
+		`INSERT INTO Item_Condition VALUES (1, 4, ''), (4, 2, 'fläckig')`,
+		`INSERT INTO Item_Function VALUES (1, 1, true, true, ''), (4, 1, true, false, '')`,
+		`INSERT INTO Item_Parent VALUES (4, 1)`,
+		`INSERT INTO Item_Trash VALUES (4, '2025-02-01 10:00:00', 1)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %s", stmt, err)
+		}
+	}
+	var fts5 bool
+	db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5)
+	for i := 0; fts5 && i < len(SearchIndexTables); i++ {
+		if _, err := db.Exec(SearchIndexTables[i]); err != nil {
+			t.Fatalf("%s: %s", SearchIndexTables[i], err)
+		}
+	}
+	return NewRepository(db)
+}
+
+/* Sorts by ItemID so results compare as lists */
+var byItemID = SortSpec{{Key: "ItemID"}}
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestFilterItemIDs(t *testing.T) {
+	r := testRepository(t)
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []int
+	}{
+		{"none", Filter{}, []int{1, 2, 3}},
+		{"CatID", Filter{CatID: 8}, []int{2}},
+		{"Category with subcategories", Filter{Category: "kontor"}, []int{1, 3}},
+		{"Category leaf", Filter{Category: "Stolar"}, []int{1, 3}},
+		{"CatID before Category", Filter{CatID: 8, Category: "Stolar"}, []int{2}},
+		{"MfrID", Filter{MfrID: 3}, []int{1, 3}},
+		{"Manufacturer", Filter{Manufacturer: "kinnarps"}, []int{1, 3}},
+		{"Manufacturer with apostrophe", Filter{Manufacturer: "O'Brien"}, []int{2}},
+		{"Manufacturer is not Model", Filter{Manufacturer: "Oval"}, nil},
+		{"ModelID", Filter{ModelID: 12}, []int{3}},
+		{"Model", Filter{Model: "oval"}, []int{2, 3}},
+		{"Model is not Manufacturer", Filter{Model: "Kinnarps"}, nil},
+		{"Manufacturer and Model", Filter{Manufacturer: "Kinnarps", Model: "Oval"}, []int{3}},
+		{"ItemStatusID", Filter{ItemStatusID: ItemStatusSold}, []int{2}},
+		{"deleted", Filter{ItemStatusID: ItemStatusDeleted}, []int{4}},
+		{"with images", Filter{Images: 1}, []int{1}},
+		{"without images", Filter{Images: -1}, []int{2, 3}},
+		{"min Width", Filter{Width: Range{Min: 45, Unit: "cm"}}, []int{1, 2}},
+		{"max Width", Filter{Width: Range{Max: 45, Unit: "cm"}}, []int{1, 3}},
+		{"Width range", Filter{Width: Range{Min: 40, Max: 50, Unit: "cm"}}, []int{1}},
+		{"Width in mm", Filter{Width: Range{Min: 300, Max: 450, Unit: "mm"}}, []int{1, 3}},
+		{"Width in m", Filter{Width: Range{Max: 0.3, Unit: "m"}}, []int{3}},
+		{"Width without unit", Filter{Width: Range{Min: 100}}, []int{2, 3}},
+		{"min Height", Filter{Height: Range{Min: 72, Unit: "cm"}}, []int{1, 2}},
+		{"max Height", Filter{Height: Range{Max: 72, Unit: "cm"}}, []int{2, 3}},
+		{"min Depth", Filter{Depth: Range{Min: 50, Unit: "cm"}}, []int{1, 2}},
+		{"max Depth", Filter{Depth: Range{Max: 30, Unit: "cm"}}, []int{3}},
+		{"min Volume", Filter{Volume: Range{Min: 1, Unit: "l"}}, nil},
+		{"max Volume", Filter{Volume: Range{Max: -1, Unit: "l"}}, nil},
+		{"min Weight", Filter{Weight: Range{Min: 7, Unit: "kg"}}, []int{1, 2}},
+		{"max Weight", Filter{Weight: Range{Max: 7, Unit: "kg"}}, []int{1, 3}},
+		{"Weight in g", Filter{Weight: Range{Min: 3000, Max: 7000, Unit: "g"}}, []int{1, 3}},
+		{"CreatedFrom", Filter{CreatedFrom: date("2025-03-01")}, []int{2, 3}},
+		{"CreatedUntil", Filter{CreatedUntil: date("2025-03-01")}, []int{1}},
+		{"created in June", Filter{CreatedFrom: date("2025-06-01"), CreatedUntil: date("2025-07-01")}, []int{3}},
+		{"ModifiedFrom", Filter{ModifiedFrom: date("2025-04-01")}, []int{1, 3}},
+		{"ModifiedUntil", Filter{ModifiedUntil: date("2025-04-01")}, []int{2}},
+		{"Word", Filter{Word: "Stapelbar"}, []int{1, 3}},
+		{"several", Filter{Category: "Kontor", Width: Range{Min: 40, Unit: "cm"}, Images: 1, ItemStatusID: ItemStatusAvailable}, []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.ItemIDs(Search{Sort: byItemID}, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterOnlyPlaceholders(t *testing.T) {
+	evil := "x' OR '1'='1"
+	f := Filter{
+		CatID: 1, MfrID: 2, ModelID: 3, Category: evil, Manufacturer: evil, Model: evil, ItemStatusID: 1, Word: evil, Images: 1,
+		Width: Range{1, 2, evil}, Height: Range{3, 4, "cm"}, Depth: Range{5, 6, ""},
+		Volume: Range{7, 8, "l"}, Weight: Range{9, 10, "kg"},
+		CreatedFrom: date("2025-01-01"), CreatedUntil: date("2025-02-01"),
+		ModifiedFrom: date("2025-03-01"), ModifiedUntil: date("2025-04-01"),
+	}
+	for _, f := range []Filter{f, {Category: evil, Manufacturer: evil, Model: evil}} {
+		w := &where{}
+		f.where(w)
+		query := w.String()
+		columns := strings.NewReplacer("ImgURL1", "", "ImgURL2", "", "ImgURL3", "", "ImgURL4", "", "ImgURL5", "")
+		if strings.Contains(query, evil) || strings.ContainsAny(columns.Replace(query), "0123456789") {
+			t.Errorf("value in query %q", query)
+		}
+		if n := strings.Count(query, "?"); n != len(w.args) {
+			t.Errorf("%d placeholders, %d arguments", n, len(w.args))
+		}
+	}
+	r := testRepository(t)
+	got, err := r.ItemIDs(Search{}, Filter{Manufacturer: evil, Model: evil})
+	if err != nil || len(got) != 0 {
+		t.Errorf("got %v, %v, want no items", got, err)
+	}
+}
+
+func TestSearchAndFilter(t *testing.T) {
+	r := testRepository(t)
+	s := Search{Term: "st", Scope: map[string]bool{"Name": true}, Match: MatchBeginsWith, Sort: byItemID}
+	got, err := r.ItemIDs(s, Filter{Manufacturer: "Kinnarps"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []int{1}) {
+		t.Errorf("got %v, want [1]", got)
+	}
+}
+
+func TestRegExpSearch(t *testing.T) {
+	r := testRepository(t)
+	for pattern, want := range map[string][]int{
+		"^st.*l$":       {1},
+		"^STOL":         {1},
+		"^(bord|pall)$": {2, 3},
+		`^ov`:           {2, 3},
+		`kinnarps|o'b`:  {1, 2, 3},
+		"^kon.*stol":    nil,
+		`\bnothing\b`:   nil,
+	} {
+		s := Search{Term: pattern, Scope: map[string]bool{"Name": true, "ModelName": true, "Manufacturer": true}, Match: RegExp, Sort: byItemID}
+		got, err := r.ItemIDs(s, Filter{})
+		if err != nil || !slices.Equal(got, want) {
+			t.Errorf("%q: got %v, %v, want %v", pattern, got, err, want)
+		}
+	}
+	db := addOrebro(t, r)
+	if _, err := db.Exec(`UPDATE Item SET Name = 'Kontorsstol' WHERE ItemID = 5`); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := r.ItemIDs(Search{Term: "^kon.*stol", Scope: map[string]bool{"Name": true}, Match: RegExp}, Filter{}); err != nil || !slices.Equal(got, []int{5}) {
+		t.Errorf("^kon.*stol: got %v, %v, want [5]", got, err)
+	}
+	for _, pattern := range []string{"([", "*stol", `\p{Nope}`} {
+		_, err := r.ItemIDs(Search{Term: pattern, Scope: map[string]bool{"Name": true}, Match: RegExp}, Filter{})
+		var perr *PatternError
+		if !errors.Is(err, ErrInvalidPattern) || !errors.As(err, &perr) || perr.Term != pattern {
+			t.Errorf("%q: got %v, want a %v", pattern, err, ErrInvalidPattern)
+		}
+	}
+}
+
+func TestParseQueryFilter(t *testing.T) {
+	r := testRepository(t)
+	s, f, err := ParseQuery(Search{Term: `kat:Kontor mfr:"kinnarps" width:>40 status:available sökord:stapelbar`, Sort: byItemID}, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Term != "" {
+		t.Errorf("term %q left", s.Term)
+	}
+	got, err := r.ItemIDs(s, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []int{1}) {
+		t.Errorf("got %v, want [1]", got)
+	}
+}
+
+func TestParseQueryFreeText(t *testing.T) {
+	for term, want := range map[string]string{
+		"Obs: repa":                 "Obs: repa",
+		"Note:x mfr:IKEA":           "Note:x",
+		`"kat:Stolar" stol`:         "kat:Stolar stol",
+		"kl:14 https://uppspar.se/": "kl:14 https://uppspar.se/",
+	} {
+		s, _, err := ParseQuery(Search{Term: term}, Filter{})
+		if err != nil || s.Term != want {
+			t.Errorf("ParseQuery(%q) term %q, %v, want %q", term, s.Term, err, want)
+		}
+	}
+	if _, _, err := ParseQuery(Search{Term: "stol width:abc"}, Filter{}); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("invalid value of a field: got %v, want %v", err, ErrInvalidQuery)
+	}
+}
+
+func TestMatchQuery(t *testing.T) {
+	scope := map[string]bool{"Name": true, "Notes": true}
+	for _, c := range []struct {
+		search   Search
+		fullText bool
+		want     string
+	}{
+		{Search{Term: "stol", Scope: scope, Match: MatchContains}, true, `{Name Notes} : ("stol")`},
+		{Search{Term: `kontor "stol`, Scope: scope, Match: MatchContains}, true, `{Name Notes} : ("kontor ""stol")`},
+		{Search{Term: "kont", Scope: scope, Match: MatchBeginsWith}, true, `{Name Notes} : (^"kont")`},
+		{Search{Term: "st", Scope: scope, Match: MatchContains}, false, ""},
+		{Search{Term: "stol", Scope: scope, Match: MatchEndsWith}, false, ""},
+	} {
+		if got := c.search.fullText(); got != c.fullText {
+			t.Errorf("%+v fullText() = %v, want %v", c.search, got, c.fullText)
+		}
+		if got := c.search.matchQuery(); c.fullText && got != c.want {
+			t.Errorf("%+v matchQuery() = %s, want %s", c.search, got, c.want)
+		}
+	}
+}
+
+/* Adds item 5, Hylla Örebro in blå lack, to the repository of testRepository */
+func addOrebro(t *testing.T, r Repository) *sql.DB {
+	t.Helper()
+	db := r.(*sqlRepository).db
+	if _, err := db.Exec(`INSERT INTO Item (ItemID, Name, Notes, CatID, ItemStatusID) VALUES (5, 'Hylla Örebro', 'blå lack', 13, 1)`); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestSearchCaseFolding(t *testing.T) {
+	r := testRepository(t)
+	addOrebro(t, r)
+	scope := map[string]bool{"Name": true, "Notes": true}
+	for _, s := range []Search{
+		{Term: "örebro", Scope: scope, Match: MatchContains},
+		{Term: "BLÅ", Scope: scope, Match: MatchContains},
+		{Term: "BLÅ LACK", Scope: scope, Match: MatchEquals},
+		{Term: "hylla ÖREBRO", Scope: scope, Match: MatchBeginsWith},
+		{Term: "Ö", Scope: scope, Match: MatchContains},
+	} {
+		s.Sort = byItemID
+		if got, err := r.ItemIDs(s, Filter{}); err != nil || !slices.Equal(got, []int{5}) {
+			t.Errorf("%q %v: got %v, %v, want [5]", s.Term, s.Match, got, err)
+		}
+	}
+}
+
+func TestFullTextFolding(t *testing.T) {
+	r := testRepository(t)
+	if !r.(*sqlRepository).hasFullText() {
+		t.Skip("built without -tags sqlite_fts5")
+	}
+	db := addOrebro(t, r)
+	scope := map[string]bool{"Name": true, "Notes": true}
+	for _, term := range []string{"örebro", "ÖREBRO", "orebro", "OREBRO", "BLÅ", "bla lack"} {
+		s := Search{Term: term, Scope: scope, Match: MatchContains}
+		var ids []int
+		rows, err := db.Query(`SELECT rowid FROM Item_FTS WHERE Item_FTS MATCH ?`, s.matchQuery())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for rows.Next() {
+			var id int
+			rows.Scan(&id)
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if !slices.Equal(ids, []int{5}) {
+			t.Errorf("MATCH %s = %v, want [5]", s.matchQuery(), ids)
+		}
+		if got, err := r.ItemIDs(s, Filter{}); err != nil || !slices.Equal(got, []int{5}) {
+			t.Errorf("%q: got %v, %v, want [5]", term, got, err)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		s     string
+		units []string
+		want  Range
+		err   string
+	}{
+		{"40", LengthUnits, Range{40, 40, "cm"}, ""},
+		{"40..60", LengthUnits, Range{40, 60, "cm"}, ""},
+		{"40-60cm", LengthUnits, Range{40, 60, "cm"}, ""},
+		{"400mm..600mm", LengthUnits, Range{400, 600, "mm"}, ""},
+		{"40 - 60 cm", LengthUnits, Range{40, 60, "cm"}, ""},
+		{"0,5m..", LengthUnits, Range{0.5, 0, "m"}, ""},
+		{"..120", LengthUnits, Range{0, 120, "cm"}, ""},
+		{">=2kg", WeightUnits, Range{2, 0, "kg"}, ""},
+		{"<=500g", WeightUnits, Range{0, 500, "g"}, ""},
+		{"..5l", VolumeUnits, Range{0, 5, "l"}, ""},
+		{"40..<60", LengthUnits, Range{40, math.Nextafter(60, math.Inf(-1)), "cm"}, ""},
+		{"40cm-60mm", LengthUnits, Range{}, "unit"},
+		{"40kg", LengthUnits, Range{}, "unit"},
+		{"40ft", LengthUnits, Range{}, "unit"},
+		{"cm", LengthUnits, Range{}, "number"},
+		{"abc", LengthUnits, Range{}, "number"},
+		{">", LengthUnits, Range{}, "number"},
+	}
+	for _, tt := range tests {
+		got, err := ParseRange(tt.s, tt.units)
+		var qerr *QueryError
+		switch {
+		case tt.err != "" && (!errors.As(err, &qerr) || qerr.Reason != tt.err):
+			t.Errorf("ParseRange(%q) error %v, want %s", tt.s, err, tt.err)
+		case tt.err == "" && (err != nil || got != tt.want):
+			t.Errorf("ParseRange(%q) = %v, %v, want %v", tt.s, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseQueryUnits(t *testing.T) {
+	r := testRepository(t)
+	for term, want := range map[string][]int{
+		"width:300-450mm": {1, 3},
+		"bredd:..0,3m":    {3},
+		"width:>40":       {1, 2},
+		"vikt:3-7":        {1, 3},
+		"weight:<4000g":   {3},
+	} {
+		s, f, err := ParseQuery(Search{Term: term, Sort: byItemID}, Filter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := r.ItemIDs(s, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: got %v, want %v", term, got, want)
+		}
+	}
+}
+
+func TestSortItemIDs(t *testing.T) {
+	r := testRepository(t)
+	for spec, want := range map[string][]int{
+		"":                           {1, 2, 3},
+		"Category, Price DESC":       {2, 1, 3},
+		"Category, Price":            {2, 3, 1},
+		"Width DESC":                 {2, 1, 3},
+		"Weight":                     {3, 1, 2},
+		"Name":                       {2, 3, 1},
+		"Manufacturer, Name DESC":    {1, 3, 2},
+		"Stock DESC":                 {3, 2, 1},
+		"Price desc, ItemID desc":    {2, 1, 3},
+		"Relevance DESC":             {3, 2, 1},
+		"DateModified DESC, Stock":   {3, 1, 2},
+		"ModelName, DateCreated ASC": {2, 3, 1},
+	} {
+		sort, err := ParseSortSpec(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := r.ItemIDs(Search{Sort: sort}, Filter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%q: got %v, want %v", spec, got, want)
+		}
+	}
+	if _, err := r.ItemIDs(Search{Sort: SortSpec{{Key: "Nope"}}}, Filter{}); !errors.Is(err, ErrInvalidSort) {
+		t.Errorf("unknown key: got %v, want %v", err, ErrInvalidSort)
+	}
+	keys, err := r.SortKeys()
+	if err != nil || !slices.Contains(keys, "Category") || !slices.Contains(keys, "Price") || slices.Contains(keys, "Nope") {
+		t.Errorf("SortKeys() = %v, %v", keys, err)
+	}
+}
+
+func TestParseSortSpec(t *testing.T) {
+	spec, err := ParseSortSpec(" Category ,Price desc,, Name ASC")
+	want := SortSpec{{"Category", SortAscending}, {"Price", SortDescending}, {"Name", SortAscending}}
+	if err != nil || !slices.Equal(spec, want) {
+		t.Errorf("got %v, %v, want %v", spec, err, want)
+	}
+	if s := spec.String(); s != "Category, Price DESC, Name" {
+		t.Errorf("String() = %q", s)
+	}
+	if spec, _ := ParseSortSpec("Descr DESC"); !slices.Equal(spec, SortSpec{{"ModelDesc", SortDescending}}) {
+		t.Errorf("old key: got %v", spec)
+	}
+	for _, s := range []string{"Price sideways", "Name; DROP TABLE Item", "Price DESC ASC", "Width+0"} {
+		if _, err := ParseSortSpec(s); !errors.Is(err, ErrInvalidSort) {
+			t.Errorf("ParseSortSpec(%q) error %v, want %v", s, err, ErrInvalidSort)
+		}
+	}
+}
+
+func TestItemIDPage(t *testing.T) {
+	r := testRepository(t)
+	s := Search{Sort: SortSpec{{Key: "Name"}}}
+	all, err := r.ItemIDs(s, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paged []int
+	for offset := 0; ; offset += 2 {
+		page, err := r.ItemIDPage(context.Background(), s, Filter{}, offset, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paged = append(paged, page...)
+		if len(page) < 2 {
+			break
+		}
+	}
+	if !slices.Equal(paged, all) || !slices.Equal(all, []int{2, 3, 1}) {
+		t.Errorf("pages %v, all %v, want [2 3 1]", paged, all)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.ItemIDPage(ctx, s, Filter{}, 0, 2); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestCompletions(t *testing.T) {
+	r := testRepository(t)
+	tests := []struct {
+		prefix string
+		limit  int
+		want   []Completion
+	}{
+		{"s", 10, []Completion{{"Stolar", "cat", 2}, {"stapelbar", "word", 2}, {"Stol", "", 1}, {"matt svart", "word", 1}}},
+		{"S", 2, []Completion{{"Stolar", "cat", 2}, {"stapelbar", "word", 2}}},
+		{"o", 10, []Completion{{"Oval", "", 2}, {"O'Brien", "", 1}}},
+		{"kont", 10, []Completion{{"Kontor", "cat", 2}}},
+		{"soff", 10, nil},
+		{"%", 10, nil},
+		{" ", 10, nil},
+	}
+	for _, tt := range tests {
+		got, err := r.Completions(context.Background(), tt.prefix, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Completions(%q) = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestFacets(t *testing.T) {
+	r := testRepository(t)
+	counts := func(buckets []BucketCount) []int {
+		var n []int
+		for _, b := range buckets {
+			n = append(n, b.Count)
+		}
+		return n
+	}
+	f, err := r.Facets(context.Background(), Search{}, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []FacetCount{{ID: 8, Count: 1}, {ID: 19, Count: 2}}; !slices.Equal(f.Categories, want) {
+		t.Errorf("Categories %v, want %v", f.Categories, want)
+	}
+	if want := []FacetCount{{Name: "Kinnarps", Count: 2}, {Name: "O'Brien", Count: 1}}; !slices.Equal(f.Manufacturers, want) {
+		t.Errorf("Manufacturers %v, want %v", f.Manufacturers, want)
+	}
+	if want := []FacetCount{{ID: ItemStatusAvailable, Count: 2}, {ID: ItemStatusSold, Count: 1}}; !slices.Equal(f.Statuses, want) {
+		t.Errorf("Statuses %v, want %v", f.Statuses, want)
+	}
+	if got, want := counts(f.Buckets["Width"]), []int{0, 2, 0, 1, 0}; !slices.Equal(got, want) {
+		t.Errorf("Width buckets %v, want %v", got, want)
+	}
+	if got, want := counts(f.Buckets["Weight"]), []int{0, 1, 1, 1, 0}; !slices.Equal(got, want) {
+		t.Errorf("Weight buckets %v, want %v", got, want)
+	}
+	if got, want := counts(f.Buckets["Depth"]), []int{0, 1, 2, 0, 0}; !slices.Equal(got, want) {
+		t.Errorf("Depth buckets %v, want %v", got, want)
+	}
+	if got := f.Buckets["Width"][1].Range.String(); got != "25..<50cm" {
+		t.Errorf("Width bucket %v", got)
+	}
+
+	/* Each facet leaves out its own restriction */
+	f, err = r.Facets(context.Background(), Search{}, Filter{Category: "Kontor", Manufacturer: "Kinnarps", Width: Range{Min: 40, Unit: "cm"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []FacetCount{{ID: 19, Count: 1}}; !slices.Equal(f.Categories, want) {
+		t.Errorf("Categories %v, want %v", f.Categories, want)
+	}
+	if want := []FacetCount{{Name: "Kinnarps", Count: 1}}; !slices.Equal(f.Manufacturers, want) {
+		t.Errorf("Manufacturers %v, want %v", f.Manufacturers, want)
+	}
+	if got, want := counts(f.Buckets["Width"]), []int{0, 2, 0, 0, 0}; !slices.Equal(got, want) {
+		t.Errorf("Width buckets %v, want %v", got, want)
+	}
+	s := Search{Term: "pall", Scope: map[string]bool{"Name": true}}
+	if f, err = r.Facets(context.Background(), s, Filter{}); err != nil || !slices.Equal(f.Statuses, []FacetCount{{ID: ItemStatusAvailable, Count: 1}}) {
+		t.Errorf("search: Statuses %v, %v", f.Statuses, err)
+	}
+}
+
+func TestRangeString(t *testing.T) {
+	for _, c := range []struct {
+		r     Range
+		units []string
+	}{
+		{Range{25, 50, "cm"}, LengthUnits},
+		{Range{0, 25, "cm"}, LengthUnits},
+		{Range{200, 0, "mm"}, LengthUnits},
+		{Range{0.5, 1.5, "l"}, VolumeUnits},
+		{Range{25, math.Nextafter(50, math.Inf(-1)), "cm"}, LengthUnits},
+		{Range{0, math.Nextafter(25, math.Inf(-1)), "cm"}, LengthUnits},
+	} {
+		if got, err := ParseRange(c.r.String(), c.units); err != nil || got != c.r {
+			t.Errorf("ParseRange(%q) = %v, %v, want %v", c.r.String(), got, err, c.r)
+		}
+	}
+}
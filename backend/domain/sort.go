@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+var ErrInvalidSort = errors.New("invalid sort")
+
+/* A sort key that is not a column of Item or one of DerivedSortKeys, or is followed by something else than ASC or DESC */
+type SortError struct {
+	Key string
+}
+
+func (e *SortError) Error() string {
+	return fmt.Sprintf("%s: %q", ErrInvalidSort, e.Key)
+}
+
+func (e *SortError) Is(target error) bool {
+	return target == ErrInvalidSort
+}
+
+/* One key of a SortSpec, a column of Item or one of DerivedSortKeys */
+type SortKey struct {
+	Key   string
+	Order SortOrder
+}
+
+/* The keys to sort by, ties on one key are broken by the next and finally by ItemID */
+type SortSpec []SortKey
+
+/* Sorts best full-text match first */
+var DefaultSort = SortSpec{{Key: "Relevance"}}
+
+/* Sort keys that are not Item columns. Relevance is the full-text rank, or ItemID when the full-text index is not used. */
+var DerivedSortKeys = []string{"Relevance", "Category"}
+
+/* The category path of an item, from its top category down, as in Hushåll / Bord */
+const categoryPath = `(WITH RECURSIVE Path(ParentID, Name, Depth) AS (
+SELECT ParentID, Name, 0 FROM Category WHERE CatID = Item.CatID
+UNION ALL SELECT Category.ParentID, Category.Name || ' / ' || Path.Name, Depth + 1
+FROM Category JOIN Path ON Category.CatID = Path.ParentID WHERE Depth < 16)
+SELECT Name FROM Path ORDER BY Depth DESC LIMIT 1) COLLATE NOCASE`
+
+/* What the keys that are not sorted by their column as it is sort by. Measurements are converted to one unit so 500 mm sorts with 50 cm. */
+var sortExpressions = map[string]string{
+	"Category": categoryPath,
+	"Width":    "convertunit(Width, LengthUnitID, 'mm')",
+	"Height":   "convertunit(Height, LengthUnitID, 'mm')",
+	"Depth":    "convertunit(Depth, LengthUnitID, 'mm')",
+	"Volume":   "convertunit(Volume, VolumeUnitID, 'ml')",
+	"Weight":   "convertunit(Weight, WeightUnitID, 'g')",
+}
+
+/* The keys of the single key sort that came before, as saved searches may still hold them */
+var sortAliases = map[string]string{
+	"Descr": "ModelDesc",
+	"Model": "ModelName",
+}
+
+/* Returns the spec as ParseSortSpec reads it, as in Category, Price DESC */
+func (s SortSpec) String() string {
+	var keys []string
+	for _, k := range s {
+		if k.Order == SortDescending {
+			keys = append(keys, k.Key+" "+k.Order.String())
+		} else {
+			keys = append(keys, k.Key)
+		}
+	}
+	return strings.Join(keys, ", ")
+}
+
+/* Returns the spec written as comma separated keys, each followed by ASC or DESC or by nothing for ascending */
+func ParseSortSpec(s string) (SortSpec, error) {
+	var spec SortSpec
+	for _, part := range strings.Split(s, ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		k := SortKey{Key: fields[0]}
+		if key, ok := sortAliases[k.Key]; ok {
+			k.Key = key
+		}
+		if strings.ContainsFunc(k.Key, func(r rune) bool { return r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+			return spec, &SortError{Key: fields[0]}
+		}
+		switch {
+		case len(fields) == 1:
+		case len(fields) == 2 && strings.EqualFold(fields[1], SortAscending.String()):
+		case len(fields) == 2 && strings.EqualFold(fields[1], SortDescending.String()):
+			k.Order = SortDescending
+		default:
+			return spec, &SortError{Key: strings.TrimSpace(part)}
+		}
+		spec = append(spec, k)
+	}
+	return spec, nil
+}
+
+/* Returns the ORDER BY clause for s, rank is what Relevance sorts by. Keys must be DerivedSortKeys or in columns, which maps the Item columns to whether they hold text. */
+func (s SortSpec) orderBy(columns map[string]bool, rank string) (string, error) {
+	var terms []string
+	if len(s) == 0 {
+		s = DefaultSort
+	}
+	for _, k := range s {
+		expr, derived := sortExpressions[k.Key]
+		text, column := columns[k.Key]
+		switch {
+		case k.Key == "Relevance":
+			expr = rank
+		case derived:
+		case column && text:
+			expr = k.Key + " COLLATE NOCASE"
+		case column:
+			expr = k.Key
+		default:
+			return "", &SortError{Key: k.Key}
+		}
+		terms = append(terms, expr+" "+k.Order.String())
+	}
+	if !slices.ContainsFunc(s, func(k SortKey) bool { return k.Key == "ItemID" }) {
+		terms = append(terms, "ItemID ASC")
+	}
+	return "ORDER BY " + strings.Join(terms, ", "), nil
+}
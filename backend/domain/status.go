@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+var ErrInvalidTransition = errors.New("status change not allowed")
+
+/*
+The statuses an item can be given from each status. A sold item is archived rather than deleted, so that it stays on
+record, and a deleted item can be given back any status, as when it is restored from the trash.
+*/
+var statusTransitions = map[int][]int{
+	ItemStatusAvailable: {ItemStatusReserved, ItemStatusSold, ItemStatusArchived, ItemStatusDeleted},
+	ItemStatusReserved:  {ItemStatusAvailable, ItemStatusSold, ItemStatusDeleted},
+	ItemStatusSold:      {ItemStatusAvailable, ItemStatusArchived},
+	ItemStatusArchived:  {ItemStatusAvailable, ItemStatusDeleted},
+	ItemStatusDeleted:   {ItemStatusAvailable, ItemStatusReserved, ItemStatusSold, ItemStatusArchived},
+}
+
+/* Returns the statuses an item with status from can be given */
+func NextStatuses(from int) []int {
+	return slices.Clone(statusTransitions[from])
+}
+
+/* Reports whether an item with status from can be given status to */
+func CanChangeStatus(from, to int) bool {
+	return slices.Contains(statusTransitions[from], to)
+}
+
+/*
+A change of the status of an item, with why it was made. A reservation can say who the item is reserved for and when
+the reservation runs out, a sale what the item was sold for and when.
+*/
+type StatusChange struct {
+	ItemID  int
+	From    int
+	To      int
+	Time    time.Time
+	Reason  string
+	Contact string
+	Expires time.Time // Zero if the reservation does not run out
+	Price   float64
+	Sold    time.Time
+}
+
+func (r *sqlRepository) SetStatus(s StatusChange) (*Change, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("Repository.SetStatus(%d, %d) error: %w", s.ItemID, s.To, err)
+	}
+	defer tx.Rollback()
+	c, err := setStatus(tx, s)
+	if err != nil {
+		return nil, fmt.Errorf("Repository.SetStatus(%d, %d) error: %w", s.ItemID, s.To, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("Repository.SetStatus(%d, %d) error: %w", s.ItemID, s.To, err)
+	}
+	return c, nil
+}
+
+/* Gives item s.ItemID status s.To and records s, or returns ErrInvalidTransition if its status cannot go there */
+func setStatus(tx *sql.Tx, s StatusChange) (*Change, error) {
+	var from int
+	if err := tx.QueryRow(`SELECT ItemStatusID FROM Item WHERE ItemID = ?`, s.ItemID).Scan(&from); err != nil {
+		return nil, err
+	}
+	if from == s.To {
+		return nil, nil
+	}
+	if !CanChangeStatus(from, s.To) {
+		return nil, ErrInvalidTransition
+	}
+	c, err := updateRow(tx, "Item", s.ItemID, "ItemStatusID", s.To)
+	if err != nil {
+		return nil, err
+	}
+	if s.Time.IsZero() {
+		s.Time = time.Now()
+	}
+	_, err = tx.Exec(`INSERT INTO Item_StatusChange (ItemID, FromStatusID, ToStatusID, DateChanged, Reason, Contact, DateExpires, SalePrice, DateSold)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.ItemID, from, s.To, s.Time.UTC().Format(subsec), s.Reason, s.Contact, nullTime(s.Expires), s.Price, nullTime(s.Sold))
+	return c, err
+}
+
+func (r *sqlRepository) StatusChanges(id int) ([]StatusChange, error) {
+	changes, err := r.statusChanges(`WHERE ItemID = ? ORDER BY ChangeID DESC`, id)
+	if err != nil {
+		return changes, fmt.Errorf("Repository.StatusChanges(%d) error: %w", id, err)
+	}
+	return changes, nil
+}
+
+func (r *sqlRepository) Reservation(id int) (*StatusChange, error) {
+	c, err := r.lastStatusChange(id, ItemStatusReserved)
+	if err != nil {
+		return nil, fmt.Errorf("Repository.Reservation(%d) error: %w", id, err)
+	}
+	return c, nil
+}
+
+func (r *sqlRepository) SoldStatus(id int) (*StatusChange, error) {
+	c, err := r.lastStatusChange(id, ItemStatusSold)
+	if err != nil {
+		return nil, fmt.Errorf("Repository.SoldStatus(%d) error: %w", id, err)
+	}
+	return c, nil
+}
+
+func (r *sqlRepository) ExpiredReservations(now time.Time) ([]int, error) {
+	var ids []int
+	rows, err := r.db.Query(`SELECT i.ItemID FROM Item i JOIN Item_StatusChange c ON c.ChangeID = (
+    SELECT max(ChangeID) FROM Item_StatusChange WHERE ItemID = i.ItemID AND ToStatusID = @1 AND FromStatusID <> @2)
+WHERE i.ItemStatusID = @1 AND c.DateExpires < @3
+ORDER BY i.ItemID`, ItemStatusReserved, ItemStatusDeleted, now.UTC().Format(subsec))
+	if err != nil {
+		return ids, fmt.Errorf("Repository.ExpiredReservations() error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return ids, fmt.Errorf("Repository.ExpiredReservations() error: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return ids, fmt.Errorf("Repository.ExpiredReservations() error: %w", err)
+	}
+	return ids, nil
+}
+
+/*
+Returns the change that gave item id its status, if that is status, or nil if it has another one. A restore from the
+trash gives back the reservation or sale the item had, so the change before it is returned.
+*/
+func (r *sqlRepository) lastStatusChange(id int, status int) (*StatusChange, error) {
+	var current int
+	err := r.db.QueryRow(`SELECT ItemStatusID FROM Item WHERE ItemID = ?`, id).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && current != status) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	changes, err := r.statusChanges(`WHERE ItemID = ? AND ToStatusID = ? AND FromStatusID <> ? ORDER BY ChangeID DESC LIMIT 1`, id, status, ItemStatusDeleted)
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+	return &changes[0], nil
+}
+
+func (r *sqlRepository) statusChanges(where string, args ...any) ([]StatusChange, error) {
+	var changes []StatusChange
+	rows, err := r.db.Query(`SELECT ItemID, FromStatusID, ToStatusID, DateChanged, Reason, Contact, DateExpires, SalePrice, DateSold
+FROM Item_StatusChange `+where, args...)
+	if err != nil {
+		return changes, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c StatusChange
+		var from sql.NullInt64
+		var price sql.NullFloat64
+		var changed, reason, contact, expires, sold sql.NullString
+		if err := rows.Scan(&c.ItemID, &from, &c.To, &changed, &reason, &contact, &expires, &price, &sold); err != nil {
+			return changes, err
+		}
+		c.From, c.Reason, c.Contact, c.Price = int(from.Int64), reason.String, contact.String, price.Float64
+		c.Time, _ = time.Parse(subsec, changed.String)
+		c.Expires, _ = time.Parse(subsec, expires.String)
+		c.Sold, _ = time.Parse(subsec, sold.String)
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+/* Returns t as stored in a date column, or NULL if it is zero */
+func nullTime(t time.Time) sql.NullString {
+	if t.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.UTC().Format(subsec), Valid: true}
+}
@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestStatusWorkflow(t *testing.T) {
+	r := testRepository(t)
+	set := func(s StatusChange) *Change {
+		t.Helper()
+		c, err := r.SetStatus(s)
+		if err != nil {
+			t.Fatalf("SetStatus(%d, %d) error: %s", s.ItemID, s.To, err)
+		}
+		return c
+	}
+
+	for _, tc := range []struct{ from, to int }{
+		{ItemStatusSold, ItemStatusDeleted},
+		{ItemStatusReserved, ItemStatusArchived},
+		{ItemStatusArchived, ItemStatusSold},
+	} {
+		if CanChangeStatus(tc.from, tc.to) {
+			t.Errorf("CanChangeStatus(%d, %d) = true", tc.from, tc.to)
+		}
+	}
+	for from, next := range statusTransitions {
+		if slices.Contains(next, from) {
+			t.Errorf("status %d goes to itself", from)
+		}
+	}
+
+	/* Item 2 is sold, it is archived rather than deleted */
+	if _, err := r.SetStatus(StatusChange{ItemID: 2, To: ItemStatusDeleted}); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("deleting a sold item error = %v, want %v", err, ErrInvalidTransition)
+	}
+	if c := set(StatusChange{ItemID: 2, To: ItemStatusSold}); c != nil {
+		t.Errorf("SetStatus to the same status = %v", c)
+	}
+
+	expires := date("2025-05-01")
+	c := set(StatusChange{ItemID: 1, To: ItemStatusReserved, Reason: "Ringde", Contact: "Anna", Expires: expires})
+	if want := []FieldChange{{"ItemStatusID", int64(ItemStatusAvailable), int64(ItemStatusReserved)}}; c == nil || !slices.Equal(c.Fields, want) {
+		t.Fatalf("SetStatus = %v, want %v", c, want)
+	}
+	if res, err := r.Reservation(1); err != nil || res == nil || res.Contact != "Anna" || !res.Expires.Equal(expires) || res.Reason != "Ringde" {
+		t.Errorf("Reservation(1) = %+v, %v", res, err)
+	}
+	if res, _ := r.Reservation(3); res != nil {
+		t.Errorf("Reservation of an available item = %+v", res)
+	}
+	if ids, err := r.ExpiredReservations(date("2025-06-01")); err != nil || !slices.Equal(ids, []int{1}) {
+		t.Errorf("ExpiredReservations = %v, %v, want [1]", ids, err)
+	}
+	if ids, _ := r.ExpiredReservations(date("2025-04-01")); len(ids) > 0 {
+		t.Errorf("ExpiredReservations before the expiry = %v", ids)
+	}
+
+	/* Undoing the sale gives back the reservation it ended */
+	sold := date("2025-04-15")
+	c = set(StatusChange{ItemID: 1, To: ItemStatusSold, Price: 450, Sold: sold})
+	if sale, err := r.SoldStatus(1); err != nil || sale == nil || sale.Price != 450 || !sale.Sold.Equal(sold) {
+		t.Errorf("SoldStatus(1) = %+v, %v", sale, err)
+	}
+	if res, _ := r.Reservation(1); res != nil {
+		t.Errorf("Reservation of a sold item = %+v", res)
+	}
+	if err := r.Revert(c); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := r.Reservation(1); res == nil || res.Contact != "Anna" {
+		t.Errorf("Reservation after undoing the sale = %+v", res)
+	}
+	if sale, _ := r.SoldStatus(1); sale != nil {
+		t.Errorf("Sale after undoing it = %+v", sale)
+	}
+
+	/* A restore from the trash keeps the reservation */
+	set(StatusChange{ItemID: 1, To: ItemStatusDeleted})
+	set(StatusChange{ItemID: 1, To: ItemStatusReserved})
+	if res, _ := r.Reservation(1); res == nil || res.Contact != "Anna" {
+		t.Errorf("Reservation after a restore = %+v", res)
+	}
+
+	changes, err := r.StatusChanges(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][2]int
+	for _, c := range changes {
+		got = append(got, [2]int{c.From, c.To})
+	}
+	want := [][2]int{
+		{ItemStatusDeleted, ItemStatusReserved},
+		{ItemStatusReserved, ItemStatusDeleted},
+		{ItemStatusReserved, ItemStatusSold},
+		{ItemStatusAvailable, ItemStatusReserved},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("StatusChanges(1) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,594 @@
+package domain
+
+import (
+	"UppSpar/backend/schema"
+	"strings"
+)
+
+/* Schema migrations for the main database, in order. Never edit a step once released, append a new one. */
+var Migrations = []schema.Step{
+	{Version: 1, Name: "baseline", Up: schema.Exec(append(baselineTables, baselineSeeds...)...)},
+	{Version: 2, Name: "item parents and search words", Up: schema.Exec(itemRelationTables...)},
+	{Version: 3, Name: "export profiles", Up: schema.Exec(exportProfileTables...)},
+	{Version: 4, Name: "saved searches", Up: schema.Exec(savedSearchTables...)},
+	{Version: 5, Name: "saved search status", Up: schema.Exec(savedSearchStatus...)},
+	{Version: 6, Name: "trash", Up: schema.Exec(trashTables...)},
+	{Version: 7, Name: "item status workflow", Up: schema.Exec(statusTables...)},
+	{Version: 8, Name: "sales", Up: schema.Exec(saleTables...)},
+}
+
+/* Default rows that the program depends on, checked and restored by the schema verification */
+var Seeds = []schema.Seed{
+	{
+		Table:   "Metric",
+		Columns: []string{"UnitID", "Text"},
+		Rows:    `(1, 'mm'), (2, 'cm'), (3, 'dm'), (4, 'm'), (5, 'g'), (6, 'hg'), (7, 'kg'), (8, 'ml'), (9, 'cl'), (10, 'dl'), (11, 'l')`,
+	},
+	{
+		Table:   "ItemStatus",
+		Columns: []string{"ItemStatusID", "Name"},
+		Rows:    `(1, 'available'), (2, 'sold'), (3, 'reserved'), (4, 'archived'), (5, 'deleted')`,
+	},
+	{
+		Table:   "Category",
+		Columns: []string{"CatID", "Name", "ParentID"},
+		Rows: `(1, 'Administration', 0), (2, 'Hushåll', 0), (3, 'Kontor', 0), (4, 'Tjänster', 0), (5, 'Övrigt', 0), 
+(6, 'Badrum', 2), (7, 'Belysning', 5), (8, 'Bord', 2), (9, 'Dekor', 5), (10, 'Elektronik', 3), (11, 'Förvaring', 3), 
+(12, 'Husgeråd', 2), (13, 'Hylla', 3), (14, 'Kök & vitvaror', 2), (15, 'Textilier & mattor', 5), (16, 'Skrivbord', 3), 
+(17, 'Skåp', 3), (18, 'Soffor & fåtöljer', 2), (19, 'Stolar', 3), (20, 'Tvätt & städ', 5), (21, 'Sängar & madrasser', 2)`,
+		// categories are user data, only restore the defaults if all of them are gone
+		IfEmpty: true,
+	},
+}
+
+/* Tables as created before versioned migrations, so that existing databases are adopted as version 1 */
+var baselineTables = []string{
+	`CREATE TABLE IF NOT EXISTS Config(
+ConfigKey TEXT PRIMARY KEY,
+ConfigVal TEXT)`,
+	`CREATE TABLE IF NOT EXISTS Item(
+-- Proceedo defined column names --
+ItemID                  INTEGER PRIMARY KEY AUTOINCREMENT, 
+Name                    TEXT DEFAULT 'Nytt föremål', 
+Price                   REAL DEFAULT 0, 
+Currency                TEXT DEFAULT 'SEK', 
+QuantityInPrice         REAL DEFAULT 1, 
+Unit                    TEXT DEFAULT 'st', 
+OrderMultiple           REAL DEFAULT 0, 
+MinOrder                REAL DEFAULT 0, 
+Vat                     REAL DEFAULT 0, 
+Eta                     INT DEFAULT 0, 
+EtaText                 TEXT DEFAULT '', 
+Priority                BOOL DEFAULT true, 
+Stock                   REAL DEFAULT 1, 
+ImgURL1                 TEXT DEFAULT '', 
+ImgURL2                 TEXT DEFAULT '', 
+ImgURL3                 TEXT DEFAULT '', 
+ImgURL4                 TEXT DEFAULT '', 
+ImgURL5                 TEXT DEFAULT '', 
+SpecsURL                TEXT DEFAULT '', 
+UNSPSC                  TEXT DEFAULT '', 
+LongDesc                TEXT DEFAULT '', 
+Manufacturer            TEXT DEFAULT '', 
+MfrItemId               TEXT DEFAULT '', 
+GlobId                  TEXT DEFAULT '', 
+GlobIdType              TEXT DEFAULT '', 
+ReplacesItem            INT DEFAULT 0, 
+Questions               TEXT DEFAULT '', 
+PackagingCode           BOOL DEFAULT false, 
+PresentationCode        BOOL DEFAULT false, 
+DeliveryAutoSign        BOOL DEFAULT false, 
+DeliveryOption          BOOL DEFAULT false, 
+ComparePrice            REAL DEFAULT 0, 
+CompareUnit             TEXT DEFAULT '', 
+CompareQuantityInPrice  REAL DEFAULT 0, 
+PriceInfo               TEXT DEFAULT '', 
+AddDesc                 TEXT DEFAULT '', 
+ProcFlow                TEXT DEFAULT '', 
+InnerUnit               TEXT DEFAULT '', 
+QuantityInUnit          REAL DEFAULT 0, 
+RiskClassification      TEXT DEFAULT '', 
+Comment                 TEXT DEFAULT '', 
+EnvClassification       TEXT DEFAULT '', 
+FormId                  TEXT DEFAULT '', 
+Article                 TEXT DEFAULT '', 
+Attachments             BOOL DEFAULT false, 
+ItemGroup               TEXT DEFAULT '', 
+-- Custom defined fields --
+MfrID                   INT DEFAULT 0, 
+ModelID                 INT DEFAULT 0, 
+ModelName               TEXT DEFAULT '',
+ModelDesc               TEXT DEFAULT '', 
+ModelURL                TEXT DEFAULT '',
+Notes                   TEXT DEFAULT '', 
+Width                   REAL DEFAULT 0, 
+Height                  REAL DEFAULT 0, 
+Depth                   REAL DEFAULT 0, 
+Volume                  REAL DEFAULT 0, 
+Weight                  REAL DEFAULT 0, 
+LengthUnitID            INT DEFAULT 2, 
+VolumeUnitID            INT DEFAULT 11, 
+WeightUnitID            INT DEFAULT 7, 
+CatID                   INT DEFAULT 2, 
+GroupID                 INT DEFAULT 0, 
+StorageID               INT DEFAULT 0, 
+ItemStatusID            INT DEFAULT 1, 
+ItemConditionID         INT DEFAULT 0, 
+DateCreated             TEXT DEFAULT(datetime('now', 'subsec')), 
+DateModified            TEXT DEFAULT(datetime('now', 'subsec')), 
+FOREIGN KEY(MfrID) REFERENCES Manufacturer(MfrID), 
+FOREIGN KEY(ModelID) REFERENCES Model(ModelID), 
+FOREIGN KEY(LengthUnitID) REFERENCES Metric(UnitID), 
+FOREIGN KEY(VolumeUnitID) REFERENCES Metric(UnitID), 
+FOREIGN KEY(WeightUnitID) REFERENCES Metric(UnitID), 
+FOREIGN KEY(CatID) REFERENCES Category(CatID), 
+FOREIGN KEY(GroupID) REFERENCES Item_Group(GroupID), 
+FOREIGN KEY(ItemStatusID) REFERENCES ItemStatus(ItemStatusID),  
+FOREIGN KEY(StorageID) REFERENCES Storage(StorageID))`,
+	`CREATE TRIGGER IF NOT EXISTS UpdateDateModified
+AFTER UPDATE ON Item FOR EACH ROW
+BEGIN
+    UPDATE Item SET DateModified = datetime('now', 'subsec') WHERE ItemID = old.ItemID;
+END`,
+	`CREATE TRIGGER IF NOT EXISTS UpdateItemMfrID AFTER UPDATE OF MfrID ON Item
+FOR EACH ROW WHEN new.MfrID <> old.MfrID
+BEGIN
+    UPDATE Item SET
+    ModelID = 0
+    WHERE ItemID = old.ItemID;
+END`,
+	// TODO consider whether this trigger should overwrite all fields or not
+	`CREATE TRIGGER IF NOT EXISTS UpdateModelData AFTER UPDATE OF ModelID ON Item
+FOR EACH ROW WHEN new.ModelID <> old.ModelID AND new.ModelID <> 0
+BEGIN
+    UPDATE Item SET
+    CatID =  (SELECT CatID FROM Model WHERE ModelID = new.ModelID),
+    ModelName = (SELECT Name FROM Model WHERE ModelID = new.ModelID),
+    ModelDesc = (SELECT Desc FROM Model WHERE ModelID = new.ModelID),
+    ModelURL = (SELECT ModelURL FROM Model WHERE ModelID = new.ModelID),
+    ImgURL1 = (CASE WHEN old.ImgURL1 = '' THEN (SELECT ImgURL1 FROM Model WHERE ModelID = new.ModelID) ELSE old.ImgURL1 END),
+    ImgURL2 = (CASE WHEN old.ImgURL2 = '' THEN (SELECT ImgURL2 FROM Model WHERE ModelID = new.ModelID) ELSE old.ImgURL2 END),
+    ImgURL3 = (CASE WHEN old.ImgURL3 = '' THEN (SELECT ImgURL3 FROM Model WHERE ModelID = new.ModelID) ELSE old.ImgURL3 END),
+    ImgURL4 = (CASE WHEN old.ImgURL4 = '' THEN (SELECT ImgURL4 FROM Model WHERE ModelID = new.ModelID) ELSE old.ImgURL4 END),
+    ImgURL5 = (CASE WHEN old.ImgURL5 = '' THEN (SELECT ImgURL5 FROM Model WHERE ModelID = new.ModelID) ELSE old.ImgURL5 END),
+    SpecsURL = (CASE WHEN old.SpecsURL = '' THEN (SELECT SpecsURL FROM Model WHERE ModelID = new.ModelID) ELSE old.SpecsURL END),
+    ModelURL = (CASE WHEN old.ModelURL = '' THEN (SELECT ModelURL FROM Model WHERE ModelID = new.ModelID) ELSE old.ModelURL END),
+    Width = (SELECT Width FROM Model WHERE ModelID = new.ModelID),
+    Height = (SELECT Height FROM Model WHERE ModelID = new.ModelID),
+    Depth = (SELECT Depth FROM Model WHERE ModelID = new.ModelID),
+    Volume = (SELECT Volume FROM Model WHERE ModelID = new.ModelID),
+    Weight = (SELECT Weight FROM Model WHERE ModelID = new.ModelID),
+    LengthUnitID = (SELECT LengthUnitID FROM Model WHERE ModelID = new.ModelID),
+    VolumeUnitID = (SELECT VolumeUnitID FROM Model WHERE ModelID = new.ModelID),
+    WeightUnitID = (SELECT WeightUnitID FROM Model WHERE ModelID = new.ModelID)
+    WHERE ItemID = old.ItemID;
+END`,
+	`CREATE TABLE IF NOT EXISTS Temp_Item(
+-- Proceedo defined column names --
+ItemID                  INT, 
+Name                    TEXT, 
+Price                   REAL, 
+Currency                TEXT, 
+QuantityInPrice         REAL, 
+Unit                    TEXT, 
+OrderMultiple           REAL, 
+MinOrder                REAL, 
+Vat                     REAL, 
+Eta                     INT, 
+EtaText                 TEXT, 
+Priority                BOOL, 
+Stock                   REAL, 
+ImgURL1                 TEXT, 
+ImgURL2                 TEXT, 
+ImgURL3                 TEXT, 
+ImgURL4                 TEXT, 
+ImgURL5                 TEXT, 
+SpecsURL                TEXT, 
+UNSPSC                  TEXT, 
+LongDesc                TEXT, 
+Manufacturer            TEXT, 
+MfrItemId               TEXT, 
+GlobId                  TEXT, 
+GlobIdType              TEXT, 
+ReplacesItem            INT, 
+Questions               TEXT, 
+PackagingCode           BOOL, 
+PresentationCode        BOOL, 
+DeliveryAutoSign        BOOL, 
+DeliveryOption          BOOL, 
+ComparePrice            REAL, 
+CompareUnit             TEXT, 
+CompareQuantityInPrice  REAL, 
+PriceInfo               TEXT, 
+AddDesc                 TEXT, 
+ProcFlow                TEXT, 
+InnerUnit               TEXT, 
+QuantityInUnit          REAL, 
+RiskClassification      TEXT, 
+Comment                 TEXT, 
+EnvClassification       TEXT, 
+FormId                  TEXT, 
+Article                 TEXT, 
+Attachments             BOOL, 
+ItemGroup               TEXT, 
+-- Custom defined fields --
+MfrID                   INT, 
+ModelID                 INT, 
+ModelName               TEXT,
+ModelDesc               TEXT, 
+ModelURL                TEXT,
+Notes                   TEXT, 
+Width                   REAL, 
+Height                  REAL, 
+Depth                   REAL, 
+Volume                  REAL, 
+Weight                  REAL, 
+LengthUnitID            INT, 
+VolumeUnitID            INT, 
+WeightUnitID            INT, 
+CatID                   INT, 
+GroupID                 INT, 
+StorageID               INT, 
+ItemStatusID            INT, 
+ItemConditionID         INT, 
+DateCreated             TEXT, 
+DateModified            TEXT, 
+FOREIGN KEY(MfrID) REFERENCES Manufacturer(MfrID), 
+FOREIGN KEY(ModelID) REFERENCES Model(ModelID), 
+FOREIGN KEY(LengthUnitID) REFERENCES Metric(UnitID), 
+FOREIGN KEY(VolumeUnitID) REFERENCES Metric(UnitID), 
+FOREIGN KEY(WeightUnitID) REFERENCES Metric(UnitID), 
+FOREIGN KEY(CatID) REFERENCES Category(CatID), 
+FOREIGN KEY(GroupID) REFERENCES Item_Group(GroupID), 
+FOREIGN KEY(ItemStatusID) REFERENCES ItemStatus(ItemStatusID),  
+FOREIGN KEY(StorageID) REFERENCES Storage(StorageID))`,
+	`CREATE TRIGGER IF NOT EXISTS Temp_UpdateDateModified
+AFTER UPDATE ON Temp_Item FOR EACH ROW
+BEGIN
+UPDATE Temp_Item SET DateModified = datetime('now', 'subsec') WHERE ItemID = old.ItemID;
+END`,
+	`CREATE TABLE IF NOT EXISTS Item_Condition(
+ItemID INT, 
+Rate INT, 
+Comment TEXT, 
+FOREIGN KEY(ItemID) REFERENCES Item(ItemID) ON DELETE CASCADE)`,
+	`CREATE TABLE IF NOT EXISTS Item_Group(
+GroupID INTEGER PRIMARY KEY AUTOINCREMENT,
+ParentID INT DEFAULT 0,
+Name TEXT DEFAULT '',
+Deleted BOOL DEFAULT false)`,
+	`CREATE TABLE IF NOT EXISTS Item_Function(
+ItemID INT, 
+FuncID INT, 
+IsTested BOOL, 
+IsWorking BOOL, 
+Comment TEXT, 
+FOREIGN KEY(ItemID) REFERENCES Item(ItemID) ON DELETE CASCADE, 
+FOREIGN KEY(FuncID) REFERENCES Function_Data(FuncID))`,
+	`CREATE TABLE IF NOT EXISTS Function_Data(
+FuncID INTEGER PRIMARY KEY AUTOINCREMENT, 
+Name TEXT)`,
+	`CREATE TABLE IF NOT EXISTS ItemStatus(
+ItemStatusID INTEGER PRIMARY KEY AUTOINCREMENT, 
+Name TEXT)`,
+	`CREATE TABLE IF NOT EXISTS Manufacturer(
+MfrID INTEGER PRIMARY KEY AUTOINCREMENT, 
+Name TEXT DEFAULT 'Ny tillverkare',
+Deleted BOOL DEFAULT false)`,
+	`CREATE TABLE IF NOT EXISTS Model(
+ModelID      INTEGER PRIMARY KEY AUTOINCREMENT, 
+Name         TEXT DEFAULT 'Ny modell', 
+Manufacturer TEXT DEFAULT '',
+MfrID        INT DEFAULT 0, 
+Desc         TEXT DEFAULT '', 
+ImgURL1      TEXT DEFAULT '', 
+ImgURL2      TEXT DEFAULT '', 
+ImgURL3      TEXT DEFAULT '', 
+ImgURL4      TEXT DEFAULT '', 
+ImgURL5      TEXT DEFAULT '', 
+SpecsURL     TEXT DEFAULT '', 
+ModelURL     TEXT DEFAULT '', 
+Width        REAL DEFAULT 0, 
+Height       REAL DEFAULT 0, 
+Depth        REAL DEFAULT 0, 
+Volume       REAL DEFAULT 0, 
+Weight       REAL DEFAULT 0, 
+LengthUnitID INT DEFAULT 2, 
+VolumeUnitID INT DEFAULT 11, 
+WeightUnitID INT DEFAULT 7, 
+CatID        INT DEFAULT 1, 
+Deleted      BOOL DEFAULT false, 
+FOREIGN KEY(MfrID) REFERENCES Manufacturer(MfrID)
+FOREIGN KEY(LengthUnitID) REFERENCES Metric(UnitID), 
+FOREIGN KEY(VolumeUnitID) REFERENCES Metric(UnitID), 
+FOREIGN KEY(WeightUnitID) REFERENCES Metric(UnitID), 
+FOREIGN KEY(CatID) REFERENCES Category(CatID))`,
+	`CREATE TABLE IF NOT EXISTS Category(
+CatID INTEGER PRIMARY KEY AUTOINCREMENT, 
+ParentID INT DEFAULT 0,
+Name TEXT DEFAULT 'Ny kategori')`,
+	`CREATE TABLE IF NOT EXISTS Category_Config(
+CatID INT, 
+ConfigKey TEXT, 
+ConfigVal BOOL, 
+FOREIGN KEY(CatID) REFERENCES Category(CatID) ON DELETE CASCADE)`,
+	`CREATE TABLE IF NOT EXISTS Category_Data(
+CatID INT, 
+DataKey TEXT, 
+DataVal TEXT, 
+FOREIGN KEY(CatID) REFERENCES Category(CatID) ON DELETE CASCADE)`,
+	`CREATE TABLE IF NOT EXISTS Image(
+ImgID INTEGER PRIMARY KEY AUTOINCREMENT, 
+ImgData BLOB, 
+ImgFileDate TEXT,
+ImgSHA1 TEXT,
+ImgSize INT,
+ImgThumb BLOB, 
+ImgURL TEXT DEFAULT '', 
+Deleted BOOL DEFAULT false)`,
+	`CREATE TABLE IF NOT EXISTS Metric(
+UnitID INTEGER PRIMARY KEY, 
+Text TEXT)`,
+	`CREATE TABLE IF NOT EXISTS SearchWords_Association(
+ItemID INT, 
+WordID INT, 
+FOREIGN KEY(ItemID) REFERENCES SearchWords_Vocabulary(WordID) ON DELETE CASCADE, 
+FOREIGN KEY(WordID) REFERENCES Item(ItemID))`,
+	`CREATE TABLE IF NOT EXISTS SearchWords_Vocabulary(
+WordID INTEGER PRIMARY KEY, 
+WordString TEXT)`,
+	`CREATE TABLE IF NOT EXISTS Storage(
+StorageID INTEGER PRIMARY KEY, 
+Place TEXT, 
+Comment TEXT)`,
+	`CREATE TABLE IF NOT EXISTS WishList(
+WishID INTEGER PRIMARY KEY, 
+ContactID TEXT, 
+WishItemID TEXT, 
+Stock INT, 
+DateCreated TEXT DEFAULT(datetime('now', 'subsec')), 
+DateModified TEXT DEFAULT(datetime('now', 'subsec')), 
+DateExpires TEXT, 
+FOREIGN KEY(ContactID) REFERENCES WishList_Contact(ContactID), 
+FOREIGN KEY(WishItemID) REFERENCES WishList_Item(WishItemID))`,
+	`CREATE TABLE IF NOT EXISTS WishList_Contact(
+ContactID INTEGER PRIMARY KEY, 
+FirstName TEXT DEFAULT 'Förnamn', 
+LastName TEXT DEFAULT 'Efternamn', 
+Email TEXT, 
+Phone TEXT, 
+Comment TEXT, 
+DateCreated TEXT DEFAULT(datetime('now', 'subsec')), 
+DateModified TEXT DEFAULT(datetime('now', 'subsec')), 
+DateExpires TEXT)`,
+	`CREATE TABLE IF NOT EXISTS WishList_Item(
+WishItemID INTEGER PRIMARY KEY AUTOINCREMENT, 
+Name TEXT DEFAULT 'Nytt föremål', 
+Comment TEXT, 
+Width REAL, 
+Height REAL, 
+Depth REAL, 
+Weight REAL, 
+LengthUnitID INT, 
+WeightUnitID INT, 
+CatID INT, 
+DateCreated TEXT DEFAULT(datetime('now', 'subsec')), 
+DateModified TEXT DEFAULT(datetime('now', 'subsec')), 
+DateExpires TEXT, 
+FOREIGN KEY(LengthUnitID) REFERENCES Metric(UnitID), 
+FOREIGN KEY(WeightUnitID) REFERENCES Metric(UnitID), 
+FOREIGN KEY(CatID) REFERENCES Category(CatID))`,
+	`CREATE TABLE IF NOT EXISTS WishList_Item_Function(
+WishItemID INT, 
+FuncID INT, 
+Comment TEXT, 
+FOREIGN KEY(WishItemID) REFERENCES WishList_Item(WishItemID) ON DELETE CASCADE,  
+FOREIGN KEY(FuncID) REFERENCES Function_Data(FuncID))`,
+}
+
+/* Default rows, only inserted into empty tables */
+var (
+	seedConfig = `INSERT OR IGNORE INTO Config (ConfigKey, ConfigVal)
+VALUES ("ItemIDWidth", "7")`
+	seedItemStatus = `INSERT INTO ItemStatus (Name)
+SELECT * FROM (VALUES ("available"), ("sold"), ("archived"), ("deleted"))
+WHERE NOT EXISTS (SELECT 1 FROM ItemStatus)`
+	seedManufacturer = `INSERT INTO Manufacturer (Name)
+SELECT * FROM (VALUES ("UppSpar"), ("IKEA"), ("Kinnarps"))
+WHERE NOT EXISTS (SELECT 1 FROM Manufacturer)`
+	seedCategory = `INSERT INTO Category (Name, ParentID)
+SELECT * FROM (VALUES ("Administration", 0), 
+                             ("Hushåll", 0), 
+                             ("Kontor", 0), 
+                             ("Tjänster", 0), 
+                             ("Övrigt", 0),
+                             ("Badrum", 2), 
+                             ("Belysning", 5), 
+                             ("Bord", 2), 
+                             ("Dekor", 5), 
+                             ("Elektronik", 3),  
+                             ("Förvaring", 3), 
+                             ("Husgeråd", 2), 
+                             ("Hylla", 3), 
+                             ("Kök & vitvaror", 2), 
+                             ("Textilier & mattor", 5), 
+                             ("Skrivbord", 3), 
+                             ("Skåp", 3), 
+                             ("Soffor & fåtöljer", 2), 
+                             ("Stolar", 3), 
+                             ("Tvätt & städ", 5), 
+                             ("Sängar & madrasser", 2))
+WHERE NOT EXISTS (SELECT 1 FROM Category)`
+	seedMetric = `INSERT INTO Metric (Text)
+SELECT * FROM (VALUES ("mm"), ("cm"), ("dm"), ("m"), ("g"), ("hg"), ("kg"), ("ml"), ("cl"), ("dl"), ("l"))
+WHERE NOT EXISTS (SELECT 1 FROM Metric)`
+)
+
+var baselineSeeds = []string{seedConfig, seedItemStatus, seedManufacturer, seedCategory, seedMetric}
+
+/* Which item an item belongs to ("Tillhör produkt"), and search words with their foreign keys the right way around */
+var itemRelationTables = []string{
+	`CREATE TABLE Item_Parent(
+ItemID INTEGER PRIMARY KEY, 
+ParentID INT NOT NULL, 
+FOREIGN KEY(ItemID) REFERENCES Item(ItemID) ON DELETE CASCADE, 
+FOREIGN KEY(ParentID) REFERENCES Item(ItemID) ON DELETE CASCADE)`,
+	`CREATE TABLE SearchWords_Association_New(
+ItemID INT, 
+WordID INT, 
+PRIMARY KEY(ItemID, WordID), 
+FOREIGN KEY(ItemID) REFERENCES Item(ItemID) ON DELETE CASCADE, 
+FOREIGN KEY(WordID) REFERENCES SearchWords_Vocabulary(WordID) ON DELETE CASCADE)`,
+	`INSERT OR IGNORE INTO SearchWords_Association_New (ItemID, WordID)
+SELECT ItemID, WordID FROM SearchWords_Association`,
+	`DROP TABLE SearchWords_Association`,
+	`ALTER TABLE SearchWords_Association_New RENAME TO SearchWords_Association`,
+}
+
+/* Named export layouts, the built-in Proceedo profile is not stored here */
+var exportProfileTables = []string{
+	`CREATE TABLE ExportProfile(
+ProfileID INTEGER PRIMARY KEY AUTOINCREMENT, 
+Name TEXT NOT NULL UNIQUE, 
+SheetName TEXT DEFAULT 'Data', 
+ItemStatusID INT DEFAULT 1)`,
+	`CREATE TABLE ExportProfile_Column(
+ProfileID INT, 
+Position INT, 
+Header TEXT DEFAULT '', 
+Source TEXT DEFAULT '', 
+Format TEXT DEFAULT '', 
+PRIMARY KEY(ProfileID, Position), 
+FOREIGN KEY(ProfileID) REFERENCES ExportProfile(ProfileID) ON DELETE CASCADE)`,
+}
+
+/* Named searches of the items list, seeded with two smart lists as examples */
+var savedSearchTables = []string{
+	`CREATE TABLE SavedSearch(
+SearchID INTEGER PRIMARY KEY AUTOINCREMENT, 
+Name TEXT NOT NULL UNIQUE, 
+Term TEXT DEFAULT '', 
+Match TEXT DEFAULT 'contains', 
+Scope TEXT DEFAULT '', 
+SortBy TEXT DEFAULT 'Relevance', 
+SortOrder TEXT DEFAULT 'ASC', 
+Category TEXT DEFAULT '', 
+Manufacturer TEXT DEFAULT '', 
+Model TEXT DEFAULT '', 
+Width TEXT DEFAULT '', 
+Height TEXT DEFAULT '', 
+Depth TEXT DEFAULT '', 
+Volume TEXT DEFAULT '', 
+Weight TEXT DEFAULT '')`,
+	`INSERT INTO SavedSearch (Name, Term, Scope) 
+VALUES ('Stolar utan bild', 'kat:Stolar bild:nej', 'Name,Manufacturer,ModelName,ModelDesc,Notes,LongDesc,AddDesc'), 
+('Tillgängliga äldre än 90 dagar', 'status:tillgänglig skapad:<-90d', 'Name,Manufacturer,ModelName,ModelDesc,Notes,LongDesc,AddDesc')`,
+}
+
+/* The status of the filter panel, kept with the other filter fields of a saved search */
+var savedSearchStatus = []string{
+	`ALTER TABLE SavedSearch ADD COLUMN Status TEXT DEFAULT ''`,
+}
+
+/* When each deleted item was deleted and the status to restore it to, kept by triggers on the status (5 is deleted) */
+var trashTables = []string{
+	`CREATE TABLE Item_Trash(
+ItemID INTEGER PRIMARY KEY, 
+DateDeleted TEXT DEFAULT(datetime('now', 'subsec')), 
+PreviousStatusID INT DEFAULT 1, 
+FOREIGN KEY(ItemID) REFERENCES Item(ItemID) ON DELETE CASCADE)`,
+	`INSERT INTO Item_Trash (ItemID, DateDeleted)
+SELECT ItemID, DateModified FROM Item WHERE ItemStatusID = 5`,
+	`CREATE TRIGGER Item_Trash_Insert AFTER UPDATE OF ItemStatusID ON Item
+FOR EACH ROW WHEN new.ItemStatusID = 5 AND old.ItemStatusID <> 5
+BEGIN
+    INSERT OR REPLACE INTO Item_Trash (ItemID, PreviousStatusID) VALUES (new.ItemID, old.ItemStatusID);
+END`,
+	`CREATE TRIGGER Item_Trash_Delete AFTER UPDATE OF ItemStatusID ON Item
+FOR EACH ROW WHEN old.ItemStatusID = 5 AND new.ItemStatusID <> 5
+BEGIN
+    DELETE FROM Item_Trash WHERE ItemID = new.ItemID;
+END`,
+	`INSERT OR IGNORE INTO Config (ConfigKey, ConfigVal)
+VALUES ('TrashDays', '0')`,
+}
+
+/*
+The status rows as the ItemStatusID constants number them, the baseline left out reserved so its IDs were one off, and
+the log of status changes with their reasons, reservations and sales
+*/
+var statusTables = []string{
+	`INSERT OR REPLACE INTO ItemStatus (ItemStatusID, Name)
+VALUES (1, 'available'), (2, 'sold'), (3, 'reserved'), (4, 'archived'), (5, 'deleted')`,
+	`CREATE TABLE Item_StatusChange(
+ChangeID INTEGER PRIMARY KEY, 
+ItemID INT NOT NULL, 
+FromStatusID INT, 
+ToStatusID INT NOT NULL, 
+DateChanged TEXT DEFAULT(datetime('now', 'subsec')), 
+Reason TEXT DEFAULT '', 
+Contact TEXT DEFAULT '', 
+DateExpires TEXT, 
+SalePrice REAL DEFAULT 0, 
+DateSold TEXT, 
+FOREIGN KEY(ItemID) REFERENCES Item(ItemID) ON DELETE CASCADE, 
+FOREIGN KEY(FromStatusID) REFERENCES ItemStatus(ItemStatusID), 
+FOREIGN KEY(ToStatusID) REFERENCES ItemStatus(ItemStatusID))`,
+	`CREATE INDEX Item_StatusChange_Item ON Item_StatusChange(ItemID, ChangeID)`,
+}
+
+/* The sale ledger, where returns and corrections are rows of their own that refer to the sale they change */
+var saleTables = []string{
+	`CREATE TABLE Sale(
+SaleID INTEGER PRIMARY KEY, 
+RefSaleID INT, 
+ItemID INT NOT NULL, 
+Kind INT NOT NULL DEFAULT 1, 
+Quantity REAL DEFAULT 1, 
+Price REAL DEFAULT 0, 
+Vat REAL DEFAULT 0, 
+Amount REAL DEFAULT 0, 
+VatAmount REAL DEFAULT 0, 
+Currency TEXT DEFAULT 'SEK', 
+Buyer TEXT DEFAULT '', 
+Reason TEXT DEFAULT '', 
+DateSold TEXT DEFAULT(datetime('now', 'subsec')), 
+FOREIGN KEY(RefSaleID) REFERENCES Sale(SaleID), 
+FOREIGN KEY(ItemID) REFERENCES Item(ItemID))`,
+	`CREATE INDEX Sale_Item ON Sale(ItemID)`,
+	`CREATE INDEX Sale_Ref ON Sale(RefSaleID)`,
+	`CREATE INDEX Sale_Date ON Sale(DateSold)`,
+}
+
+var searchIndexColumns = strings.Join(SearchColumns, ", ")
+
+var searchIndexValues = "new." + strings.Join(SearchColumns, ", new.")
+
+/*
+The full-text index Item_FTS, kept outside the migrations as only builds with FTS5 can create it. Trigrams match inside
+words, as LIKE does, which finds the parts of Swedish compounds such as stol in Kontorsstol. Case and diacritics are
+folded, so orebro and ÖREBRO both find Örebro.
+*/
+const SearchIndexTokenizer = "trigram remove_diacritics 1"
+
+var SearchIndexTables = []string{
+	`DROP TABLE IF EXISTS Item_FTS`,
+	`CREATE VIRTUAL TABLE Item_FTS USING fts5(` + searchIndexColumns + `, tokenize = '` + SearchIndexTokenizer + `')`,
+	`INSERT INTO Item_FTS (rowid, ` + searchIndexColumns + `) SELECT ItemID, ` + searchIndexColumns + ` FROM Item`,
+	`CREATE TRIGGER Item_FTS_Insert AFTER INSERT ON Item
+BEGIN
+    INSERT INTO Item_FTS (rowid, ` + searchIndexColumns + `) VALUES (new.ItemID, ` + searchIndexValues + `);
+END`,
+	`CREATE TRIGGER Item_FTS_Delete AFTER DELETE ON Item
+BEGIN
+    DELETE FROM Item_FTS WHERE rowid = old.ItemID;
+END`,
+	// only the indexed columns, so that the nested update of DateModified does not fire it
+	`CREATE TRIGGER Item_FTS_Update AFTER UPDATE OF ` + searchIndexColumns + ` ON Item
+BEGIN
+    DELETE FROM Item_FTS WHERE rowid = old.ItemID;
+    INSERT INTO Item_FTS (rowid, ` + searchIndexColumns + `) VALUES (new.ItemID, ` + searchIndexValues + `);
+END`,
+}
+
+var SearchIndexTriggers = []string{"Item_FTS_Insert", "Item_FTS_Delete", "Item_FTS_Update"}
@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrNotInTrash = errors.New("not in trash")
+
+/* The tables whose rows belong to an item and go with it when it is purged, by the column holding its ID. Items with rows in the Sale ledger are never purged, so the ledger and the sales reports keep them. */
+var itemDependents = []struct{ table, column string }{
+	{"Item_Condition", "ItemID"},
+	{"Item_Function", "ItemID"},
+	{"Item_Parent", "ItemID"},
+	{"Item_Parent", "ParentID"},
+	{"SearchWords_Association", "ItemID"},
+	{"Item_Trash", "ItemID"},
+	{"Item_StatusChange", "ItemID"},
+}
+
+/* A deleted item, with when it was deleted and the status it had before */
+type TrashedItem struct {
+	ID             int
+	Name           string
+	Deleted        time.Time
+	PreviousStatus int
+}
+
+func (r *sqlRepository) Trash() ([]TrashedItem, error) {
+	var items []TrashedItem
+	rows, err := r.db.Query(`SELECT i.ItemID, i.Name, t.DateDeleted, t.PreviousStatusID
+FROM Item i JOIN Item_Trash t ON t.ItemID = i.ItemID
+WHERE i.ItemStatusID = ?
+ORDER BY t.DateDeleted DESC, i.ItemID DESC`, ItemStatusDeleted)
+	if err != nil {
+		return items, fmt.Errorf("Repository.Trash() error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t TrashedItem
+		var name, deleted sql.NullString
+		if err := rows.Scan(&t.ID, &name, &deleted, &t.PreviousStatus); err != nil {
+			return items, fmt.Errorf("Repository.Trash() error: %w", err)
+		}
+		t.Name = name.String
+		t.Deleted, _ = time.Parse(subsec, deleted.String)
+		items = append(items, t)
+	}
+	if err := rows.Err(); err != nil {
+		return items, fmt.Errorf("Repository.Trash() error: %w", err)
+	}
+	return items, nil
+}
+
+func (r *sqlRepository) TrashedStatus(id int) (int, error) {
+	var status int
+	err := r.db.QueryRow(`SELECT t.PreviousStatusID FROM Item_Trash t JOIN Item i ON i.ItemID = t.ItemID
+WHERE t.ItemID = ? AND i.ItemStatusID = ?`, id, ItemStatusDeleted).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("Repository.TrashedStatus(%d) error: %w", id, ErrNotInTrash)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("Repository.TrashedStatus(%d) error: %w", id, err)
+	}
+	if status == 0 || status == ItemStatusDeleted {
+		status = ItemStatusAvailable
+	}
+	return status, nil
+}
+
+func (r *sqlRepository) ExpiredTrash(before time.Time) ([]int, error) {
+	var ids []int
+	rows, err := r.db.Query(`SELECT t.ItemID FROM Item_Trash t JOIN Item i ON i.ItemID = t.ItemID
+WHERE i.ItemStatusID = ? AND t.DateDeleted < ? AND NOT EXISTS (SELECT 1 FROM Sale s WHERE s.ItemID = t.ItemID)
+ORDER BY t.ItemID`, ItemStatusDeleted, before.UTC().Format(subsec))
+	if err != nil {
+		return ids, fmt.Errorf("Repository.ExpiredTrash() error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return ids, fmt.Errorf("Repository.ExpiredTrash() error: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return ids, fmt.Errorf("Repository.ExpiredTrash() error: %w", err)
+	}
+	return ids, nil
+}
+
+func (r *sqlRepository) PurgeItems(ids []int) ([]int, error) {
+	var purged []int
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("Repository.PurgeItems() error: %w", err)
+	}
+	defer tx.Rollback()
+	for _, id := range ids {
+		var status int
+		var sold bool
+		err := tx.QueryRow(`SELECT ItemStatusID, EXISTS (SELECT 1 FROM Sale WHERE ItemID = ?) FROM Item WHERE ItemID = ?`, id, id).Scan(&status, &sold)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && (status != ItemStatusDeleted || sold)) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Repository.PurgeItems(%d) error: %w", id, err)
+		}
+		for _, d := range itemDependents {
+			if _, err := tx.Exec(`DELETE FROM `+d.table+` WHERE `+d.column+` = ?`, id); err != nil {
+				return nil, fmt.Errorf("Repository.PurgeItems(%d) error: %w", id, err)
+			}
+		}
+		if _, err := tx.Exec(`DELETE FROM Item WHERE ItemID = ?`, id); err != nil {
+			return nil, fmt.Errorf("Repository.PurgeItems(%d) error: %w", id, err)
+		}
+		purged = append(purged, id)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("Repository.PurgeItems() error: %w", err)
+	}
+	return purged, nil
+}
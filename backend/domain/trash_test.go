@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestTrash(t *testing.T) {
+	r := testRepository(t)
+	trash := func() []int {
+		t.Helper()
+		items, err := r.Trash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+	count := func(table string) int {
+		t.Helper()
+		var n int
+		if err := r.(*sqlRepository).db.QueryRow(`SELECT count(*) FROM ` + table).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	if got := trash(); !slices.Equal(got, []int{4}) {
+		t.Fatalf("Trash = %v, want [4]", got)
+	}
+	if _, err := r.Update("Item", 2, "ItemStatusID", ItemStatusDeleted); err != nil {
+		t.Fatal(err)
+	}
+	if got := trash(); !slices.Equal(got, []int{2, 4}) {
+		t.Errorf("Trash after deleting 2 = %v, want [2 4]", got)
+	}
+	if status, err := r.TrashedStatus(2); status != ItemStatusSold || err != nil {
+		t.Errorf("TrashedStatus(2) = %d, %v, want %d", status, err, ItemStatusSold)
+	}
+	if _, err := r.TrashedStatus(1); !errors.Is(err, ErrNotInTrash) {
+		t.Errorf("TrashedStatus(1) error = %v, want %v", err, ErrNotInTrash)
+	}
+	if _, err := r.Update("Item", 2, "ItemStatusID", ItemStatusSold); err != nil {
+		t.Fatal(err)
+	}
+	if got := trash(); !slices.Equal(got, []int{4}) {
+		t.Errorf("Trash after restoring 2 = %v, want [4]", got)
+	}
+
+	expired, err := r.ExpiredTrash(date("2025-03-01"))
+	if err != nil || !slices.Equal(expired, []int{4}) {
+		t.Errorf("ExpiredTrash = %v, %v, want [4]", expired, err)
+	}
+	if expired, _ := r.ExpiredTrash(date("2025-01-01")); len(expired) > 0 {
+		t.Errorf("ExpiredTrash before the deletion = %v", expired)
+	}
+
+	/* Only deleted items are purged */
+	purged, err := r.PurgeItems([]int{1, 4})
+	if err != nil || !slices.Equal(purged, []int{4}) {
+		t.Fatalf("PurgeItems = %v, %v, want [4]", purged, err)
+	}
+	if got := trash(); len(got) > 0 {
+		t.Errorf("Trash after purging = %v", got)
+	}
+	for table, want := range map[string]int{"Item": 3, "Item_Condition": 1, "Item_Function": 1, "Item_Parent": 0, "Item_Trash": 0, "SearchWords_Association": 3} {
+		if got := count(table); got != want {
+			t.Errorf("%s has %d rows, want %d", table, got, want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+/* Builds the conditions of a WHERE clause. Column names come from the code, every value is passed as an argument of a placeholder. */
+type where struct {
+	conds []string
+	args  []any
+}
+
+/* Adds cond, which must have one ? for each of args */
+func (w *where) add(cond string, args ...any) {
+	if n := strings.Count(cond, "?"); n != len(args) {
+		panic(fmt.Sprintf("where.add(%q): %d placeholders, %d arguments", cond, n, len(args)))
+	}
+	w.conds = append(w.conds, cond)
+	w.args = append(w.args, args...)
+}
+
+/* Adds column >= min, unless min is 0 */
+func (w *where) atLeast(column string, min float64) {
+	if min != 0 {
+		w.add(column+" >= ?", min)
+	}
+}
+
+/* Adds column <= max, unless max is 0 */
+func (w *where) atMost(column string, max float64) {
+	if max != 0 {
+		w.add(column+" <= ?", max)
+	}
+}
+
+/*
+Adds the bounds of r on column. With a unit the stored value is first converted from the unit in unitColumn by the
+convertunit function the database must provide.
+*/
+func (w *where) measure(column, unitColumn string, r Range) {
+	if r.Min == 0 && r.Max == 0 {
+		return
+	}
+	if r.Unit == "" {
+		w.atLeast(column, r.Min)
+		w.atMost(column, r.Max)
+		return
+	}
+	if r.Min != 0 {
+		w.add("convertunit("+column+", "+unitColumn+", ?) >= ?", r.Unit, r.Min)
+	}
+	if r.Max != 0 {
+		w.add("convertunit("+column+", "+unitColumn+", ?) <= ?", r.Unit, r.Max)
+	}
+}
+
+/* Adds that the date in column is t or later, unless t is zero */
+func (w *where) from(column string, t time.Time) {
+	if !t.IsZero() {
+		w.add(column+" >= ?", t.UTC().Format(subsec))
+	}
+}
+
+/* Adds that the date in column is before t, unless t is zero */
+func (w *where) until(column string, t time.Time) {
+	if !t.IsZero() {
+		w.add(column+" < ?", t.UTC().Format(subsec))
+	}
+}
+
+/* Returns the WHERE clause, or "" if there are no conditions */
+func (w *where) String() string {
+	if len(w.conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(w.conds, "\nAND ") + " "
+}
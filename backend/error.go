@@ -9,6 +9,7 @@ import (
 var (
 	ErrNotFound         = errors.New("not found")
 	ErrIndexOutOfBounds = errors.New("index out of bounds")
+	ErrInvalidField     = errors.New("invalid field")
 	ErrInvalidType      = errors.New("invalid type")
 	ErrInvalidValue     = errors.New("invalid value")
 	ErrLossyConversion  = errors.New("lossy conversion")
@@ -1,203 +1,549 @@
 package backend
 
 import (
+	"UppSpar/backend/journal"
+	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
 
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/lang"
 	"github.com/xuri/excelize/v2"
 )
 
-/* Export all items with ItemStatusAvailable */
-func (m *Items) ExportExcel(p string) {
-	f := excelize.NewFile()
-	defer f.Close()
-	f.Path = p
+type ExportScope int
+
+const (
+	ScopeProfile   ExportScope = iota // Every item with the status the profile exports
+	ScopeList                         // The items listed for the current Search and Filter
+	ScopeSelection                    // The selected items
+)
+
+var ExportScopes = []ExportScope{ScopeProfile, ScopeList, ScopeSelection}
+
+/* Returns a localized string */
+func (s ExportScope) LString() string {
+	return lang.X("export.scope."+s.String(), "export.scope."+s.String())
+}
 
-	f.SetSheetName("Sheet1", "Data")
-	sw, err := f.NewStreamWriter("Data")
+func (s ExportScope) String() string {
+	switch s {
+	case ScopeList:
+		return "list"
+	case ScopeSelection:
+		return "selection"
+	default:
+		return "profile"
+	}
+}
+
+/* Returns the IDs of the items in scope, in list order. The profile is only used by ScopeProfile. */
+func (m *Items) ScopeItemIDs(scope ExportScope, profile *ExportProfile) ([]ItemID, error) {
+	var list binding.UntypedList
+	switch scope {
+	case ScopeList:
+		list = m.ItemIDList
+	case ScopeSelection:
+		list = m.ItemIDSelection
+	default:
+		return profile.itemIDs()
+	}
+	vals, err := list.Get()
 	if err != nil {
-		log.Printf("Items.ExportExcel(%s) query error: %s", p, err)
-	}
-
-	/* Write R1 headers */
-	if err := sw.SetRow("A1",
-		[]any{
-			excelize.Cell{Value: "Artikelnummer*"},                 // ItemId           *Obligatoriskt fält*
-			excelize.Cell{Value: "Produktbenämning*"},              // Name             *Obligatoriskt fält*
-			excelize.Cell{Value: "Pris*"},                          // Price            *Obligatoriskt fält*
-			excelize.Cell{Value: "Valuta*"},                        // Currency         *Obligatoriskt fält*
-			excelize.Cell{Value: "Antal enheter i pris*"},          // QuantityInPrice
-			excelize.Cell{Value: "Säljenhet*"},                     // Unit             *Obligatoriskt fält*
-			excelize.Cell{Value: "Beställs i multiplar av*"},       // OrderMultiple
-			excelize.Cell{Value: "Minsta beställningskvantitet*"},  // MinOrder
-			excelize.Cell{Value: "Momssats*"},                      // Vat              *Obligatoriskt fält*
-			excelize.Cell{Value: "Antal dagar för leverans"},       // Eta
-			excelize.Cell{Value: "Leveransbeskr. (ers. dagar)"},    // EtaText
-			excelize.Cell{Value: "Bassortiment (\"tumme upp\")*"},  // Priority         *Obligatoriskt fält*
-			excelize.Cell{Value: "Saldo"},                          // Stock
-			excelize.Cell{Value: "Sökord"},                         // SearchWords
-			excelize.Cell{Value: "Webblänk till bild"},             // ImgURL1
-			excelize.Cell{Value: "Webblänk till bild 2"},           // ImgURL2
-			excelize.Cell{Value: "Webblänk till bild 3"},           // ImgURL3
-			excelize.Cell{Value: "Webblänk till bild 4"},           // ImgURL4
-			excelize.Cell{Value: "Webblänk till bild 5"},           // ImgURL5
-			excelize.Cell{Value: "Webblänk till produktblad"},      // SpecsURL
-			excelize.Cell{Value: "UNSPSC (00.00.00.00)"},           // UNSPSC
-			excelize.Cell{Value: "Utförligare beskrivning"},        // LongDesc
-			excelize.Cell{Value: "Tillverkare"},                    // Manufacturer
-			excelize.Cell{Value: "Tillverkarens artnr."},           // MfrItemId
-			excelize.Cell{Value: "Globalt ID"},                     // GlobId
-			excelize.Cell{Value: "Kvalificerare globalt ID"},       // GlobIdType
-			excelize.Cell{Value: "Ersätter artikelnummer"},         // ReplacesItem
-			excelize.Cell{Value: "Tillhör produkt"},                // SubItemOf
-			excelize.Cell{Value: "Tilläggsfrågor"},                 // Questions
-			excelize.Cell{Value: "Förpackas*"},                     // PackagingCode
-			excelize.Cell{Value: "Presentation*"},                  // PresentationCode
-			excelize.Cell{Value: "Automatisk leveranskvittens"},    // DeliveryAutoSign
-			excelize.Cell{Value: "Visa i alternativ best."},        // DeliveryOption
-			excelize.Cell{Value: "Jämförelsepris"},                 // ComparePrice
-			excelize.Cell{Value: "Enhetstyp i jämförelsepris"},     // CompareUnit
-			excelize.Cell{Value: "Antal enheter i jfrpris"},        // CompareQuantityInPrice
-			excelize.Cell{Value: "Prisinformation"},                // PriceInfo
-			excelize.Cell{Value: "Extra beskrivningsfält"},         // AddDesc
-			excelize.Cell{Value: "Flöde*"},                         // ProcFlow
-			excelize.Cell{Value: "Inre enhetstyp"},                 // InnerUnit
-			excelize.Cell{Value: "Antal inre enheter i säljenhet"}, // QuantityInUnit
-			excelize.Cell{Value: "Riskbeskrivning"},                // RiskClassification
-			excelize.Cell{Value: "Kommentar till beställare"},      // Comment
-			excelize.Cell{Value: "Miljömärkning"},                  // EnvClassification
-			excelize.Cell{Value: "Formulär"},                       // FormId
-			excelize.Cell{Value: "Artikeltyp "},                    // Article
-			excelize.Cell{Value: "Bifoga filer"},                   // Attachments
-			excelize.Cell{Value: "Produktgrupp"},                   // ItemGroup
-		}); err != nil {
-		log.Printf("Items.ExportExcel(%s) error: %s", p, err)
-		return
-	}
-
-	/* Fetch ItemIds for all items set to be exported */
+		return nil, fmt.Errorf("Items.ScopeItemIDs(%s) error: %w", scope, err)
+	}
 	var ids []ItemID
-	query := `SELECT ItemID FROM Item WHERE ItemStatusID = 1`
-	rows, err := b.db.Query(query)
+	for _, val := range vals {
+		if id, ok := val.(ItemID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+/* Export the items ids using the Proceedo profile, see ExportItems */
+func (m *Items) Export(p string, format ExportFormat, ids []ItemID, force bool) error {
+	_, err := ExportItems(p, format, ids, force)
+	return err
+}
+
+/* Export all items with ItemStatusAvailable to a Proceedo spreadsheet */
+func ExportExcel(p string, force bool) (*ValidationReport, error) {
+	return Export(p, FormatXLSX, force)
+}
+
+/* Export all items with ItemStatusAvailable using the Proceedo profile */
+func Export(p string, format ExportFormat, force bool) (*ValidationReport, error) {
+	ids, err := ProceedoProfile().itemIDs()
 	if err != nil {
-		log.Printf("Items.ExportExcel(%s) error: %s", p, err)
-	}
-
-	for rows.Next() {
-		var id NullInt
-		rows.Scan(&id)
-		if id.Valid {
-			ids = append(ids, ItemID(id.Int))
-		}
-	}
-
-	/* Iterate over items, add each one as a row */
-	for i, id := range ids {
-		row := make([]any, 48)
-		row[0] = id.String()
-		row[1] = valueOrVoid(id, "Name")            // *Obligatoriskt fält*
-		row[2] = valueOrVoid(id, "Price")           // *Obligatoriskt fält*
-		row[3] = valueOrVoid(id, "Currency")        // *Obligatoriskt fält* SEK
-		row[4] = valueOrVoid(id, "QuantityInPrice") //
-		row[5] = valueOrVoid(id, "Unit")            // *Obligatoriskt fält*
-		row[6] = valueOrVoid(id, "OrderMultiple")   //
-		row[7] = valueOrVoid(id, "MinOrder")        //
-		row[8] = valueOrVoid(id, "Vat")             // *Obligatoriskt fält*
-		row[9] = valueOrVoid(id, "Eta")             //
-		row[10] = valueOrVoid(id, "EtaText")        //
-		row[11] = valueOrVoid(id, "Priority")       // *Obligatoriskt fält* [Y|N]
-		row[12] = valueOrVoid(id, "Stock")
-		row[13] = valueOrVoid(id, "SearchWords") // TODO compile from search words list
-		row[14] = valueOrVoid(id, "ImgURL1")
-		row[15] = valueOrVoid(id, "ImgURL2")
-		row[16] = valueOrVoid(id, "ImgURL3")
-		row[17] = valueOrVoid(id, "ImgURL4")
-		row[18] = valueOrVoid(id, "ImgURL5")
-		row[19] = valueOrVoid(id, "SpecsURL")
-		row[20] = valueOrVoid(id, "UNSPSC")
-		row[21] = valueOrVoid(id, "LongDesc")
-		row[22] = valueOrVoid(id, "Manufacturer")
-		row[23] = valueOrVoid(id, "MfrItemId")
-		row[24] = valueOrVoid(id, "GlobId")
-		row[25] = valueOrVoid(id, "GlobIdType")
-		row[26] = valueOrVoid(id, "ReplacesItem")
-		row[27] = valueOrVoid(id, "SubItemOf") // TODO pull from separate table
-		row[28] = valueOrVoid(id, "Questions")
-		row[29] = valueOrVoid(id, "PackagingCode")
-		row[30] = valueOrVoid(id, "PresentationCode")
-		row[31] = valueOrVoid(id, "DeliveryAutoSign")
-		row[32] = valueOrVoid(id, "DeliveryOption")
-		row[33] = valueOrVoid(id, "ComparePrice")
-		row[34] = valueOrVoid(id, "CompareUnit")
-		row[35] = valueOrVoid(id, "CompareQuantityInPrice")
-		row[36] = valueOrVoid(id, "PriceInfo")
-		row[37] = valueOrVoid(id, "AddDesc")
-		row[38] = valueOrVoid(id, "ProcFlow")
-		row[39] = valueOrVoid(id, "InnerUnit")
-		row[40] = valueOrVoid(id, "QuantityInUnit")
-		row[41] = valueOrVoid(id, "RiskClassification")
-		row[42] = valueOrVoid(id, "Comment")
-		row[43] = valueOrVoid(id, "EnvClassification")
-		row[44] = valueOrVoid(id, "FormId")
-		row[45] = valueOrVoid(id, "Article")
-		row[46] = valueOrVoid(id, "Attachments")
-		row[47] = valueOrVoid(id, "ItemGroup")
-
-		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		return &ValidationReport{}, fmt.Errorf("Export(%s) error: %w", p, err)
+	}
+	return ExportItems(p, format, ids, force)
+}
+
+/* Export the items ids using the Proceedo profile. The items are validated first and the summary written to the Journal. If any item has errors nothing is exported unless force is set, and the error wraps ErrValidation. */
+func ExportItems(p string, format ExportFormat, ids []ItemID, force bool) (*ValidationReport, error) {
+	report, err := ValidateItems(ids)
+	if err != nil {
+		return report, fmt.Errorf("Export(%s) error: %w", p, err)
+	}
+	logValidationReport(report)
+	if !report.OK() && !force {
+		b.Journal.NewEntry(journal.Error, journal.Log, fmt.Sprintf("Exporten till %s avbröts på grund av %d fel.", p, report.Count(SeverityError)))
+		return report, fmt.Errorf("Export(%s) error: %w", p, ErrValidation)
+	}
+	return report, ProceedoProfile().ExportItems(p, format, ids)
+}
+
+/* Write the items the profile selects to a file at path */
+func (p *ExportProfile) Export(path string, format ExportFormat) error {
+	ids, err := p.itemIDs()
+	if err != nil {
+		return fmt.Errorf("Export(%s) error: %w", path, err)
+	}
+	return p.ExportItems(path, format, ids)
+}
+
+/* Write the items ids to a file at path, one row per item below a header row, regardless of their status */
+func (p *ExportProfile) ExportItems(path string, format ExportFormat, ids []ItemID) error {
+	if err := p.Check(); err != nil {
+		return fmt.Errorf("Export(%s) error: %w", path, err)
+	}
+
+	columns, err := itemColumns()
+	if err != nil {
+		return fmt.Errorf("Export(%s) error: %w", path, err)
+	}
+
+	e, err := newExporter(format, path)
+	if err != nil {
+		return fmt.Errorf("Export(%s) error: %w", path, err)
+	}
+	if err := e.Begin(p.SheetName, p.headers()); err != nil {
+		e.Close()
+		return fmt.Errorf("Export(%s) error: %w", path, err)
+	}
+
+	/* Iterate over items, add each one as a row. A row that fails is left out and counted, the others are still written. */
+	var failed int
+	var rowErr error
+	for _, id := range ids {
+		row, err := p.row(id, columns)
+		if err == nil {
+			err = e.Row(row)
+		}
 		if err != nil {
-			log.Printf("Items.ExportExcel(%s) error: %s", p, err)
-		}
-		if err := sw.SetRow(cell, row); err != nil {
-			log.Printf("Items.ExportExcel(%s) error: %s", p, err)
-		}
-	}
-
-	/* Flush stream */
-	if err := sw.Flush(); err != nil {
-		log.Printf("Items.ExportExcel(%s) error: %s", p, err)
-	}
-	/* Save file */
-	if err := f.SaveAs(f.Path); err != nil {
-		log.Printf("Items.ExportExcel(%s) error: %s", p, err)
-	}
-}
-
-/* If value equals the zero value, return an empty any */
-func valueOrVoid(id ItemID, key string) (val any) {
-	switch key {
-	case "Name":
-		val, _ = id.Name()
-	case "Price":
-		val, _ = id.Price()
-	case "Currency":
-		val, _ = id.Currency()
-	case "Unit":
-		val, _ = id.Unit()
-	case "Vat":
-		val, _ = id.Vat()
-	case "Priority":
-		val = "Y"
-	case "Stock":
-		val, _ = id.Stock()
-	case "ImgURL1":
-		val, _ = id.ImgURL1()
-	case "ImgURL2":
-		val, _ = id.ImgURL2()
-	case "ImgURL3":
-		val, _ = id.ImgURL3()
-	case "ImgURL4":
-		val, _ = id.ImgURL4()
-	case "ImgURL5":
-		val, _ = id.ImgURL5()
-	case "SpecsURL":
-		val, _ = id.SpecsURL()
-	case "LongDesc":
-		val, _ = id.LongDesc()
-	case "Manufacturer":
-		val, _ = id.Manufacturer()
-	case "AddDesc":
-		val, _ = id.AddDesc()
+			log.Printf("Export(%s) error: %s", path, err)
+			failed++
+			if rowErr == nil {
+				rowErr = err
+			}
+		}
+	}
+
+	if err := e.Close(); err != nil {
+		return fmt.Errorf("Export(%s) error: %w", path, err)
+	}
+	if failed > 0 {
+		b.Journal.NewEntry(journal.Error, journal.Log, fmt.Sprintf("Exporterade %d av %d artiklar till %s med profilen %s, %d misslyckades: %s", len(ids)-failed, len(ids), path, p.Name, failed, rowErr))
+		return fmt.Errorf("Export(%s) error: %d of %d rows failed: %w", path, failed, len(ids), rowErr)
+	}
+	b.Journal.NewEntry(journal.Message, journal.Log, fmt.Sprintf("Exporterade %d artiklar till %s med profilen %s.", len(ids), path, p.Name))
+	return nil
+}
+
+type ImportMode int
+
+const (
+	ImportCreate ImportMode = iota // Create a new item for every row
+	ImportUpdate                   // Update the item with the row's Artikelnummer, create one if there is none
+	ImportSkip                     // Skip rows whose Artikelnummer already exists, create the rest
+)
+
+func (m ImportMode) String() string {
+	switch m {
+	case ImportUpdate:
+		return "update"
+	case ImportSkip:
+		return "skip"
+	default:
+		return "create"
+	}
+}
+
+/* Returns the ImportMode named s, as returned by ImportMode.String() */
+func ImportModeFor(s string) (ImportMode, error) {
+	for _, m := range []ImportMode{ImportCreate, ImportUpdate, ImportSkip} {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return ImportCreate, fmt.Errorf("ImportModeFor(%s) error: %w", s, ErrInvalidValue)
+}
+
+type ImportAction int
+
+const (
+	ImportCreated ImportAction = iota
+	ImportUpdated
+	ImportSkipped
+	ImportFailed
+)
+
+/* Returns a localized string */
+func (a ImportAction) LString() string {
+	switch a {
+	case ImportUpdated:
+		return lang.X("import.action.updated", "import.action.updated")
+	case ImportSkipped:
+		return lang.X("import.action.skipped", "import.action.skipped")
+	case ImportFailed:
+		return lang.X("import.action.failed", "import.action.failed")
+	default:
+		return lang.X("import.action.created", "import.action.created")
+	}
+}
+
+func (a ImportAction) String() string {
+	switch a {
+	case ImportUpdated:
+		return "updated"
+	case ImportSkipped:
+		return "skipped"
+	case ImportFailed:
+		return "failed"
+	default:
+		return "created"
+	}
+}
+
+/* The outcome of importing one row of a spreadsheet. ItemID is 0 for rows that were created during a dry run. */
+type ImportRow struct {
+	Row    int
+	ItemID ItemID
+	Name   string
+	Action ImportAction
+	Err    error
+}
+
+type ImportReport struct {
+	File   string
+	Mode   ImportMode
+	DryRun bool
+	Rows   []ImportRow
+}
+
+/* Returns the number of rows with action a */
+func (r *ImportReport) Count(a ImportAction) int {
+	n := 0
+	for _, row := range r.Rows {
+		if row.Action == a {
+			n++
+		}
+	}
+	return n
+}
+
+/* Returns the errors of all failed rows, or nil */
+func (r *ImportReport) Err() error {
+	var errs []error
+	for _, row := range r.Rows {
+		if row.Err != nil {
+			errs = append(errs, fmt.Errorf("rad %d: %w", row.Row, row.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+/* Import the rows of the first sheet of a Proceedo spreadsheet into the Item table. Columns are matched on their headers, the first column wins when a header is repeated, "Tillverkare" must name an existing manufacturer, "Sökord" holds search words separated by commas and "Tillhör produkt" the Artikelnummer of an existing item. Empty cells leave the field unchanged. In a dry run every row is rolled back, so the report shows what would happen without changing anything. */
+func ImportExcel(p string, mode ImportMode, dryRun bool) (*ImportReport, error) {
+	report := &ImportReport{File: p, Mode: mode, DryRun: dryRun}
+
+	f, err := excelize.OpenFile(p)
+	if err != nil {
+		return report, fmt.Errorf("ImportExcel(%s) error: %w", p, err)
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(f.GetSheetName(0))
+	if err != nil {
+		return report, fmt.Errorf("ImportExcel(%s) error: %w", p, err)
+	}
+	if len(rows) < 2 {
+		return report, nil
+	}
+
+	columns, err := itemColumns()
+	if err != nil {
+		return report, fmt.Errorf("ImportExcel(%s) error: %w", p, err)
+	}
+	/* A field given by more than one header is read from the first of them */
+	keys := make(map[int][2]string)
+	seen := make(map[string]bool)
+	for i, header := range rows[0] {
+		for _, column := range proceedoColumns {
+			if column.Header != strings.TrimSpace(header) && column.Header != header || seen[column.Source] {
+				continue
+			}
+			seen[column.Source] = true
+			if column.Source == "SearchWords" || column.Source == "SubItemOf" {
+				keys[i] = [2]string{column.Source, ""}
+			} else if c := slices.IndexFunc(columns, func(c [2]string) bool { return c[0] == column.Source }); c >= 0 {
+				keys[i] = columns[c]
+			}
+		}
+	}
+
+	for r, row := range rows[1:] {
+		if slices.IndexFunc(row, func(s string) bool { return strings.TrimSpace(s) != "" }) < 0 {
+			continue
+		}
+		report.Rows = append(report.Rows, importRow(keys, row, r+2, mode, dryRun))
+	}
+
+	if !dryRun {
+		b.Journal.NewEntry(journal.Message, journal.Add, fmt.Sprintf("Importerade %s: %d nya, %d uppdaterade och %d överhoppade artiklar.",
+			p, report.Count(ImportCreated), report.Count(ImportUpdated), report.Count(ImportSkipped)))
+		if n := report.Count(ImportFailed); n > 0 {
+			msg := fmt.Sprintf("%d rader från %s kunde inte importeras.", n, p)
+			for _, row := range report.Rows {
+				if row.Action == ImportFailed {
+					msg += fmt.Sprintf("\nRad %d: %s", row.Row, row.Err)
+				}
+			}
+			b.Journal.NewEntry(journal.Warning, journal.Add, msg)
+		}
+	}
+	return report, report.Err()
+}
+
+func importRow(keys map[int][2]string, row []string, n int, mode ImportMode, dryRun bool) ImportRow {
+	res := ImportRow{Row: n, Action: ImportFailed}
+	cell := func(key string) string {
+		for i, k := range keys {
+			if k[0] == key && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+		}
+		return ""
+	}
+	res.Name = cell("Name")
+
+	/* Look up the item the row refers to, if any */
+	var existing ItemID
+	if s := cell("ItemID"); s != "" && mode != ImportCreate {
+		i, err := strconv.Atoi(s)
+		if err != nil || i < 1 {
+			res.Err = fmt.Errorf("Artikelnummer %q: %w", s, ErrInvalidValue)
+			return res
+		}
+		var found NullInt
+		err = b.db.QueryRow(`SELECT ItemID FROM Item WHERE ItemID = @0`, i).Scan(&found)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			res.Err = err
+			return res
+		}
+		if found.Valid {
+			existing = ItemID(found.Int)
+		}
+	}
+	if existing != 0 && mode == ImportSkip {
+		res.ItemID = existing
+		res.Action = ImportSkipped
+		return res
+	}
+	if existing == 0 && res.Name == "" {
+		res.Err = fmt.Errorf("Produktbenämning saknas")
+		return res
+	}
+
+	var mfr MfrID
+	if s := cell("Manufacturer"); s != "" {
+		id, err := MfrIDFor(s)
+		if errors.Is(err, sql.ErrNoRows) {
+			res.Err = fmt.Errorf("Tillverkare %q: %w", s, ErrNotFound)
+			return res
+		}
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		mfr = id
+	}
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	defer tx.Rollback()
+
+	id := existing
+	if id == 0 {
+		r, err := tx.Exec(`INSERT INTO Item DEFAULT VALUES`)
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		i, err := r.LastInsertId()
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		id = ItemID(i)
+	}
+	for _, i := range slices.Sorted(maps.Keys(keys)) {
+		key := keys[i]
+		if key[0] == "ItemID" || i >= len(row) || strings.TrimSpace(row[i]) == "" {
+			continue
+		}
+		switch key[0] {
+		case "SearchWords":
+			if err := id.setSearchWords(tx, strings.Split(row[i], ",")); err != nil {
+				res.Err = fmt.Errorf("Sökord: %w", err)
+				return res
+			}
+			continue
+		case "SubItemOf":
+			if err := importParent(tx, id, strings.TrimSpace(row[i])); err != nil {
+				res.Err = fmt.Errorf("Tillhör produkt: %w", err)
+				return res
+			}
+			continue
+		}
+		val, err := fieldValue(key[1], strings.TrimSpace(row[i]))
+		if err != nil {
+			res.Err = fmt.Errorf("%s: %w", key[0], err)
+			return res
+		}
+		if _, err := tx.Exec(`UPDATE Item SET `+key[0]+` = @0 WHERE ItemID = @1`, val, id); err != nil {
+			res.Err = fmt.Errorf("%s: %w", key[0], err)
+			return res
+		}
+	}
+	if mfr != 0 {
+		if _, err := tx.Exec(`UPDATE Item SET MfrID = @0 WHERE ItemID = @1`, mfr, id); err != nil {
+			res.Err = fmt.Errorf("MfrID: %w", err)
+			return res
+		}
+	}
+
+	res.Action = ImportCreated
+	if existing != 0 {
+		res.Action = ImportUpdated
+		res.ItemID = existing
+	}
+	if dryRun {
+		return res
+	}
+	if err := tx.Commit(); err != nil {
+		res.Action = ImportFailed
+		res.Err = err
+		return res
+	}
+	res.ItemID = id
+	return res
+}
+
+/* Make item id belong to the item whose Artikelnummer is s, which must exist */
+func importParent(tx *sql.Tx, id ItemID, s string) error {
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 1 {
+		return fmt.Errorf("%q: %w", s, ErrInvalidValue)
+	}
+	var found NullInt
+	err = tx.QueryRow(`SELECT ItemID FROM Item WHERE ItemID = @0`, i).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%q: %w", s, ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	return id.setParentItemID(tx, ItemID(i))
+}
+
+/* Format a value read from a column of type typ for the spreadsheet. Booleans become Y or N, numbers stay numbers and item references become ItemID strings. Mandatory columns are always filled in, other empty text and zero numbers are left blank. */
+func exportValue(typ string, val any, mandatory bool) any {
+	switch typ {
+	case "ITEMID":
+		if i := intValue(val); i != 0 {
+			return ItemID(i).String()
+		}
+		return nil
+	case "BOOL":
+		if intValue(val) != 0 {
+			return "Y"
+		}
+		return "N"
+	case "REAL":
+		f := floatValue(val)
+		if f == 0 && !mandatory {
+			return nil
+		}
+		return f
+	case "INT", "INTEGER":
+		i := intValue(val)
+		if i == 0 && !mandatory {
+			return nil
+		}
+		return i
+	default:
+		s := strings.TrimSpace(textValue(val))
+		if s == "" {
+			return nil
+		}
+		return s
+	}
+}
+
+func intValue(val any) int64 {
+	switch v := val.(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	case bool:
+		if v {
+			return 1
+		}
+	case string, []byte:
+		s := strings.ToLower(strings.TrimSpace(textValue(v)))
+		if s == "true" {
+			return 1
+		}
+		i, _ := strconv.ParseInt(s, 10, 64)
+		return i
+	}
+	return 0
+}
+
+func floatValue(val any) float64 {
+	switch v := val.(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	case string, []byte:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(textValue(v)), 64)
+		return f
+	}
+	return 0
+}
+
+func textValue(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
 	default:
-		return
+		return fmt.Sprint(v)
 	}
-	return
 }
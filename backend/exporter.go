@@ -0,0 +1,343 @@
+package backend
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2/lang"
+	"github.com/xuri/excelize/v2"
+)
+
+/* File formats an export can be written in, each one has an Exporter */
+
+type ExportFormat int
+
+const (
+	FormatXLSX ExportFormat = iota // Excel spreadsheet
+	FormatCSV                      // UTF-8 text with semicolon separators and decimal commas, as Swedish Excel reads it
+	FormatJSON                     // JSON lines, one object per item keyed by the headers
+	FormatODS                      // OpenDocument spreadsheet
+)
+
+var ExportFormats = []ExportFormat{FormatXLSX, FormatCSV, FormatJSON, FormatODS}
+
+/* Returns a localized string */
+func (f ExportFormat) LString() string {
+	return lang.X("export.format."+f.String(), "export.format."+f.String())
+}
+
+func (f ExportFormat) String() string {
+	switch f {
+	case FormatCSV:
+		return "csv"
+	case FormatJSON:
+		return "json"
+	case FormatODS:
+		return "ods"
+	default:
+		return "xlsx"
+	}
+}
+
+/* Returns the file name extension, including the dot */
+func (f ExportFormat) Extension() string {
+	if f == FormatJSON {
+		return ".jsonl"
+	}
+	return "." + f.String()
+}
+
+/* Returns the ExportFormat named s, as returned by ExportFormat.String() */
+func ExportFormatFor(s string) (ExportFormat, error) {
+	for _, f := range ExportFormats {
+		if f.String() == strings.ToLower(s) {
+			return f, nil
+		}
+	}
+	return FormatXLSX, fmt.Errorf("ExportFormatFor(%s) error: %w", s, ErrInvalidValue)
+}
+
+/* Returns the ExportFormat whose extension path has, and false if there is none */
+func ExportFormatForPath(path string) (ExportFormat, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".json" {
+		return FormatJSON, true
+	}
+	for _, f := range ExportFormats {
+		if f.Extension() == ext {
+			return f, true
+		}
+	}
+	return FormatXLSX, false
+}
+
+/* Writes the rows of an export to a file. Cells are nil, strings, int64, int or float64 as returned by ExportProfile.row. */
+type Exporter interface {
+	Begin(sheet string, headers []string) error
+	Row(cells []any) error
+	Close() error
+}
+
+func newExporter(f ExportFormat, path string) (Exporter, error) {
+	switch f {
+	case FormatCSV:
+		return newCSVExporter(path)
+	case FormatJSON:
+		return newJSONExporter(path)
+	case FormatODS:
+		return newODSExporter(path)
+	default:
+		return newXLSXExporter(path), nil
+	}
+}
+
+type xlsxExporter struct {
+	f  *excelize.File
+	sw *excelize.StreamWriter
+	n  int
+}
+
+func newXLSXExporter(path string) *xlsxExporter {
+	f := excelize.NewFile()
+	f.Path = path
+	return &xlsxExporter{f: f}
+}
+
+func (e *xlsxExporter) Begin(sheet string, headers []string) error {
+	e.f.SetSheetName("Sheet1", sheet)
+	sw, err := e.f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+	e.sw = sw
+	var cells []any
+	for _, header := range headers {
+		cells = append(cells, excelize.Cell{Value: header})
+	}
+	return e.Row(cells)
+}
+
+func (e *xlsxExporter) Row(cells []any) error {
+	e.n++
+	cell, err := excelize.CoordinatesToCellName(1, e.n)
+	if err != nil {
+		return err
+	}
+	return e.sw.SetRow(cell, cells)
+}
+
+func (e *xlsxExporter) Close() error {
+	defer e.f.Close()
+	if e.sw != nil {
+		if err := e.sw.Flush(); err != nil {
+			return err
+		}
+	}
+	return e.f.SaveAs(e.f.Path)
+}
+
+type csvExporter struct {
+	file *os.File
+	w    *csv.Writer
+}
+
+func newCSVExporter(path string) (*csvExporter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	/* Excel only reads the file as UTF-8 if it starts with a byte order mark */
+	if _, err := file.WriteString("\ufeff"); err != nil {
+		file.Close()
+		return nil, err
+	}
+	w := csv.NewWriter(file)
+	w.Comma = ';'
+	w.UseCRLF = true
+	return &csvExporter{file: file, w: w}, nil
+}
+
+func (e *csvExporter) Begin(sheet string, headers []string) error {
+	return e.w.Write(headers)
+}
+
+func (e *csvExporter) Row(cells []any) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		switch v := cell.(type) {
+		case float64:
+			record[i] = strings.Replace(strconv.FormatFloat(v, 'f', -1, 64), ".", ",", 1)
+		default:
+			record[i] = textValue(v)
+		}
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvExporter) Close() error {
+	e.w.Flush()
+	if err := e.w.Error(); err != nil {
+		e.file.Close()
+		return err
+	}
+	return e.file.Close()
+}
+
+type jsonExporter struct {
+	file    *os.File
+	w       *bufio.Writer
+	headers []string
+}
+
+func newJSONExporter(path string) (*jsonExporter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonExporter{file: file, w: bufio.NewWriter(file)}, nil
+}
+
+func (e *jsonExporter) Begin(sheet string, headers []string) error {
+	e.headers = headers
+	return nil
+}
+
+/* Writes the cells as one object, in column order */
+func (e *jsonExporter) Row(cells []any) error {
+	e.w.WriteByte('{')
+	for i, cell := range cells {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		key, err := json.Marshal(strings.TrimSpace(e.headers[i]))
+		if err != nil {
+			return err
+		}
+		val, err := json.Marshal(cell)
+		if err != nil {
+			return err
+		}
+		e.w.Write(key)
+		e.w.WriteByte(':')
+		e.w.Write(val)
+	}
+	_, err := e.w.WriteString("}\n")
+	return err
+}
+
+func (e *jsonExporter) Close() error {
+	if err := e.w.Flush(); err != nil {
+		e.file.Close()
+		return err
+	}
+	return e.file.Close()
+}
+
+/* Writes a single table OpenDocument spreadsheet, with content.xml streamed into the archive */
+type odsExporter struct {
+	file *os.File
+	z    *zip.Writer
+	w    *bufio.Writer
+}
+
+const odsManifest = `<?xml version="1.0" encoding="UTF-8"?>
+<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">
+ <manifest:file-entry manifest:full-path="/" manifest:version="1.2" manifest:media-type="application/vnd.oasis.opendocument.spreadsheet"/>
+ <manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>
+</manifest:manifest>
+`
+
+const odsContentBegin = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+	`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" ` +
+	`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" office:version="1.2">
+<office:body><office:spreadsheet>
+`
+
+const odsContentEnd = `</table:table>
+</office:spreadsheet></office:body></office:document-content>
+`
+
+func newODSExporter(path string) (*odsExporter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	e := &odsExporter{file: file, z: zip.NewWriter(file)}
+
+	/* The mimetype must be the first entry and stored uncompressed */
+	w, err := e.z.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err == nil {
+		_, err = w.Write([]byte("application/vnd.oasis.opendocument.spreadsheet"))
+	}
+	if err == nil {
+		w, err = e.z.Create("META-INF/manifest.xml")
+	}
+	if err == nil {
+		_, err = w.Write([]byte(odsManifest))
+	}
+	if err == nil {
+		w, err = e.z.Create("content.xml")
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	e.w = bufio.NewWriter(w)
+	return e, nil
+}
+
+func (e *odsExporter) Begin(sheet string, headers []string) error {
+	e.w.WriteString(odsContentBegin)
+	e.w.WriteString(`<table:table table:name="`)
+	xml.EscapeText(e.w, []byte(sheet))
+	e.w.WriteString("\">\n")
+	cells := make([]any, len(headers))
+	for i, header := range headers {
+		cells[i] = header
+	}
+	return e.Row(cells)
+}
+
+func (e *odsExporter) Row(cells []any) error {
+	e.w.WriteString("<table:table-row>")
+	for _, cell := range cells {
+		switch v := cell.(type) {
+		case nil:
+			e.w.WriteString("<table:table-cell/>")
+		case float64, int64, int:
+			s := textValue(v)
+			if f, ok := v.(float64); ok {
+				s = strconv.FormatFloat(f, 'f', -1, 64)
+			}
+			fmt.Fprintf(e.w, `<table:table-cell office:value-type="float" office:value="%s"><text:p>%s</text:p></table:table-cell>`, s, s)
+		default:
+			e.w.WriteString(`<table:table-cell office:value-type="string"><text:p>`)
+			xml.EscapeText(e.w, []byte(textValue(v)))
+			e.w.WriteString("</text:p></table:table-cell>")
+		}
+	}
+	_, err := e.w.WriteString("</table:table-row>\n")
+	return err
+}
+
+func (e *odsExporter) Close() error {
+	e.w.WriteString(odsContentEnd)
+	if err := e.w.Flush(); err != nil {
+		e.file.Close()
+		return err
+	}
+	if err := e.z.Close(); err != nil {
+		e.file.Close()
+		return err
+	}
+	return e.file.Close()
+}
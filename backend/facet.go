@@ -0,0 +1,69 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+)
+
+/* One value a field of the filter can be set to and how many items of the current search it gives */
+type FacetOption struct {
+	Value string // What the Filter field is set to
+	Label string
+	Depth int // How far below a top category a category is
+	Count int
+}
+
+/* The options of the filter fields with their counts. Categories are in tree order and count the items of their subcategories too. */
+type ItemFacets struct {
+	Categories    []FacetOption
+	Manufacturers []FacetOption
+	Statuses      []FacetOption
+	Buckets       map[string][]FacetOption // By measurement, the values are ranges as the filter takes them
+}
+
+/* Returns the options of the filter fields for the current search and filter of the list. Each field is counted as if it was not set. */
+func (m *Items) Facets(ctx context.Context) (*ItemFacets, error) {
+	e, f, err := m.query()
+	if err != nil {
+		return nil, fmt.Errorf("Items.Facets() error: %w", err)
+	}
+	facets, err := b.Repository.Facets(ctx, e, f)
+	if err != nil {
+		return nil, fmt.Errorf("Items.Facets() error: %w", err)
+	}
+	v := &ItemFacets{Buckets: make(map[string][]FacetOption)}
+
+	counts := make(map[CatID]int)
+	for _, c := range facets.Categories {
+		counts[CatID(c.ID)] = c.Count
+	}
+	var walk func(id CatID, depth int) int
+	walk = func(id CatID, depth int) int {
+		n := counts[id]
+		for _, child := range id.Children() {
+			i := len(v.Categories)
+			name, _ := child.Name()
+			v.Categories = append(v.Categories, FacetOption{Value: name, Label: name, Depth: depth})
+			v.Categories[i].Count = walk(child, depth+1)
+			n += v.Categories[i].Count
+		}
+		return n
+	}
+	walk(0, 0)
+
+	for _, c := range facets.Manufacturers {
+		if c.Name != "" {
+			v.Manufacturers = append(v.Manufacturers, FacetOption{Value: c.Name, Label: c.Name, Count: c.Count})
+		}
+	}
+	for _, c := range facets.Statuses {
+		s := ItemStatusID(c.ID).LString()
+		v.Statuses = append(v.Statuses, FacetOption{Value: s, Label: s, Count: c.Count})
+	}
+	for key, buckets := range facets.Buckets {
+		for _, c := range buckets {
+			v.Buckets[key] = append(v.Buckets[key], FacetOption{Value: c.Range.String(), Label: c.Range.String(), Count: c.Count})
+		}
+	}
+	return v, nil
+}
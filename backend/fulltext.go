@@ -0,0 +1,72 @@
+package backend
+
+import (
+	"UppSpar/backend/domain"
+	"UppSpar/backend/journal"
+	"fmt"
+	"strings"
+)
+
+/* The full-text index Item_FTS over the item texts. SQLite only has FTS5 when the program is built with -tags sqlite_fts5, so the index is kept outside the migrations. It is created when the module is available. When it is not, the triggers that keep it in sync are dropped so that Item can still be changed, and searches fall back to LIKE. */
+
+/* Reports whether this build of SQLite has FTS5 */
+func (backend *Backend) fullTextAvailable() bool {
+	var used bool
+	backend.db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&used)
+	return used
+}
+
+/* Returns the tokenizer the full-text index was created with, or "" if there is none */
+func (backend *Backend) searchIndexTokenizer() string {
+	var stmt string
+	backend.db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'Item_FTS'`).Scan(&stmt)
+	_, tokenizer, _ := strings.Cut(stmt, "tokenize = '")
+	tokenizer, _, _ = strings.Cut(tokenizer, "'")
+	return tokenizer
+}
+
+/* Create and fill the full-text index unless its triggers already keep a trigram index in sync, or drop the triggers if FTS5 is missing */
+func (backend *Backend) ensureSearchIndex() error {
+	var n int
+	query := `SELECT count(*) FROM sqlite_master WHERE type = 'trigger' AND name IN ('` + strings.Join(domain.SearchIndexTriggers, "', '") + `')`
+	if err := backend.db.QueryRow(query).Scan(&n); err != nil {
+		return fmt.Errorf("Backend.ensureSearchIndex() error: %w", err)
+	}
+
+	if !backend.fullTextAvailable() {
+		if n == 0 {
+			return nil
+		}
+		for _, trigger := range domain.SearchIndexTriggers {
+			if _, err := backend.db.Exec(`DROP TRIGGER IF EXISTS ` + trigger); err != nil {
+				return fmt.Errorf("Backend.ensureSearchIndex() error: %w", err)
+			}
+		}
+		backend.Journal.NewEntry(journal.Warning, journal.SQL, "Fritextsökning saknas i den här versionen, sökindexet uppdateras inte längre.")
+		return nil
+	}
+	if n == len(domain.SearchIndexTriggers) && backend.searchIndexTokenizer() == domain.SearchIndexTokenizer {
+		return nil
+	}
+
+	tx, err := backend.db.Begin()
+	if err != nil {
+		return fmt.Errorf("Backend.ensureSearchIndex() error: %w", err)
+	}
+	defer tx.Rollback()
+	for _, trigger := range domain.SearchIndexTriggers {
+		if _, err := tx.Exec(`DROP TRIGGER IF EXISTS ` + trigger); err != nil {
+			return fmt.Errorf("Backend.ensureSearchIndex() error: %w", err)
+		}
+	}
+	for _, stmt := range domain.SearchIndexTables {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("Backend.ensureSearchIndex() error: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Backend.ensureSearchIndex() error: %w", err)
+	}
+	backend.Journal.NewEntry(journal.Message, journal.SQL, "Byggde sökindexet för fritextsökning.")
+	return nil
+}
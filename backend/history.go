@@ -0,0 +1,176 @@
+package backend
+
+import (
+	"UppSpar/backend/domain"
+	"UppSpar/backend/journal"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+/* How soon another edit of the same row joins the last one, as one undo step and one journal entry */
+const editMergeDelay = 1500 * time.Millisecond
+
+/* How many columns and how many characters of each value a journal message shows */
+const (
+	historyFieldsShown = 3
+	historyValueWidth  = 40
+)
+
+/* How journal messages of field changes begin */
+const (
+	editLead    = "Ändrade"
+	undoLead    = "Ångrade ändringen av"
+	redoLead    = "Gjorde om ändringen av"
+	restoreLead = "Återställde"
+	deleteLead  = "Tog bort"
+	expireLead  = "Släppte den utgångna reservationen av"
+)
+
+/* What the tables whose edits are undone and written to the history are called in the journal */
+var tableNouns = map[string]string{
+	"Item":         "artikel",
+	"Model":        "modell",
+	"Manufacturer": "tillverkare",
+	"Category":     "kategori",
+}
+
+/* The newest field change entry of the journal, rewritten while the same row is edited */
+type history struct {
+	mu     sync.Mutex
+	change *domain.Change
+	entry  journal.EntryID
+	at     time.Time
+}
+
+/*
+Sets column key of row id in table t to val if it differs, and records what changed in the row, the columns triggers
+changed included, in the undo stack and the journal. Returns nil if nothing changed.
+*/
+func update(t string, id int, key string, val any, lead string) (*domain.Change, error) {
+	c, err := b.Repository.Update(t, id, key, val)
+	if err != nil || c == nil {
+		return c, err
+	}
+	if b.Undo != nil {
+		b.Undo.record(c)
+	}
+	b.history.record(c, lead)
+	return c, nil
+}
+
+/* Sets the column of c back to its value before c and reloads what shows it. A status goes back as the status dialog changes it. */
+func Restore(c journal.Change) error {
+	if _, ok := tableNouns[c.Table]; !ok {
+		return fmt.Errorf("Restore(%s, %d, %s) error: %w", c.Table, c.RowID, c.Column, domain.ErrUnknownTable)
+	}
+	if c.Table == "Item" && c.Column == "ItemStatusID" {
+		to, err := strconv.Atoi(fmt.Sprint(c.Old))
+		if err != nil {
+			return fmt.Errorf("Restore(%s, %d, %s) error: %w", c.Table, c.RowID, c.Column, ErrInvalidValue)
+		}
+		return ItemID(c.RowID).ChangeStatus(StatusChange{To: to, Reason: restoreReason})
+	}
+	change, err := update(c.Table, c.RowID, c.Column, c.Old, restoreLead)
+	if err != nil {
+		return fmt.Errorf("Restore(%s, %d, %s) error: %w", c.Table, c.RowID, c.Column, err)
+	}
+	if change != nil {
+		reload(change)
+	}
+	return nil
+}
+
+/* Returns the field changes of the item, newest first */
+func (id ItemID) History() ([]journal.Change, error) {
+	return b.Journal.History("Item", id.Int())
+}
+
+/*
+Writes c to the journal. An edit of the same row as the newest entry soon after it is merged into that entry, unless
+the two cancel out.
+*/
+func (h *history) record(c *domain.Change, lead string) {
+	if _, ok := tableNouns[c.Table]; !ok {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	if lead == editLead && h.change != nil && h.change.Table == c.Table && h.change.ID == c.ID && now.Sub(h.at) < editMergeDelay {
+		merged := cloneChange(h.change)
+		merged.Merge(c)
+		if !merged.Empty() {
+			h.change, h.at = merged, now
+			b.Journal.UpdateChange(h.entry, historyMessage(merged, lead), historyFields(merged))
+			return
+		}
+	}
+	h.change, h.at = cloneChange(c), now
+	h.entry = b.Journal.NewChange(historyMessage(c, lead), historyFields(c))
+	if lead != editLead {
+		h.change = nil
+	}
+}
+
+/* Returns a copy of c whose fields can be merged into without changing c */
+func cloneChange(c *domain.Change) *domain.Change {
+	return &domain.Change{Table: c.Table, ID: c.ID, Key: c.Key, Fields: slices.Clone(c.Fields)}
+}
+
+/* Returns c with the values before and after swapped */
+func reverseChange(c *domain.Change) *domain.Change {
+	r := cloneChange(c)
+	for i, f := range r.Fields {
+		r.Fields[i].Before, r.Fields[i].After = f.After, f.Before
+	}
+	return r
+}
+
+func historyFields(c *domain.Change) []journal.FieldChange {
+	var fields []journal.FieldChange
+	for _, f := range c.Fields {
+		fields = append(fields, journal.FieldChange{Table: c.Table, RowID: c.ID, Column: f.Key, Old: f.Before, New: f.After})
+	}
+	return fields
+}
+
+/* Such as: Ändrade artikel 12: Name från "Stol" till "Pinnstol". The column that was set comes before those triggers changed. */
+func historyMessage(c *domain.Change, lead string) string {
+	changes := slices.Clone(c.Fields)
+	slices.SortStableFunc(changes, func(a, b domain.FieldChange) int {
+		switch {
+		case a.Key == c.Key && b.Key != c.Key:
+			return -1
+		case b.Key == c.Key && a.Key != c.Key:
+			return 1
+		}
+		return 0
+	})
+	var fields []string
+	for _, f := range changes[:min(len(changes), historyFieldsShown)] {
+		fields = append(fields, fmt.Sprintf("%s från %s till %s", f.Key, historyValue(f.Before), historyValue(f.After)))
+	}
+	if n := len(c.Fields) - historyFieldsShown; n > 0 {
+		fields = append(fields, fmt.Sprintf("och %d fält till", n))
+	}
+	return fmt.Sprintf("%s %s %d: %s.", lead, tableNouns[c.Table], c.ID, strings.Join(fields, ", "))
+}
+
+func historyValue(v any) string {
+	if v == nil {
+		return "tomt"
+	}
+	s := journal.FormatValue(v)
+	if _, ok := v.(string); !ok {
+		return s
+	}
+	if utf8.RuneCountInString(s) > historyValueWidth {
+		s = string([]rune(s)[:historyValueWidth]) + "…"
+	}
+	return `"` + s + `"`
+}
@@ -1,7 +1,9 @@
 package backend
 
 import (
+	"UppSpar/backend/domain"
 	"UppSpar/backend/journal"
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
@@ -11,72 +13,38 @@ import (
 	"reflect"
 	"runtime"
 	"slices"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/lang"
-	"fyne.io/fyne/v2/widget"
 )
 
-type SearchTermMatch int
+type SearchTermMatch = domain.SearchTermMatch
 
 const (
-	MatchBeginsWith SearchTermMatch = iota
-	MatchEndsWith
-	MatchContains
-	MatchEquals
-	RegExp
+	MatchBeginsWith = domain.MatchBeginsWith
+	MatchEndsWith   = domain.MatchEndsWith
+	MatchContains   = domain.MatchContains
+	MatchEquals     = domain.MatchEquals
+	RegExp          = domain.RegExp
 )
 
-type SearchKey int
+var SearchTermMatches = domain.SearchTermMatches
 
-const (
-	SearchKeyName SearchKey = iota
-	SearchKeyDesc
-	SearchKeyManufacturer
-	SearchKeyModel
-	SearchKeyItemID
-	SearchKeyDateCreated
-	SearchKeyDateModified
-)
+type SortKey = domain.SortKey
+type SortSpec = domain.SortSpec
 
-func (k SearchKey) String() string {
-	switch k {
-	case SearchKeyItemID:
-		return "ItemID"
-	case SearchKeyName:
-		return "Name"
-	case SearchKeyDesc:
-		return "Descr"
-	case SearchKeyManufacturer:
-		return "Manufacturer"
-	case SearchKeyModel:
-		return "Model"
-	case SearchKeyDateCreated:
-		return "DateCreated"
-	case SearchKeyDateModified:
-		return "DateModified"
-	default:
-		return ""
-	}
-}
+var DefaultSort = domain.DefaultSort
 
-type SortOrder int
+type SortOrder = domain.SortOrder
 
 const (
-	SortAscending SortOrder = iota
-	SortDescending
+	SortAscending  = domain.SortAscending
+	SortDescending = domain.SortDescending
 )
 
-func (o SortOrder) String() string {
-	if o == SortAscending {
-		return "ASC"
-	}
-	return "DESC"
-}
-
 var (
 	_ sql.Scanner   = (*ItemStatusID)(nil)
 	_ driver.Valuer = (*ItemStatusID)(nil)
@@ -164,16 +132,30 @@ func (id *ItemStatusID) Scan(src any) error {
 }
 
 const (
-	ItemStatusAvailable ItemStatusID = iota + 1
-	ItemStatusSold
-	ItemStatusReserved
-	ItemStatusArchived
-	ItemStatusDeleted
+	ItemStatusAvailable ItemStatusID = domain.ItemStatusAvailable
+	ItemStatusSold      ItemStatusID = domain.ItemStatusSold
+	ItemStatusReserved  ItemStatusID = domain.ItemStatusReserved
+	ItemStatusArchived  ItemStatusID = domain.ItemStatusArchived
+	ItemStatusDeleted   ItemStatusID = domain.ItemStatusDeleted
 )
 
+/* How long the search and filter must stay unchanged before the items are listed again */
+const searchDelay = 250 * time.Millisecond
+
+/* The number of item IDs fetched at a time */
+const itemPageSize = 500
+
+/* The most completions offered for the search term */
+const completionLimit = 20
+
 type Items struct {
-	j    *journal.Journal
-	data map[ItemID]*Item
+	j          *journal.Journal
+	data       map[ItemID]*Item
+	mu         sync.Mutex // Guards timer, cancel and completing
+	timer      *time.Timer
+	cancel     context.CancelFunc
+	completing context.CancelFunc
+	listMu     sync.Mutex // Held while a listing publishes its results
 
 	ItemIDList      binding.UntypedList
 	ItemIDSelection binding.UntypedList
@@ -200,7 +182,7 @@ func (m *Items) GetItem(id ItemID) *Item {
 	}
 	return m.data[id]
 }
-func (m *Items) GetItemIDFor(index widget.ListItemID) (ItemID, error) {
+func (m *Items) GetItemIDFor(index int) (ItemID, error) {
 	id, err := m.ItemIDList.GetValue(index)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		log.Println(err)
@@ -208,7 +190,7 @@ func (m *Items) GetItemIDFor(index widget.ListItemID) (ItemID, error) {
 	}
 	return id.(ItemID), err
 }
-func (m *Items) GetListItemIDFor(id ItemID) (widget.ListItemID, error) {
+func (m *Items) GetListItemIDFor(id ItemID) (int, error) {
 	ids, err := m.ItemIDList.Get()
 	if err != nil {
 		panic(err)
@@ -217,7 +199,7 @@ func (m *Items) GetListItemIDFor(id ItemID) (widget.ListItemID, error) {
 	if index == -1 {
 		return index, ErrNotFound
 	}
-	return widget.ListItemID(index), nil
+	return index, nil
 }
 func (m *Items) CreateNewItem() (ItemID, error) {
 	var i ItemID
@@ -279,14 +261,10 @@ FROM Item WHERE ItemID = @0`
 	m.GetItemIDs()
 	return newid, err
 }
+
+/* Moves item id to the trash, from where it can be restored or purged */
 func (m *Items) DeleteItem(id ItemID) error {
-	query := `UPDATE Item SET ItemStatusID = @0 WHERE ItemID = @1`
-	stmt, err := b.db.Prepare(query)
-	if err != nil {
-		return fmt.Errorf("DeleteItem error: %w", err)
-	}
-	defer stmt.Close()
-	_, err = stmt.Exec(ItemStatusDeleted, id)
+	_, err := setStatus(StatusChange{ItemID: id.Int(), To: int(ItemStatusDeleted)}, deleteLead)
 	if err != nil {
 		return fmt.Errorf("DeleteItem error: %w", err)
 	}
@@ -304,201 +282,250 @@ func (m *Items) UnselectItem(id ItemID) error {
 func (m *Items) ClearSelection() error {
 	return m.ItemIDSelection.Set([]any{})
 }
+
+/* Returns the text around the match of the current search in item id, or "" */
+func (m *Items) Snippet(id ItemID) string {
+	e, _, err := m.query()
+	if err != nil {
+		return ""
+	}
+	snippet, err := b.Repository.Snippet(e, int(id))
+	if err != nil {
+		log.Println(err)
+	}
+	return snippet
+}
+
+/* Returns the search and filter of the list, with the field conditions typed in the search term moved to the filter */
+func (m *Items) query() (domain.Search, domain.Filter, error) {
+	f, err := m.Filter.complex()
+	if err != nil {
+		return domain.Search{}, f, err
+	}
+	return domain.ParseQuery(m.Search.complex(), f)
+}
+
+/* Returns the localized reason why the search term could not be used, or "" if err is not about the term */
+func searchError(err error) string {
+	var perr *domain.PatternError
+	if errors.As(err, &perr) {
+		return fmt.Sprintf(lang.X("search.error.pattern", "search.error.pattern"), perr.Reason)
+	}
+	var qerr *domain.QueryError
+	if errors.As(err, &qerr) {
+		key := "search.error.query." + qerr.Reason
+		return fmt.Sprintf(lang.X(key, key), qerr.Token)
+	}
+	var serr *domain.SortError
+	if errors.As(err, &serr) {
+		return fmt.Sprintf(lang.X("search.error.sort", "search.error.sort"), serr.Key)
+	}
+	return ""
+}
+
+/* Lists the items again once the search and filter have stayed unchanged for searchDelay, so typing runs one query instead of one per key */
+func (m *Items) QueueItemIDs() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+	m.timer = time.AfterFunc(searchDelay, m.GetItemIDs)
+}
+
+/* Cancels the listing in progress, if any, and returns the context of a new one */
+func (m *Items) restart() context.Context {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancel != nil {
+		m.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	return ctx
+}
+
+/* Calls set unless the listing of ctx has been replaced by a newer one, and reports whether it did */
+func (m *Items) publish(ctx context.Context, set func()) bool {
+	m.listMu.Lock()
+	defer m.listMu.Unlock()
+	if ctx.Err() != nil {
+		return false
+	}
+	set()
+	return true
+}
+
+/*
+Lists the items that match the search and filter a page at a time, the list shows the first page while the rest load.
+A newer call cancels the queries of an older one, whose results are dropped.
+*/
 func (m *Items) GetItemIDs() {
-	m.ItemIDList.Set([]any{})
-	query := `SELECT ItemID FROM Item WHERE ItemID <> 0 `
-	e := m.Search.complex()
-	query, term := e.addSearchStrings(query)
-	f := m.Filter.complex()
-	query = f.addFilterStrings(query)
-	query += fmt.Sprintf("AND ItemStatusID <> %d ", ItemStatusDeleted) // TODO update this
-	query += "ORDER BY " + e.sortby.String() + " " + e.order.String()
-
-	// log.Println(query)
-
-	var err error
-	var rows *sql.Rows
-	switch len(e.scope) {
-	case 1:
-		rows, err = b.db.Query(query, term)
-	case 2:
-		rows, err = b.db.Query(query, term, term)
-	case 3:
-		rows, err = b.db.Query(query, term, term, term)
-	case 4:
-		rows, err = b.db.Query(query, term, term, term, term)
-	default:
-		rows, err = b.db.Query(query)
+	ctx := m.restart()
+	e, f, err := m.query()
+	var page []int
+	if err == nil {
+		page, err = b.Repository.ItemIDPage(ctx, e, f, 0, itemPageSize)
+	}
+	if msg := searchError(err); msg != "" {
+		m.publish(ctx, func() {
+			m.ItemIDList.Set([]any{})
+			m.Search.Error.Set(msg)
+		})
+		return
+	}
+	if ctx.Err() != nil {
+		return
 	}
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+	if err != nil {
 		panic(err)
 	}
-	defer rows.Close()
-
-	m.ItemIDList.Set([]any{})
-	m.Search.Completions.Set([]string{})
-	uniqueResults := make(map[string]bool)
-	for rows.Next() {
-		var hit string
-		var id ItemID
-		rows.Scan(&id)
-		m.ItemIDList.Append(id)
-		if e.scope["Name"] {
-			hit, _ = id.Name()
-			if !uniqueResults[hit] {
-				uniqueResults[hit] = true
-				m.Search.Completions.Append(hit)
+
+	ids := []any{}
+	for _, n := range page {
+		ids = append(ids, ItemID(n))
+	}
+	ok := m.publish(ctx, func() {
+		m.Search.Error.Set("")
+		m.ItemIDList.Set(slices.Clip(ids))
+	})
+	if !ok || len(page) < itemPageSize {
+		return
+	}
+	/* Every Set updates each item already in the list, so the rest is set once */
+	for len(page) == itemPageSize {
+		page, err = b.Repository.ItemIDPage(ctx, e, f, len(ids), itemPageSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Println(err)
 			}
+			return
 		}
-		if e.scope["Manufacturer"] {
-			hit, _ = id.Manufacturer()
-			if !uniqueResults[hit] {
-				uniqueResults[hit] = true
-				m.Search.Completions.Append(hit)
-			}
+		for _, n := range page {
+			ids = append(ids, ItemID(n))
 		}
-		// if e.scope["ModelName"] {
-		// 	hit, _ = id.ModelName()
-		// 	if !uniqueResults[hit] {
-		// 		uniqueResults[hit] = true
-		// 		m.Search.Completions.Append(hit)
-		// 	}
-		// }
 	}
+	m.publish(ctx, func() { m.ItemIDList.Set(ids) })
+}
+
+/* Sets Search.Completions to the completions of the search term. A newer call cancels the query of an older one, so it can run on every keystroke. */
+func (m *Items) GetCompletions() {
+	m.mu.Lock()
+	if m.completing != nil {
+		m.completing()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.completing = cancel
+	m.mu.Unlock()
+
+	completions, err := Completions(ctx, m.Search.term())
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		log.Println(err)
+	}
+	m.Search.Completions.Set(completions)
+}
+
+/* Returns the IDs of all items that are not deleted and contain term in any of the search columns, best match first, or all of them if term is empty. Field conditions in term are applied as with the search box. Does not depend on any bindings. */
+func SearchItemIDs(term string) ([]ItemID, error) {
+	e, f, err := domain.ParseQuery(termSearch(term), domain.Filter{})
+	if err != nil {
+		return nil, err
+	}
+	return queryItemIDs(e, f)
+}
+
+/* Returns the text around the match of term in item id, see SearchItemIDs */
+func SearchSnippet(term string, id ItemID) (string, error) {
+	e, _, err := domain.ParseQuery(termSearch(term), domain.Filter{})
+	if err != nil {
+		return "", err
+	}
+	return b.Repository.Snippet(e, int(id))
+}
+
+func termSearch(term string) domain.Search {
+	e := domain.Search{
+		Term:  term,
+		Scope: make(map[string]bool),
+		Match: MatchContains,
+		Sort:  DefaultSort,
+	}
+	for _, key := range domain.SearchColumns {
+		e.Scope[key] = true
+	}
+	return e
+}
+
+/* Returns the keys the items list can be sorted by */
+func SortKeys() ([]string, error) {
+	return b.Repository.SortKeys()
+}
+
+func queryItemIDs(e domain.Search, f domain.Filter) ([]ItemID, error) {
+	var ids []ItemID
+	nums, err := b.Repository.ItemIDs(e, f)
+	for _, n := range nums {
+		ids = append(ids, ItemID(n))
+	}
+	return ids, err
 }
 
 type Search struct {
 	Completions binding.StringList
+	Error       binding.String // Why the term could not be used, or empty
 	Term        binding.String
 	Scope       map[string]binding.Bool
 	Match       SearchTermMatch
-	SortBy      SearchKey
-	Order       SortOrder
+	Sort        SortSpec
 }
 
 func newSearch() *Search {
 	s := &Search{
 		Completions: binding.NewStringList(),
+		Error:       binding.NewString(),
 		Term:        binding.NewString(),
 		Scope:       make(map[string]binding.Bool),
 		Match:       MatchContains,
-		SortBy:      SearchKeyItemID,
-		Order:       SortAscending,
+		Sort:        DefaultSort,
 	}
-	columns := []string{"Name", "Manufacturer", "ModelName", "ModelDesc"}
-	for _, key := range columns {
+	for _, key := range domain.SearchColumns {
 		s.Scope[key] = binding.NewBool()
-		s.Scope[key].AddListener(binding.NewDataListener(func() { b.Items.GetItemIDs() }))
+		s.Scope[key].Set(true)
+		s.Scope[key].AddListener(binding.NewDataListener(func() { b.Items.QueueItemIDs() }))
 	}
-	s.Scope["Name"].Set(true)
-	s.Term.AddListener(binding.NewDataListener(func() { b.Items.GetItemIDs() }))
+	s.Term.AddListener(binding.NewDataListener(func() {
+		b.Items.QueueItemIDs()
+		go b.Items.GetCompletions()
+	}))
 	return s
 }
 
-func (e *Search) complex() searchComplex {
-	c := searchComplex{
-		scope: make(map[string]bool),
-	}
-	c.term, _ = e.Term.Get()
-	c.scope["Name"], _ = e.Scope["Name"].Get()
-	c.scope["Manufacturer"], _ = e.Scope["Manufacturer"].Get()
-	c.scope["ModelName"], _ = e.Scope["ModelName"].Get()
-	c.scope["ModelDesc"], _ = e.Scope["ModelDesc"].Get()
-	c.match = e.Match
-	c.sortby = e.SortBy
-	c.order = e.Order
-	return c
-}
-
-type searchComplex struct {
-	term   string
-	scope  map[string]bool
-	match  SearchTermMatch
-	sortby SearchKey
-	order  SortOrder
+func (e *Search) term() string {
+	term, _ := e.Term.Get()
+	return term
 }
 
-func (e searchComplex) addSearchStrings(query string) (string, string) {
-	var term string
-	if e.term == "" {
-		return query, term
+func (e *Search) complex() domain.Search {
+	c := domain.Search{
+		Scope: make(map[string]bool),
 	}
-	columns := []string{"Name", "Manufacturer", "ModelName", "ModelDesc"}
-	var keys []string
-	for _, column := range columns {
-		if e.scope[column] {
-			keys = append(keys, column)
-		}
-	}
-	if len(keys) < 1 {
-		return query, term
-	}
-	switch e.match {
-	case MatchBeginsWith:
-		term = fmt.Sprintf("%s%%", e.term)
-		if len(keys) > 1 {
-			query += "AND ("
-			for i, key := range keys {
-				if i > 0 {
-					query += " OR "
-				}
-				query += fmt.Sprintf("%s LIKE ?", key)
-			}
-			query += ") "
-		} else {
-			query += fmt.Sprintf("AND %s LIKE ? ", keys[0])
-		}
-	case MatchEndsWith:
-		term = fmt.Sprintf("%%%s", e.term)
-		if len(keys) > 1 {
-			query += "AND ("
-			for i, key := range keys {
-				if i > 0 {
-					query += " OR "
-				}
-				query += fmt.Sprintf("%s LIKE ?", key)
-			}
-			query += ") "
-		} else {
-			query += fmt.Sprintf("AND %s LIKE ? ", keys[0])
-		}
-	case MatchContains:
-		term = fmt.Sprintf("%%%s%%", e.term)
-		if len(keys) > 1 {
-			query += "AND ("
-			for i, key := range keys {
-				if i > 0 {
-					query += " OR "
-				}
-				query += fmt.Sprintf("%s LIKE ?", key)
-			}
-			query += ") "
-		} else {
-			query += fmt.Sprintf("AND %s LIKE ? ", keys[0])
-		}
-	default:
-		// MatchEquals
-		term = fmt.Sprintf("%s", e.term)
-		if len(keys) > 1 {
-			query += "AND ("
-			for i, key := range keys {
-				if i > 0 {
-					query += " OR "
-				}
-				query += fmt.Sprintf("%s LIKE ?", key)
-			}
-			query += ") "
-		} else {
-			query += fmt.Sprintf("AND %s LIKE ? ", keys[0])
-		}
+	c.Term, _ = e.Term.Get()
+	for _, key := range domain.SearchColumns {
+		c.Scope[key], _ = e.Scope[key].Get()
 	}
-	return query, term
+	c.Match = e.Match
+	c.Sort = e.Sort
+	return c
 }
 
 type Filter struct {
 	Category             binding.String
 	Manufacturer         binding.String
 	Model                binding.String
+	Status               binding.String // The name of an item status, as in status:available
 	Width, Height, Depth binding.String
 	Volume, Weight       binding.String
 }
@@ -508,215 +535,108 @@ func newFilter() *Filter {
 		Category:     binding.NewString(),
 		Manufacturer: binding.NewString(),
 		Model:        binding.NewString(),
+		Status:       binding.NewString(),
 		Width:        binding.NewString(),
 		Height:       binding.NewString(),
 		Depth:        binding.NewString(),
 		Volume:       binding.NewString(),
 		Weight:       binding.NewString(),
 	}
-	f.Category.AddListener(binding.NewDataListener(func() { b.Items.GetItemIDs() }))
-	f.Manufacturer.AddListener(binding.NewDataListener(func() { b.Items.GetItemIDs() }))
-	f.Model.AddListener(binding.NewDataListener(func() { b.Items.GetItemIDs() }))
-	f.Width.AddListener(binding.NewDataListener(func() { b.Items.GetItemIDs() }))
-	f.Height.AddListener(binding.NewDataListener(func() { b.Items.GetItemIDs() }))
-	f.Depth.AddListener(binding.NewDataListener(func() { b.Items.GetItemIDs() }))
-	f.Volume.AddListener(binding.NewDataListener(func() { b.Items.GetItemIDs() }))
-	f.Weight.AddListener(binding.NewDataListener(func() { b.Items.GetItemIDs() }))
+	f.Category.AddListener(binding.NewDataListener(func() { b.Items.QueueItemIDs() }))
+	f.Manufacturer.AddListener(binding.NewDataListener(func() { b.Items.QueueItemIDs() }))
+	f.Model.AddListener(binding.NewDataListener(func() { b.Items.QueueItemIDs() }))
+	f.Status.AddListener(binding.NewDataListener(func() { b.Items.QueueItemIDs() }))
+	f.Width.AddListener(binding.NewDataListener(func() { b.Items.QueueItemIDs() }))
+	f.Height.AddListener(binding.NewDataListener(func() { b.Items.QueueItemIDs() }))
+	f.Depth.AddListener(binding.NewDataListener(func() { b.Items.QueueItemIDs() }))
+	f.Volume.AddListener(binding.NewDataListener(func() { b.Items.QueueItemIDs() }))
+	f.Weight.AddListener(binding.NewDataListener(func() { b.Items.QueueItemIDs() }))
 	return f
 }
 
-func (f Filter) complex() filterComplex {
-	c := filterComplex{}
-	if s, _ := f.Category.Get(); s != "" {
-		c.CatID, _ = CatIDFor(s)
-	}
-	if s, _ := f.Manufacturer.Get(); s != "" {
+/* The filter fields as typed, see Filter */
+type FilterValues struct {
+	Category, Manufacturer, Model, Status string
+	Width, Height, Depth, Volume, Weight  string
+}
+
+func (f Filter) Values() FilterValues {
+	var v FilterValues
+	v.Category, _ = f.Category.Get()
+	v.Manufacturer, _ = f.Manufacturer.Get()
+	v.Model, _ = f.Model.Get()
+	v.Status, _ = f.Status.Get()
+	v.Width, _ = f.Width.Get()
+	v.Height, _ = f.Height.Get()
+	v.Depth, _ = f.Depth.Get()
+	v.Volume, _ = f.Volume.Get()
+	v.Weight, _ = f.Weight.Get()
+	return v
+}
+
+func (f Filter) SetValues(v FilterValues) {
+	f.Category.Set(v.Category)
+	f.Manufacturer.Set(v.Manufacturer)
+	f.Model.Set(v.Model)
+	f.Status.Set(v.Status)
+	f.Width.Set(v.Width)
+	f.Height.Set(v.Height)
+	f.Depth.Set(v.Depth)
+	f.Volume.Set(v.Volume)
+	f.Weight.Set(v.Weight)
+}
+
+func (f Filter) complex() (domain.Filter, error) {
+	return f.Values().complex()
+}
+
+/* Returns the filter v stands for, the measurements are ranges as in the query language and work without a model */
+func (v FilterValues) complex() (domain.Filter, error) {
+	c := domain.Filter{}
+	c.Category = v.Category
+	if s := v.Manufacturer; s != "" {
 		if id, err := MfrIDFor(s); id != 0 && err == nil {
-			c.MfrID = id
+			c.MfrID = int(id)
 		} else {
 			c.Manufacturer = s
 		}
 	}
-	if s, _ := f.Model.Get(); s != "" {
+	if s := v.Model; s != "" {
+		c.Model = s
 		if c.MfrID != 0 {
-			if id, err := ModelIDFor(c.MfrID, s); id != 0 && err == nil {
-				c.ModelID = id
-			} else {
-				c.Model = s
-			}
-		}
-		if s, _ := f.Width.Get(); s != "" {
-			if strings.Contains(s, "-") {
-				t := strings.Split(s, "-")
-				min, err := strconv.ParseFloat(t[0], 64)
-				if err != nil {
-					log.Println(err)
-				} else {
-					c.MinWidth = min
-				}
-				max, err := strconv.ParseFloat(t[1], 64)
-				if err != nil {
-					log.Println(err)
-				} else {
-					c.MaxWidth = max
-				}
-			} else {
-				w, err := strconv.ParseFloat(s, 64)
-				if err != nil {
-					log.Println(err)
-				} else {
-					c.MinWidth = w
-					c.MaxWidth = w
-				}
-			}
-		}
-		if s, _ := f.Height.Get(); s != "" {
-			if strings.Contains(s, "-") {
-				t := strings.Split(s, "-")
-				min, err := strconv.ParseFloat(t[0], 64)
-				if err != nil {
-					log.Println(err)
-				} else {
-					c.MinHeight = min
-				}
-				max, err := strconv.ParseFloat(t[1], 64)
-				if err != nil {
-					log.Println(err)
-				} else {
-					c.MaxHeight = max
-				}
-			} else {
-				w, err := strconv.ParseFloat(s, 64)
-				if err != nil {
-					log.Println(err)
-				} else {
-					c.MinHeight = w
-					c.MaxHeight = w
-				}
+			if id, err := ModelIDFor(MfrID(c.MfrID), s); id != 0 && err == nil {
+				c.ModelID = int(id)
 			}
 		}
-		if s, _ := f.Depth.Get(); s != "" {
-			if strings.Contains(s, "-") {
-				t := strings.Split(s, "-")
-				min, err := strconv.ParseFloat(t[0], 64)
-				if err != nil {
-					log.Println(err)
-				} else {
-					c.MinDepth = min
-				}
-				max, err := strconv.ParseFloat(t[1], 64)
-				if err != nil {
-					log.Println(err)
-				} else {
-					c.MaxDepth = max
-				}
-			} else {
-				w, err := strconv.ParseFloat(s, 64)
-				if err != nil {
-					log.Println(err)
-				} else {
-					c.MinDepth = w
-					c.MaxDepth = w
-				}
-			}
+	}
+	if s := strings.TrimSpace(v.Status); s != "" {
+		id, ok := domain.QueryStatuses[strings.ToLower(s)]
+		if !ok {
+			return c, &domain.QueryError{Token: s, Reason: "status"}
 		}
-		if s, _ := f.Volume.Get(); s != "" {
-			if strings.Contains(s, "-") {
-				t := strings.Split(s, "-")
-				min, err := strconv.ParseFloat(t[0], 64)
-				if err != nil {
-					log.Println(err)
-				} else {
-					c.MinVolume = min
-				}
-				max, err := strconv.ParseFloat(t[1], 64)
-				if err != nil {
-					log.Println(err)
-				} else {
-					c.MaxVolume = max
-				}
-			} else {
-				w, err := strconv.ParseFloat(s, 64)
-				if err != nil {
-					log.Println(err)
-				} else {
-					c.MinVolume = w
-					c.MaxVolume = w
-				}
-			}
+		c.ItemStatusID = id
+	}
+	for _, m := range []struct {
+		s     string
+		r     *domain.Range
+		units []string
+	}{
+		{v.Width, &c.Width, domain.LengthUnits},
+		{v.Height, &c.Height, domain.LengthUnits},
+		{v.Depth, &c.Depth, domain.LengthUnits},
+		{v.Volume, &c.Volume, domain.VolumeUnits},
+		{v.Weight, &c.Weight, domain.WeightUnits},
+	} {
+		if strings.TrimSpace(m.s) == "" {
+			continue
 		}
-		if s, _ := f.Weight.Get(); s != "" {
-			if strings.Contains(s, "-") {
-				t := strings.Split(s, "-")
-				min, err := strconv.ParseFloat(t[0], 64)
-				if err != nil {
-					log.Println(err)
-				} else {
-					c.MinWeight = min
-				}
-				max, err := strconv.ParseFloat(t[1], 64)
-				if err != nil {
-					log.Println(err)
-				} else {
-					c.MaxWeight = max
-				}
-			} else {
-				w, err := strconv.ParseFloat(s, 64)
-				if err != nil {
-					log.Println(err)
-				} else {
-					c.MinWeight = w
-					c.MaxWeight = w
-				}
-			}
+		r, err := domain.ParseRange(m.s, m.units)
+		if err != nil {
+			return c, err
 		}
+		*m.r = r
 	}
-	return c
-}
-
-type filterComplex struct {
-	CatID                CatID
-	MfrID                MfrID
-	ModelID              ModelID
-	Manufacturer         string
-	Model                string
-	MinWidth, MaxWidth   float64
-	MinHeight, MaxHeight float64
-	MinDepth, MaxDepth   float64
-	MinVolume, MaxVolume float64
-	MinWeight, MaxWeight float64
-}
-
-func (f filterComplex) addFilterStrings(query string) string {
-	if f.CatID != 0 {
-		query += fmt.Sprintf("AND CatID = %d ", f.CatID)
-	}
-	if f.MfrID != 0 {
-		query += fmt.Sprintf("AND MfrID = %d ", f.MfrID)
-	} else if f.Manufacturer != "" {
-		query += fmt.Sprintf("AND Model = '%s' ", f.Model)
-	}
-	if f.ModelID != 0 {
-		query += fmt.Sprintf("AND ModelID = %d ", f.ModelID)
-	} else if f.Model != "" {
-		query += fmt.Sprintf("AND Manufacturer = '%s' ", f.Manufacturer)
-	}
-	if f.MinWidth != 0 || f.MaxWidth != 0 {
-		query += fmt.Sprintf("AND Width BETWEEN %f AND %f ", f.MinWidth, f.MaxWidth)
-	}
-	if f.MinHeight != 0 || f.MaxHeight != 0 {
-		query += fmt.Sprintf("AND Height BETWEEN %f AND %f ", f.MinHeight, f.MaxHeight)
-	}
-	if f.MinDepth != 0 || f.MaxDepth != 0 {
-		query += fmt.Sprintf("AND Depth BETWEEN %f AND %f ", f.MinDepth, f.MaxDepth)
-	}
-	if f.MinVolume != 0 || f.MaxVolume != 0 {
-		query += fmt.Sprintf("AND Volume BETWEEN %f AND %f ", f.MinVolume, f.MaxVolume)
-	}
-	if f.MinWeight != 0 || f.MaxWeight != 0 {
-		query += fmt.Sprintf("AND Weight BETWEEN %f AND %f ", f.MinWeight, f.MaxWeight)
-	}
-	return query
+	return c, nil
 }
 
 type Item struct {
@@ -765,6 +685,7 @@ type Item struct {
 	VolumeUnit   binding.String
 	WeightUnit   binding.String
 	ItemStatus   binding.String
+	StatusInfo   binding.String
 	DateCreated  binding.String
 	DateModified binding.String
 }
@@ -808,6 +729,7 @@ func newItem(id ItemID) *Item {
 	t.VolumeUnit = binding.NewString()
 	t.WeightUnit = binding.NewString()
 	t.ItemStatus = binding.NewString()
+	t.StatusInfo = binding.NewString()
 
 	t.DateCreated = binding.NewString()
 	t.DateModified = binding.NewString()
@@ -823,7 +745,7 @@ func newItem(id ItemID) *Item {
 	t.VolumeString = binding.FloatToStringWithFormat(t.volumeFloat, "%.2f")
 	t.WeightString = binding.FloatToStringWithFormat(t.weightFloat, "%.2f")
 
-	t.Name.AddListener(binding.NewDataListener(func() { t.ItemID.SetName(); b.Items.GetItemIDs(); t.ItemID.CompileLongDesc() }))
+	t.Name.AddListener(binding.NewDataListener(func() { t.ItemID.SetName(); b.Items.QueueItemIDs(); t.ItemID.CompileLongDesc() }))
 	t.Category.AddListener(binding.NewDataListener(func() { t.ItemID.SetCategory(); t.ItemID.CompileLongDesc() }))
 	t.priceFloat.AddListener(binding.NewDataListener(func() { t.ItemID.SetPrice(); t.ItemID.CompileLongDesc() }))
 	t.Currency.AddListener(binding.NewDataListener(func() { t.ItemID.SetCurrency(); t.ItemID.CompileLongDesc() }))
@@ -895,207 +817,64 @@ func (t *Item) StringBindings() map[string]binding.String {
 	m["VolumeUnit"] = t.VolumeUnit
 	m["WeightUnit"] = t.WeightUnit
 	m["ItemStatus"] = t.ItemStatus
+	m["StatusInfo"] = t.StatusInfo
 	m["DateCreated"] = t.DateCreated
 	m["DateModified"] = t.DateModified
 	return m
 }
 
 func (t *Item) FetchAllFields() error {
-	var Name, Currency, Unit, ImgURL1, ImgURL2, ImgURL3, ImgURL4, ImgURL5, SpecsURL sql.NullString
-	var AddDesc, LongDesc, Manufacturer, ModelName, ModelDesc, ModelURL, Notes, DateCreated, DateModified sql.NullString
-	var Price, QuantityInPrice, Vat, Stock, Width, Height, Depth, Volume, Weight sql.NullFloat64
-	var Priority sql.NullBool
-	var CatID CatID
-	var MfrID MfrID
-	var ModelID ModelID
-	var LengthUnitID, VolumeUnitID, WeightUnitID UnitID
-	var ItemStatusID ItemStatusID
-
-	query := `SELECT 
-Name, CatID, Price, Currency, QuantityInPrice, Unit, Vat, 
-Priority, Stock, ImgURL1, ImgURL2, ImgURL3, ImgURL4, ImgURL5, SpecsURL, 
-AddDesc, LongDesc, Manufacturer, MfrID, ModelID, ModelName, ModelDesc, ModelURL, Notes, 
-Width, Height, Depth, Volume, Weight, 
-LengthUnitID, VolumeUnitID, WeightUnitID, 
-ItemStatusID, DateCreated, DateModified 
-FROM Item WHERE ItemID = @0`
-	stmt, err := b.db.Prepare(query)
+	it, err := b.Repository.Item(t.ItemID.Int())
 	if err != nil {
 		return fmt.Errorf("get all item fields error: %w", err)
 	}
-	defer stmt.Close()
-	err = stmt.QueryRow(t.ItemID).Scan(
-		&Name, &CatID, &Price, &Currency, &QuantityInPrice, &Unit, &Vat,
-		&Priority, &Stock, &ImgURL1, &ImgURL2, &ImgURL3, &ImgURL4, &ImgURL5, &SpecsURL,
-		&AddDesc, &LongDesc, &Manufacturer, &MfrID, &ModelID, &ModelName, &ModelDesc, &ModelURL, &Notes,
-		&Width, &Height, &Depth, &Volume, &Weight,
-		&LengthUnitID, &VolumeUnitID, &WeightUnitID,
-		&ItemStatusID, &DateCreated, &DateModified,
-	)
-	if err != nil {
-		return fmt.Errorf("get all item fields error: %w", err)
-	}
-
-	var category, manufacturer, model, modelUrl, modelDesc string
 
-	category, err = CatID.Name()
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return fmt.Errorf("get all item fields error: %w", err)
-	}
-
-	manufacturer = Manufacturer.String
-	model = ModelName.String
-	modelUrl = ModelURL.String
-	modelDesc = ModelDesc.String
-
-	width := Width.Float64
-	height := Height.Float64
-	depth := Depth.Float64
-	volume := Volume.Float64
-	weight := Weight.Float64
-
-	LengthUnitString, err := LengthUnitID.Name()
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return fmt.Errorf("get all item fields error: %w", err)
-	}
-	VolumeUnitString, err := VolumeUnitID.Name()
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return fmt.Errorf("get all item fields error: %w", err)
-	}
-	WeightUnitString, err := WeightUnitID.Name()
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return fmt.Errorf("get all item fields error: %w", err)
-	}
-	ItemStatusString := ItemStatusID.LString()
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return fmt.Errorf("get all item fields error: %w", err)
-	}
-
-	if MfrID != 0 {
-		if n, _ := MfrID.Name(); manufacturer == "" && n != manufacturer {
-			// log.Printf("MfrID is set to %d", MfrID)
-			manufacturer = n
-		}
-	} else {
-		// log.Printf("MfrID is unset")
-	}
-
-	if ModelID != 0 {
-		// log.Printf("ModelID is set to %d", ModelID)
-		if n, _ := ModelID.Name(); model == "" && n != model {
-			model = n
-		}
-		modelCatID, err := ModelID.CatID()
-		if err != nil && !errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf("get all item fields error: %w", err)
-		}
-		if modelCategory, _ := modelCatID.Name(); category == "" && modelCategory != category {
-			category = modelCategory
-		}
-		modelMfrID, err := ModelID.MfrID()
-		if err != nil && !errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf("get all item fields error: %w", err)
-		}
-		if t.MfrID == 0 && modelMfrID != t.MfrID {
-			t.MfrID = modelMfrID
-		}
-		if n, _ := modelMfrID.Name(); manufacturer == "" && n != manufacturer {
-			manufacturer = n
-		}
-		if u, _ := ModelID.ModelURL(); modelUrl == "" && u != modelUrl {
-			modelUrl = u
-		}
-		if d, _ := ModelID.Desc(); modelDesc == "" && d != modelDesc {
-			modelDesc = d
-		}
-		if weight == 0 && height == 0 && depth == 0 {
-			if w, _ := ModelID.Weight(); w != weight {
-				weight = w
-			}
-			if h, _ := ModelID.Height(); h != height {
-				height = h
-			}
-			if d, _ := ModelID.Depth(); d != depth {
-				depth = d
-			}
-			if u, _ := ModelID.LengthUnitID(); u != LengthUnitID {
-				LengthUnitID = u
-			}
-		}
-		if volume == 0 {
-			if v, _ := ModelID.Volume(); v != volume {
-				volume = v
-			}
-			if u, _ := ModelID.VolumeUnitID(); u != VolumeUnitID {
-				VolumeUnitID = u
-			}
-		}
-		if weight == 0 {
-			if w, _ := ModelID.Weight(); w != weight {
-				weight = w
-			}
-			if u, _ := ModelID.WeightUnitID(); u != WeightUnitID {
-				WeightUnitID = u
-			}
-		}
-	} else {
-		// log.Printf("ModelID is unset")
-	}
+	t.CatID = CatID(it.CatID)
+	t.MfrID = MfrID(it.MfrID)
+	t.ModelID = ModelID(it.ModelID)
 
 	t.ItemIDString.Set(t.ItemID.String())
-	t.Name.Set(Name.String)
-	t.Category.Set(category)
-	t.priceFloat.Set(Price.Float64)
-	t.Currency.Set(Currency.String)
-	t.Unit.Set(Unit.String)
-	t.vatFloat.Set(Vat.Float64)
-	t.Priority.Set(Priority.Bool)
-	t.stockFloat.Set(Stock.Float64)
-	t.ImgURL1.Set(ImgURL1.String)
-	t.ImgURL2.Set(ImgURL2.String)
-	t.ImgURL3.Set(ImgURL3.String)
-	t.ImgURL4.Set(ImgURL4.String)
-	t.ImgURL5.Set(ImgURL5.String)
-	t.SpecsURL.Set(SpecsURL.String)
-	t.AddDesc.Set(AddDesc.String)
-	t.LongDesc.Set(LongDesc.String)
-	t.Manufacturer.Set(manufacturer)
-	t.ModelName.Set(model)
-	t.ModelDesc.Set(modelDesc)
-	t.ModelURL.Set(modelUrl)
-	t.Notes.Set(Notes.String)
-	t.widthFloat.Set(width)
-	t.heightFloat.Set(height)
-	t.depthFloat.Set(depth)
-	t.volumeFloat.Set(volume)
-	t.weightFloat.Set(weight)
-	t.LengthUnit.Set(LengthUnitString)
-	t.VolumeUnit.Set(VolumeUnitString)
-	t.WeightUnit.Set(WeightUnitString)
-	t.ItemStatus.Set(ItemStatusString)
-
-	var created, modified time.Time
+	t.Name.Set(it.Name)
+	t.Category.Set(it.Category)
+	t.priceFloat.Set(it.Price)
+	t.Currency.Set(it.Currency)
+	t.Unit.Set(it.Unit)
+	t.vatFloat.Set(it.Vat)
+	t.Priority.Set(it.Priority)
+	t.stockFloat.Set(it.Stock)
+	t.ImgURL1.Set(it.ImgURL1)
+	t.ImgURL2.Set(it.ImgURL2)
+	t.ImgURL3.Set(it.ImgURL3)
+	t.ImgURL4.Set(it.ImgURL4)
+	t.ImgURL5.Set(it.ImgURL5)
+	t.SpecsURL.Set(it.SpecsURL)
+	t.AddDesc.Set(it.AddDesc)
+	t.LongDesc.Set(it.LongDesc)
+	t.Manufacturer.Set(it.Manufacturer)
+	t.ModelName.Set(it.ModelName)
+	t.ModelDesc.Set(it.ModelDesc)
+	t.ModelURL.Set(it.ModelURL)
+	t.Notes.Set(it.Notes)
+	t.widthFloat.Set(it.Width)
+	t.heightFloat.Set(it.Height)
+	t.depthFloat.Set(it.Depth)
+	t.volumeFloat.Set(it.Volume)
+	t.weightFloat.Set(it.Weight)
+	t.LengthUnit.Set(it.LengthUnit)
+	t.VolumeUnit.Set(it.VolumeUnit)
+	t.WeightUnit.Set(it.WeightUnit)
+	t.ItemStatus.Set(ItemStatusID(it.ItemStatusID).LString())
+	t.StatusInfo.Set(t.ItemID.StatusInfo())
+
 	stockholm, err := time.LoadLocation("Europe/Stockholm")
 	if err != nil {
 		log.Printf("time.LoadLocation error: %s", err)
 	}
-	if DateCreated.Valid {
-		utc, err := time.Parse(subsec, DateCreated.String)
-		created = utc.In(stockholm)
-		if err != nil {
-			t.DateCreated.Set(fmt.Sprintf("error parsing DateCreated: %v", err))
-		} else {
-			t.DateCreated.Set(created.Format(time.DateTime))
-		}
+	if !it.DateCreated.IsZero() {
+		t.DateCreated.Set(it.DateCreated.In(stockholm).Format(time.DateTime))
 	}
-	if DateModified.Valid {
-		utc, err := time.Parse(subsec, DateCreated.String)
-		modified = utc.In(stockholm)
-		if err != nil {
-			t.DateModified.Set(fmt.Sprintf("error parsing DateModified: %v", err))
-		} else {
-			t.DateModified.Set(modified.Format(time.DateTime))
-		}
+	if !it.DateModified.IsZero() {
+		t.DateModified.Set(it.DateModified.In(stockholm).Format(time.DateTime))
 	}
 	return nil
 }
@@ -1,6 +1,7 @@
 package backend
 
 import (
+	"UppSpar/backend/domain"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
@@ -9,6 +10,7 @@ import (
 	"math"
 	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -237,6 +239,36 @@ func (id ItemID) DateModified() (t time.Time, err error) {
 	return
 }
 
+/* Returns the search words of the item in alphabetical order */
+func (id ItemID) SearchWords() (words []string, err error) {
+	query := `SELECT v.WordString FROM SearchWords_Vocabulary v 
+JOIN SearchWords_Association a ON a.WordID = v.WordID 
+WHERE a.ItemID = @0 ORDER BY v.WordString`
+	rows, err := b.db.Query(query, id)
+	if err != nil {
+		return words, fmt.Errorf("ItemID(%d).SearchWords() error: %w", id, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var w sql.NullString
+		rows.Scan(&w)
+		if w.String != "" {
+			words = append(words, w.String)
+		}
+	}
+	return words, rows.Err()
+}
+
+/* Returns the item this item belongs to, or 0 */
+func (id ItemID) ParentItemID() (ItemID, error) {
+	var parent NullInt
+	err := b.db.QueryRow(`SELECT ParentID FROM Item_Parent WHERE ItemID = @0`, id).Scan(&parent)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("ItemID(%d).ParentItemID() error: %w", id, err)
+	}
+	return ItemID(parent.Int), nil
+}
+
 func (id ItemID) getBool(key string) (val bool, err error) {
 	b, err := getValue[sql.NullBool]("Item", id, key)
 	if b.Valid {
@@ -337,8 +369,202 @@ func (id ItemID) CompileLongDesc() error {
 	return id.SetLongDesc()
 }
 
+/* Access by column name, without bindings */
+
+/* Returns the names of all columns in the Item table */
+func ItemFields() ([]string, error) {
+	var fields []string
+	columns, err := itemColumns()
+	for _, column := range columns {
+		fields = append(fields, column[0])
+	}
+	return fields, err
+}
+
+/* Returns name and declared type of every column in the Item table */
+func itemColumns() ([][2]string, error) {
+	var columns [][2]string
+	rows, err := b.db.Query(`SELECT name, upper(type) FROM pragma_table_info('Item')`)
+	if err != nil {
+		return columns, fmt.Errorf("itemColumns() error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var column [2]string
+		rows.Scan(&column[0], &column[1])
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+/* Returns the column name as spelled in the Item table and its type, or ErrInvalidField */
+func itemField(key string) (string, string, error) {
+	columns, err := itemColumns()
+	if err != nil {
+		return "", "", err
+	}
+	for _, column := range columns {
+		if strings.EqualFold(column[0], key) {
+			return column[0], column[1], nil
+		}
+	}
+	return "", "", fmt.Errorf("%s: %w", key, ErrInvalidField)
+}
+
+/* Convert text to a value for a column of type typ, accepting decimal commas and Y/N for booleans */
+func fieldValue(typ string, s string) (any, error) {
+	s = strings.TrimSpace(s)
+	switch typ {
+	case "REAL":
+		f, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", s, ErrInvalidValue)
+		}
+		return f, nil
+	case "INT", "INTEGER":
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", s, ErrInvalidValue)
+		}
+		return i, nil
+	case "BOOL":
+		switch strings.ToLower(s) {
+		case "y", "j", "ja", "yes", "true", "1":
+			return true, nil
+		case "n", "nej", "no", "false", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%q: %w", s, ErrInvalidValue)
+	}
+	return s, nil
+}
+
+/* Returns the value of any column as text, empty if NULL */
+func (id ItemID) GetField(key string) (string, error) {
+	var val string
+	field, _, err := itemField(key)
+	if err != nil {
+		return val, fmt.Errorf("ItemID(%d).GetField(%s) error: %w", id, key, err)
+	}
+	query := `SELECT ifnull(CAST(` + field + ` AS TEXT), '') FROM Item WHERE ItemID = @0`
+	err = b.db.QueryRow(query, id).Scan(&val)
+	if errors.Is(err, sql.ErrNoRows) {
+		return val, fmt.Errorf("ItemID(%d).GetField(%s) error: %w", id, key, ErrNotFound)
+	}
+	if err != nil {
+		return val, fmt.Errorf("ItemID(%d).GetField(%s) error: %w", id, key, err)
+	}
+	return val, nil
+}
+
+/*
+Set any column except ItemID and Stock from text, which must be valid for the type of the column, recording the change
+as an edit in the form does. ItemStatusID is changed as the status dialog changes it.
+*/
+func (id ItemID) SetField(key string, val string) error {
+	field, typ, err := itemField(key)
+	if err != nil {
+		return fmt.Errorf("ItemID(%d).SetField(%s) error: %w", id, key, err)
+	}
+	/* The stock is changed by sales, returns and receipts, which keep the ledger */
+	if field == "ItemID" || field == "Stock" {
+		return fmt.Errorf("ItemID(%d).SetField(%s) error: %w", id, key, ErrInvalidField)
+	}
+	v, err := fieldValue(typ, val)
+	if err != nil {
+		return fmt.Errorf("ItemID(%d).SetField(%s) error: %w", id, key, err)
+	}
+	if _, err := id.GetField(field); err != nil {
+		return err
+	}
+	if field == "ItemStatusID" {
+		to, _ := v.(int)
+		s := StatusChange{To: to}
+		if s.To == int(ItemStatusSold) {
+			price, _ := id.GetField("Price")
+			s.Price, _ = strconv.ParseFloat(price, 64)
+		}
+		return id.ChangeStatus(s)
+	}
+	c, err := update("Item", id.Int(), field, v, editLead)
+	if err != nil {
+		return fmt.Errorf("ItemID(%d).SetField(%s) error: %w", id, key, err)
+	}
+	if c != nil && b.Items != nil {
+		reload(c)
+	}
+	return nil
+}
+
 /* Updating data */
 
+/* Replace the search words of the item, adding new words to the vocabulary */
+func (id ItemID) SetSearchWords(words []string) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ItemID(%d).SetSearchWords() error: %w", id, err)
+	}
+	defer tx.Rollback()
+	if err := id.setSearchWords(tx, words); err != nil {
+		return fmt.Errorf("ItemID(%d).SetSearchWords() error: %w", id, err)
+	}
+	return tx.Commit()
+}
+
+func (id ItemID) setSearchWords(tx *sql.Tx, words []string) error {
+	if _, err := tx.Exec(`DELETE FROM SearchWords_Association WHERE ItemID = @0`, id); err != nil {
+		return err
+	}
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w == "" {
+			continue
+		}
+		var wid NullInt
+		err := tx.QueryRow(`SELECT WordID FROM SearchWords_Vocabulary WHERE WordString = @0`, w).Scan(&wid)
+		if errors.Is(err, sql.ErrNoRows) {
+			res, err := tx.Exec(`INSERT INTO SearchWords_Vocabulary (WordString) VALUES (@0)`, w)
+			if err != nil {
+				return err
+			}
+			i, _ := res.LastInsertId()
+			wid = NullInt{Int: int(i), Valid: true}
+		} else if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO SearchWords_Association (ItemID, WordID) VALUES (@0, @1)`, id, wid.Int); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* Make the item belong to parent, or to no item if parent is 0 */
+func (id ItemID) SetParentItemID(parent ItemID) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ItemID(%d).SetParentItemID(%d) error: %w", id, parent, err)
+	}
+	defer tx.Rollback()
+	if err := id.setParentItemID(tx, parent); err != nil {
+		return fmt.Errorf("ItemID(%d).SetParentItemID(%d) error: %w", id, parent, err)
+	}
+	return tx.Commit()
+}
+
+func (id ItemID) setParentItemID(tx *sql.Tx, parent ItemID) error {
+	var err error
+	switch {
+	case parent == id:
+		return ErrInvalidValue
+	case parent == 0:
+		_, err = tx.Exec(`DELETE FROM Item_Parent WHERE ItemID = @0`, id)
+	default:
+		_, err = tx.Exec(`INSERT INTO Item_Parent (ItemID, ParentID) VALUES (@0, @1) 
+ON CONFLICT(ItemID) DO UPDATE SET ParentID = excluded.ParentID`, id, parent)
+	}
+	return err
+}
+
 func (id ItemID) SetName() error {
 	key := "Name"
 	val, err := id.Item().Name.Get()
@@ -652,6 +878,7 @@ func (id ItemID) SetWeightUnitID(w UnitID) error {
 	return id.setInt(key, val)
 }
 
+/* Gives the item the status its ItemStatus string names, if its status can go there, and shows its status again if not */
 func (id ItemID) SetItemStatus() error {
 	str, err := id.Item().ItemStatus.Get()
 	if err != nil {
@@ -673,11 +900,21 @@ func (id ItemID) SetItemStatus() error {
 	}
 }
 
-/* Set ItemStatusID based on contents of ItemID.Item().ItemStatus string */
+/* Gives the item status t without a reason, a sale at the price of the item */
 func (id ItemID) SetItemStatusID(t ItemStatusID) error {
-	key := "ItemStatusID"
-	val := int(t)
-	return id.setInt(key, val)
+	s := StatusChange{To: int(t)}
+	if t == ItemStatusSold {
+		s.Price, _ = id.getFloat("Price")
+	}
+	err := id.ChangeStatus(s)
+	if err != nil {
+		log.Println(err)
+	}
+	if errors.Is(err, domain.ErrInvalidTransition) {
+		current, _ := id.getInt("ItemStatusID")
+		id.Item().ItemStatus.Set(ItemStatusID(current).LString())
+	}
+	return err
 }
 func (id ItemID) updateDateModified() {
 	dm, err := id.DateModified()
@@ -0,0 +1,127 @@
+package journal
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+/* A column of a row in another table with its value before and after an edit */
+type FieldChange struct {
+	Table  string
+	RowID  int
+	Column string
+	Old    any
+	New    any
+}
+
+/* A field change as written to the journal, with the entry telling of it */
+type Change struct {
+	FieldChange
+	EntryID EntryID
+	Time    time.Time
+}
+
+/* Writes an Edit entry with message and the field changes it tells of */
+func (j *Journal) NewChange(message string, fields []FieldChange) EntryID {
+	id := j.newEntry(Message, Edit, message)
+	j.writeFields(id, fields)
+	j.addEntry(id, j.getEntry(id))
+	j.countChange()
+	return id
+}
+
+/* Rewrites the entry id written by NewChange with a new message and field changes, and moves it to now */
+func (j *Journal) UpdateChange(id EntryID, message string, fields []FieldChange) {
+	_, err := j.db.Exec(`UPDATE Journal SET Message = @0, Time = datetime('now', 'subsec') WHERE EntryID = @1`, message, id)
+	if err != nil {
+		panic(err)
+	}
+	j.writeFields(id, fields)
+	if e, ok := j.entries[id]; ok {
+		*e = *j.getEntryFromSQL(id)
+	}
+	j.countChange()
+}
+
+/* Returns the field changes of row id of table, newest first */
+func (j *Journal) History(table string, id int) ([]Change, error) {
+	var changes []Change
+	rows, err := j.db.Query(`SELECT c.EntryID, j.Time, c.TableName, c.RowID, c.ColumnName, c.OldValue, c.NewValue
+FROM Journal_Change c JOIN Journal j ON j.EntryID = c.EntryID
+WHERE c.TableName = @0 AND c.RowID = @1
+ORDER BY j.Time DESC, c.ChangeID DESC`, table, id)
+	if err != nil {
+		return changes, fmt.Errorf("Journal.History(%s, %d) error: %w", table, id, err)
+	}
+	defer rows.Close()
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		return changes, fmt.Errorf("Journal.History(%s, %d) error: %w", table, id, err)
+	}
+	for rows.Next() {
+		var c Change
+		var tim sql.NullString
+		if err := rows.Scan(&c.EntryID, &tim, &c.Table, &c.RowID, &c.Column, &c.Old, &c.New); err != nil {
+			return changes, fmt.Errorf("Journal.History(%s, %d) error: %w", table, id, err)
+		}
+		utc, _ := time.Parse(subsec, tim.String)
+		c.Time = utc.In(stockholm)
+		if b, ok := c.Old.([]byte); ok {
+			c.Old = string(b)
+		}
+		if b, ok := c.New.([]byte); ok {
+			c.New = string(b)
+		}
+		changes = append(changes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return changes, fmt.Errorf("Journal.History(%s, %d) error: %w", table, id, err)
+	}
+	return changes, nil
+}
+
+/* Replaces the field changes of entry id */
+func (j *Journal) writeFields(id EntryID, fields []FieldChange) {
+	tx, err := j.db.Begin()
+	if err != nil {
+		panic(err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM Journal_Change WHERE EntryID = @0`, id); err != nil {
+		panic(err)
+	}
+	for _, f := range fields {
+		_, err := tx.Exec(`INSERT INTO Journal_Change (EntryID, TableName, RowID, ColumnName, OldValue, NewValue)
+VALUES (@0, @1, @2, @3, @4, @5)`, id, f.Table, f.RowID, f.Column, f.Old, f.New)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+}
+
+func (j *Journal) countChange() {
+	if j.Changes == nil {
+		return
+	}
+	n, _ := j.Changes.Get()
+	j.Changes.Set(n + 1)
+}
+
+/* Returns a column value as text, nothing for NULL and numbers without trailing zeros */
+func FormatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
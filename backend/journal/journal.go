@@ -1,7 +1,9 @@
 package journal
 
 import (
+	"UppSpar/backend/schema"
 	"database/sql"
+	"fmt"
 	"slices"
 
 	"fyne.io/fyne/v2/data/binding"
@@ -43,10 +45,11 @@ type Journal struct {
 	config  config
 	entries map[EntryID]*Entry
 
-	List binding.UntypedList
+	List    binding.UntypedList
+	Changes binding.Int // Counts the field changes written or rewritten, for views to reload on
 }
 
-func NewJournal(dc *sql.DB) *Journal {
+func NewJournal(dc *sql.DB) (*Journal, error) {
 	db = dc
 	j := &Journal{
 		db: dc,
@@ -57,12 +60,33 @@ func NewJournal(dc *sql.DB) *Journal {
 		},
 		entries: make(map[EntryID]*Entry),
 		List:    binding.NewUntypedList(),
+		Changes: binding.NewInt(),
 	}
 	// 1. connect and set up/verify tables/schema
-	j.createTables()
+	if err := j.createTables(); err != nil {
+		return nil, err
+	}
 	// 2. get `limit` last entries from database
 	j.List.Set(j.getRecentEntryIds())
-	return j
+	return j, nil
+}
+
+/* A Journal without the entry list binding, for use without a Fyne app */
+func NewHeadlessJournal(dc *sql.DB) (*Journal, error) {
+	db = dc
+	j := &Journal{
+		db: dc,
+		config: config{
+			limit:   100,
+			sorting: Ascending,
+			time:    minute,
+		},
+		entries: make(map[EntryID]*Entry),
+	}
+	if err := j.createTables(); err != nil {
+		return nil, err
+	}
+	return j, nil
 }
 
 func (j *Journal) NewEntry(level Level, event Event, message string) {
@@ -99,6 +123,9 @@ func (j *Journal) GetAllEntryIDs() []any {
 
 func (j *Journal) addEntry(id EntryID, entry *Entry) {
 	j.entries[id] = entry
+	if j.List == nil {
+		return
+	}
 	if j.config.sorting == Ascending {
 		j.List.Append(id)
 	} else {
@@ -119,6 +146,9 @@ func (j *Journal) EntryListItemID(id EntryID) (widget.ListItemID, bool) {
 
 func (j *Journal) Refresh() {
 	// TODO: fix way to reload currently loaded entries
+	if j.List == nil {
+		return
+	}
 	j.List.Set(j.getRecentEntryIds())
 }
 
@@ -130,6 +160,23 @@ func (j *Journal) LastEntryId() EntryID {
 	return id.(EntryID)
 }
 
+/* Returns the n most recent entries, oldest first */
+func (j *Journal) Tail(n int) []*Entry {
+	limit := j.config.limit
+	sorting := j.config.sorting
+	j.config.limit = n
+	j.config.sorting = Ascending
+	ids := j.getRecentEntryIds()
+	j.config.limit = limit
+	j.config.sorting = sorting
+
+	var entries []*Entry
+	for _, id := range ids {
+		entries = append(entries, j.getEntry(id.(EntryID)))
+	}
+	return entries
+}
+
 func (j *Journal) SetEntryLimit(n int) {
 	j.config.limit = n
 }
@@ -155,3 +202,36 @@ func (j *Journal) SortDescending() {
 	j.config.sorting = Descending
 	j.Refresh()
 }
+
+/* Verify the journal tables and optionally repair them, use LogSchemaReport to record the outcome */
+func (j *Journal) CheckTables(repair bool) (*schema.Report, error) {
+	report, err := j.verifyTables()
+	if err != nil {
+		return nil, fmt.Errorf("Journal.CheckTables() error: %w", err)
+	}
+	if repair {
+		err = j.repairTables(report)
+	}
+	if err != nil {
+		return report, fmt.Errorf("Journal.CheckTables() error: %w", err)
+	}
+	return report, nil
+}
+
+/* Record every difference in a schema report, and whether it was repaired */
+func (j *Journal) LogSchemaReport(report *schema.Report, repair bool) {
+	if report.OK() {
+		j.NewEntry(Message, SQL, fmt.Sprintf("Databasschemat (%s) stämmer.", report.Component))
+		return
+	}
+	for _, d := range report.Differences {
+		switch {
+		case d.Repaired:
+			j.NewEntry(Message, SQL, fmt.Sprintf("Databasschemat (%s), åtgärdat: %s.", report.Component, d))
+		case repair:
+			j.NewEntry(Error, SQL, fmt.Sprintf("Databasschemat (%s), kan inte åtgärdas: %s.", report.Component, d))
+		default:
+			j.NewEntry(Warning, SQL, fmt.Sprintf("Databasschemat (%s): %s.", report.Component, d))
+		}
+	}
+}
@@ -1,59 +1,80 @@
 package journal
 
 import (
+	"UppSpar/backend/schema"
 	"database/sql"
+	"fmt"
 	"reflect"
-	"slices"
 	"time"
 )
 
-func (j *Journal) createMainTable() {
-	j.db.Exec(`CREATE TABLE Journal(
+/* Schema migrations for the journal tables, in order. Never edit a step once released, append a new one. */
+var migrations = []schema.Step{
+	{Version: 1, Name: "baseline", Up: schema.Exec(
+		`CREATE TABLE IF NOT EXISTS Journal(
 EntryID INTEGER PRIMARY KEY AUTOINCREMENT,
 Time TEXT DEFAULT(datetime('now', 'subsec')),
 LevelID INT DEFAULT 1,
 EventID INT DEFAULT 1,
 Message TEXT,
 FOREIGN KEY(LevelID) REFERENCES Journal_EntryLevel(LevelID),
-FOREIGN KEY(EventID) REFERENCES Journal_EntryEvent(EventID))`)
-}
-func (j *Journal) createEntryLevelTable() {
-	j.db.Exec(`CREATE TABLE Journal_EntryLevel(
+FOREIGN KEY(EventID) REFERENCES Journal_EntryEvent(EventID))`,
+		`CREATE TABLE IF NOT EXISTS Journal_EntryLevel(
 LevelID INTEGER PRIMARY KEY AUTOINCREMENT,
-Name TEXT UNIQUE)`)
-	j.db.Exec(`INSERT INTO Journal_EntryLevel 
-VALUES ("Message"), ("Warning"), ("Error")`)
-}
-func (j *Journal) createEntryEventTable() {
-	j.db.Exec(`CREATE TABLE Journal_EntryEvent(
+Name TEXT UNIQUE)`,
+		`INSERT OR IGNORE INTO Journal_EntryLevel (Name)
+VALUES ("Message"), ("Warning"), ("Error")`,
+		`CREATE TABLE IF NOT EXISTS Journal_EntryEvent(
 EventID INTEGER PRIMARY KEY AUTOINCREMENT,
-Name TEXT UNIQUE)`)
-	j.db.Exec(`INSERT INTO Journal_EntryEvent 
-VALUES ("Log"), ("Add"), ("Copy"), ("Edit"), ("Delete"), ("SQL")`)
+Name TEXT UNIQUE)`,
+		`INSERT OR IGNORE INTO Journal_EntryEvent (Name)
+VALUES ("Log"), ("Add"), ("Copy"), ("Edit"), ("Delete"), ("SQL")`,
+	)},
+	{Version: 2, Name: "field changes", Up: schema.Exec(
+		`CREATE TABLE IF NOT EXISTS Journal_Change(
+ChangeID INTEGER PRIMARY KEY AUTOINCREMENT,
+EntryID INT,
+TableName TEXT,
+RowID INT,
+ColumnName TEXT,
+OldValue,
+NewValue,
+FOREIGN KEY(EntryID) REFERENCES Journal(EntryID) ON DELETE CASCADE)`,
+		`CREATE INDEX IF NOT EXISTS Journal_Change_Row ON Journal_Change(TableName, RowID)`,
+	)},
 }
-func (j *Journal) createTables() {
-	tables := j.listTables()
-	touched := false
-	if !slices.Contains(tables, "Journal") {
-		j.createMainTable()
-		touched = true
-	}
-	if !slices.Contains(tables, "Journal_EntryLevel") {
-		j.createEntryLevelTable()
-		touched = true
-	}
-	if !slices.Contains(tables, "Journal_EntryEvent") {
-		j.createEntryEventTable()
-		touched = true
+
+/* Levels and events are referenced by their IDs in Level and Event */
+var seeds = []schema.Seed{
+	{
+		Table:   "Journal_EntryLevel",
+		Columns: []string{"LevelID", "Name"},
+		Rows:    `(1, 'Message'), (2, 'Warning'), (3, 'Error')`,
+	},
+	{
+		Table:   "Journal_EntryEvent",
+		Columns: []string{"EventID", "Name"},
+		Rows:    `(1, 'Log'), (2, 'Add'), (3, 'Copy'), (4, 'Edit'), (5, 'Delete'), (6, 'SQL')`,
+	},
+}
+
+func (j *Journal) createTables() error {
+	empty := len(j.listTables()) == 0
+	m := j.migrator()
+	var applied []schema.Step
+	err := m.Migrate(func(step schema.Step) {
+		applied = append(applied, step)
+	})
+	if err != nil {
+		return fmt.Errorf("Journal.createTables() error: %w", err)
 	}
-	if len(tables) == 0 {
-		j.db.Exec(`INSERT INTO Journal (EventID, Message)
-VALUES ("6", "Databasen tom. Skapar nya tabeller.")`)
+	if empty {
+		j.newEntry(Message, SQL, "Databasen tom. Skapar nya tabeller.")
 	}
-	if touched {
-		j.db.Exec(`INSERT INTO Journal (EventID, Message)
-VALUES ("6", "Skapade nya tabeller för Journalen i databasen.")`)
+	for _, step := range applied {
+		j.newEntry(Message, SQL, fmt.Sprintf("Uppdaterade Journalens tabeller till version %d (%s).", step.Version, step.Name))
 	}
+	return nil
 }
 
 func (j *Journal) listTables() []string {
@@ -75,8 +96,15 @@ func (j *Journal) listTables() []string {
 	}
 	return tables
 }
-func (j *Journal) verifyTables() bool { panic("unimplemented") }
-func (j *Journal) repairTables() bool { panic("unimplemented") }
+func (j *Journal) migrator() *schema.Migrator {
+	return schema.NewMigrator(j.db, "journal", migrations).WithSeeds(seeds...)
+}
+func (j *Journal) verifyTables() (*schema.Report, error) {
+	return j.migrator().Verify()
+}
+func (j *Journal) repairTables(report *schema.Report) error {
+	return j.migrator().Repair(report)
+}
 
 func (j *Journal) getAllEntryIDs() []any {
 	var id EntryID
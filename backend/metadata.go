@@ -11,7 +11,6 @@ import (
 	"strings"
 
 	"fyne.io/fyne/v2/data/binding"
-	"fyne.io/fyne/v2/widget"
 )
 
 type Metadata struct {
@@ -181,7 +180,7 @@ func (m *Metadata) DeleteManufacturer(id MfrID) error {
 func (m *Metadata) DeleteProduct(id ModelID) error {
 	return nil
 }
-func (m *Metadata) GetCatIDForListItem(index widget.ListItemID) CatID {
+func (m *Metadata) GetCatIDForListItem(index int) CatID {
 	id, err := m.CatIDList.GetValue(index)
 	if err != nil {
 		log.Printf("Metadata.GetCatIDFor(%d) error: %s", index, err)
@@ -189,7 +188,7 @@ func (m *Metadata) GetCatIDForListItem(index widget.ListItemID) CatID {
 	}
 	return id.(CatID)
 }
-func (m *Metadata) GetListItemIDForCategory(s string) widget.ListItemID {
+func (m *Metadata) GetListItemIDForCategory(s string) int {
 	cats, err := m.Categories.Get()
 	if err != nil {
 		log.Printf("Metadata.GetListItemIDFor(%s) error: %s", s, err)
@@ -200,7 +199,7 @@ func (m *Metadata) GetListItemIDForCategory(s string) widget.ListItemID {
 	})
 	return index
 }
-func (m *Metadata) GetProductIDFor(index widget.TreeNodeID) (id NumID, isMfr bool) {
+func (m *Metadata) GetProductIDFor(index string) (id NumID, isMfr bool) {
 	s, err := m.ProductTree.GetValue(index)
 	if err != nil {
 		log.Printf("Metadata.GetProductIDFor(%s) error: %s", index, err)
@@ -214,7 +213,7 @@ func (m *Metadata) GetProductIDFor(index widget.TreeNodeID) (id NumID, isMfr boo
 	}
 	return id, isMfr
 }
-func (m *Metadata) GetCatIDForTreeItem(index widget.TreeNodeID) CatID {
+func (m *Metadata) GetCatIDForTreeItem(index string) CatID {
 	id, err := m.CatIDTree.GetValue(index)
 	if err != nil {
 		log.Printf("Metadata.GetCatIDForTreeItem(%s) error: %s", index, err)
@@ -230,6 +229,9 @@ func (m *Metadata) ItemStatusList() []string {
 	var strs []string
 	stats, _ := m.ItemStatusIDList.Get()
 	for _, stat := range stats {
+		if stat.(ItemStatusID) == ItemStatusDeleted {
+			continue // items are deleted to and restored from the trash
+		}
 		strs = append(strs, stat.(ItemStatusID).LString())
 	}
 	return strs
@@ -19,11 +19,13 @@ func newMfr(id MfrID) *Manufacturer {
 		Name:  binding.NewString(),
 	}
 
-	name, err := mfr.MfrID.Name()
+	r, err := b.Repository.Manufacturer(mfr.MfrID.Int())
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		panic(err)
 	}
-	mfr.Name.Set(name)
+	if r != nil {
+		mfr.Name.Set(r.Name)
+	}
 	mfr.Name.AddListener(binding.NewDataListener(func() { mfr.MfrID.SetName(); b.Metadata.GetMfrIDs(); b.Metadata.GetProductTree() }))
 	return mfr
 }
@@ -23,6 +23,9 @@ type MfrID int
 func (id MfrID) String() string {
 	return fmt.Sprintf("%d", id)
 }
+func (id MfrID) Int() int {
+	return int(id)
+}
 
 /* Value implements driver.Valuer. */
 func (id MfrID) Value() (driver.Value, error) {
@@ -1,8 +1,10 @@
 package backend
 
 import (
+	"UppSpar/backend/domain"
 	"database/sql"
 	"errors"
+	"fmt"
 
 	"fyne.io/fyne/v2/data/binding"
 )
@@ -70,57 +72,10 @@ func newModel(id ModelID) *Model {
 		WeightUnit:   binding.NewString(),
 	}
 
-	var Name, Desc, ImgURL1, ImgURL2, ImgURL3, ImgURL4, ImgURL5, SpecsURL, ModelURL, Manufacturer sql.NullString
-	var Width, Height, Depth, Volume, Weight sql.NullFloat64
-	var CatID CatID
-	var MfrID MfrID
-	var LengthUnitID, VolumeUnitID, WeightUnitID UnitID
-
-	query := `SELECT Name, Manufacturer, MfrID, Desc, ImgURL1, ImgURL2, ImgURL3, ImgURL4, ImgURL5, SpecsURL, ModelURL, 
-Width, Height, Depth, Volume, Weight, LengthUnitID, VolumeUnitID, WeightUnitID, CatID
-FROM Model WHERE ModelID = @0`
-	err := b.db.QueryRow(query, mdl.ModelID).Scan(
-		&Name, &Manufacturer, &MfrID, &Desc, &ImgURL1, &ImgURL2, &ImgURL3, &ImgURL4, &ImgURL5, &SpecsURL, &ModelURL,
-		&Width, &Height, &Depth, &Volume, &Weight, &LengthUnitID, &VolumeUnitID, &WeightUnitID, &CatID,
-	)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+	if err := mdl.fetch(); err != nil {
 		panic(err)
 	}
 
-	Category, err := CatID.Name()
-	manufacturer := Manufacturer.String
-	LengthUnit, err := LengthUnitID.Name()
-	VolumeUnit, err := VolumeUnitID.Name()
-	WeightUnit, err := WeightUnitID.Name()
-
-	if MfrID != 0 {
-		if n, _ := MfrID.Name(); n != manufacturer {
-			manufacturer = n
-		}
-	}
-
-	mdl.Name.Set(Name.String)
-	mdl.Category.Set(Category)
-	mdl.CatID = CatID
-	mdl.MfrID = MfrID
-	mdl.Manufacturer.Set(manufacturer)
-	mdl.Desc.Set(Desc.String)
-	mdl.ImgURL1.Set(ImgURL1.String)
-	mdl.ImgURL2.Set(ImgURL2.String)
-	mdl.ImgURL3.Set(ImgURL3.String)
-	mdl.ImgURL4.Set(ImgURL4.String)
-	mdl.ImgURL5.Set(ImgURL5.String)
-	mdl.SpecsURL.Set(SpecsURL.String)
-	mdl.ModelURL.Set(ModelURL.String)
-	mdl.widthFloat.Set(Width.Float64)
-	mdl.heightFloat.Set(Height.Float64)
-	mdl.depthFloat.Set(Depth.Float64)
-	mdl.volumeFloat.Set(Volume.Float64)
-	mdl.weightFloat.Set(Weight.Float64)
-	mdl.LengthUnit.Set(LengthUnit)
-	mdl.VolumeUnit.Set(VolumeUnit)
-	mdl.WeightUnit.Set(WeightUnit)
-
 	mdl.Width = binding.FloatToStringWithFormat(mdl.widthFloat, "%.2f")
 	mdl.Height = binding.FloatToStringWithFormat(mdl.heightFloat, "%.2f")
 	mdl.Depth = binding.FloatToStringWithFormat(mdl.depthFloat, "%.2f")
@@ -150,6 +105,40 @@ FROM Model WHERE ModelID = @0`
 	return mdl
 }
 
+/* Sets the bindings to the values of the model in the database */
+func (mdl *Model) fetch() error {
+	o, err := b.Repository.Model(mdl.ModelID.Int())
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("Model.fetch() error: %w", err)
+	}
+	if o == nil {
+		o = &domain.Model{}
+	}
+
+	mdl.Name.Set(o.Name)
+	mdl.Category.Set(o.Category)
+	mdl.CatID = CatID(o.CatID)
+	mdl.MfrID = MfrID(o.MfrID)
+	mdl.Manufacturer.Set(o.Manufacturer)
+	mdl.Desc.Set(o.Desc)
+	mdl.ImgURL1.Set(o.ImgURL1)
+	mdl.ImgURL2.Set(o.ImgURL2)
+	mdl.ImgURL3.Set(o.ImgURL3)
+	mdl.ImgURL4.Set(o.ImgURL4)
+	mdl.ImgURL5.Set(o.ImgURL5)
+	mdl.SpecsURL.Set(o.SpecsURL)
+	mdl.ModelURL.Set(o.ModelURL)
+	mdl.widthFloat.Set(o.Width)
+	mdl.heightFloat.Set(o.Height)
+	mdl.depthFloat.Set(o.Depth)
+	mdl.volumeFloat.Set(o.Volume)
+	mdl.weightFloat.Set(o.Weight)
+	mdl.LengthUnit.Set(o.LengthUnit)
+	mdl.VolumeUnit.Set(o.VolumeUnit)
+	mdl.WeightUnit.Set(o.WeightUnit)
+	return nil
+}
+
 func (o *Model) Bindings() map[string]binding.String {
 	m := make(map[string]binding.String)
 	m["Name"] = o.Name
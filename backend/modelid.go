@@ -22,6 +22,9 @@ type ModelID int
 func (id ModelID) String() string {
 	return fmt.Sprintf("%d", id)
 }
+func (id ModelID) Int() int {
+	return int(id)
+}
 
 /* Returns a tree-friendly identifying string */
 func (id ModelID) TString() string {
@@ -15,6 +15,8 @@ type NumID interface {
 	TypeName() string
 	/* Returns a string with the underlying int */
 	String() string
+	/* Returns the underlying int */
+	Int() int
 	/* Returns a tree-friendly identifier string */
 	TString() string
 
@@ -52,15 +54,8 @@ func getValue[T sql.NullBool | sql.NullFloat64 | NullInt | sql.NullInt64 | sql.N
 
 /* Set value for column 'key' for row 'id' in table 't' to 'val' */
 func setValue[T bool | float64 | int | string](t string, id NumID, key string, val T) (err error) {
-	query := `UPDATE ` + t + ` SET ` + key + ` = @1 WHERE ` + id.TypeName() + ` = @2 AND ` + key + ` <> @3`
 	// log.Printf("UPDATE %s SET %s = %v WHERE %s = %d AND %s <> %v", t, key, val, id.TypeName(), id, key, val)
-	stmt, err := b.db.Prepare(query)
-	if err != nil {
-		log.Printf("setItemIDValue(%d, %s, %v) panic!", id, key, val)
-		panic(err)
-	}
-	defer stmt.Close()
-	_, err = stmt.Exec(val, id, val)
+	_, err = update(t, id.Int(), key, val, editLead)
 	if err != nil {
 		log.Printf("setItemIDValue(%d, %s, %v) panic!", id, key, val)
 		panic(err)
@@ -0,0 +1,491 @@
+package backend
+
+import (
+	"UppSpar/backend/journal"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2/lang"
+)
+
+/* Export profiles describe the layout of an exported sheet. The Proceedo layout is built in, the rest are stored in the ExportProfile tables. */
+
+/* Sources that are not plain Item columns */
+var exportSources = []string{"ItemID", "SearchWords", "SubItemOf"}
+
+/* How the value of a column is written to a cell */
+type CellFormat string
+
+const (
+	CellAuto    CellFormat = ""        // By column type, booleans as Y/N and zero or empty optional values blank
+	CellText    CellFormat = "text"    // Always text
+	CellNumber  CellFormat = "number"  // Decimal number
+	CellInteger CellFormat = "integer" // Rounded to a whole number
+	CellDecimal CellFormat = "decimal" // Rounded to two decimals
+	CellYesNo   CellFormat = "yn"      // Y or N
+	CellJaNej   CellFormat = "janej"   // Ja or Nej
+	CellOneZero CellFormat = "10"      // 1 or 0
+)
+
+var CellFormats = []CellFormat{CellAuto, CellText, CellNumber, CellInteger, CellDecimal, CellYesNo, CellJaNej, CellOneZero}
+
+/* Returns a localized string */
+func (f CellFormat) LString() string {
+	return lang.X("profile.format."+f.String(), "profile.format."+f.String())
+}
+
+func (f CellFormat) String() string {
+	if f == CellAuto {
+		return "auto"
+	}
+	return string(f)
+}
+
+/* Returns the CellFormat named s, as returned by CellFormat.String() */
+func CellFormatFor(s string) (CellFormat, error) {
+	for _, f := range CellFormats {
+		if f.String() == s {
+			return f, nil
+		}
+	}
+	return CellAuto, fmt.Errorf("CellFormatFor(%s) error: %w", s, ErrInvalidValue)
+}
+
+/* Format a value read from a column of type typ. Headers ending with * mark mandatory columns, which CellAuto never leaves blank. */
+func (f CellFormat) value(typ string, val any, mandatory bool) any {
+	switch f {
+	case CellText:
+		if typ == "ITEMID" {
+			if i := intValue(val); i != 0 {
+				return ItemID(i).String()
+			}
+			return nil
+		}
+		s := strings.TrimSpace(textValue(val))
+		if s == "" {
+			return nil
+		}
+		return s
+	case CellNumber:
+		return floatValue(val)
+	case CellInteger:
+		return int64(math.Round(floatValue(val)))
+	case CellDecimal:
+		return math.Round(floatValue(val)*100) / 100
+	case CellYesNo, CellJaNej, CellOneZero:
+		yes := intValue(val) != 0
+		switch {
+		case f == CellOneZero && yes:
+			return 1
+		case f == CellOneZero:
+			return 0
+		case f == CellJaNej && yes:
+			return "Ja"
+		case f == CellJaNej:
+			return "Nej"
+		case yes:
+			return "Y"
+		default:
+			return "N"
+		}
+	default:
+		return exportValue(typ, val, mandatory)
+	}
+}
+
+/* One column of an export profile. Source is an Item column, one of ItemID, SearchWords or SubItemOf, or = followed by an SQL expression over the Item columns. */
+type ExportColumn struct {
+	Header string
+	Source string
+	Format CellFormat
+}
+
+/* Reports whether Source is an SQL expression */
+func (c ExportColumn) computed() bool {
+	return strings.HasPrefix(c.Source, "=")
+}
+
+type ExportProfile struct {
+	ProfileID    int
+	Name         string
+	SheetName    string
+	ItemStatusID ItemStatusID // 0 exports every item that is not deleted
+	Columns      []ExportColumn
+	BuiltIn      bool
+}
+
+/* Columns of the Proceedo spreadsheet, in order, with the Item column each one is read from */
+var proceedoColumns = []ExportColumn{
+	{Header: "Artikelnummer*", Source: "ItemID"},  // *Obligatoriskt fält*
+	{Header: "Produktbenämning*", Source: "Name"}, // *Obligatoriskt fält*
+	{Header: "Pris*", Source: "Price"},            // *Obligatoriskt fält*
+	{Header: "Valuta*", Source: "Currency"},       // *Obligatoriskt fält* SEK
+	{Header: "Antal enheter i pris*", Source: "QuantityInPrice"},
+	{Header: "Säljenhet*", Source: "Unit"}, // *Obligatoriskt fält*
+	{Header: "Beställs i multiplar av*", Source: "OrderMultiple"},
+	{Header: "Minsta beställningskvantitet*", Source: "MinOrder"},
+	{Header: "Momssats*", Source: "Vat"}, // *Obligatoriskt fält*
+	{Header: "Antal dagar för leverans", Source: "Eta"},
+	{Header: "Leveransbeskr. (ers. dagar)", Source: "EtaText"},
+	{Header: "Bassortiment (\"tumme upp\")*", Source: "Priority"}, // *Obligatoriskt fält* [Y|N]
+	{Header: "Saldo", Source: "Stock"},
+	{Header: "Sökord", Source: "SearchWords"}, // From SearchWords_Association
+	{Header: "Webblänk till bild", Source: "ImgURL1"},
+	{Header: "Webblänk till bild 2", Source: "ImgURL2"},
+	{Header: "Webblänk till bild 3", Source: "ImgURL3"},
+	{Header: "Webblänk till bild 4", Source: "ImgURL4"},
+	{Header: "Webblänk till bild 5", Source: "ImgURL5"},
+	{Header: "Webblänk till produktblad", Source: "SpecsURL"},
+	{Header: "UNSPSC (00.00.00.00)", Source: "UNSPSC"},
+	{Header: "Utförligare beskrivning", Source: "LongDesc"},
+	{Header: "Tillverkare", Source: "Manufacturer"},
+	{Header: "Tillverkarens artnr.", Source: "MfrItemId"},
+	{Header: "Globalt ID", Source: "GlobId"},
+	{Header: "Kvalificerare globalt ID", Source: "GlobIdType"},
+	{Header: "Ersätter artikelnummer", Source: "ReplacesItem"},
+	{Header: "Tillhör produkt", Source: "SubItemOf"}, // From Item_Parent
+	{Header: "Tilläggsfrågor", Source: "Questions"},
+	{Header: "Förpackas*", Source: "PackagingCode"},
+	{Header: "Presentation*", Source: "PresentationCode"},
+	{Header: "Automatisk leveranskvittens", Source: "DeliveryAutoSign"},
+	{Header: "Visa i alternativ best.", Source: "DeliveryOption"},
+	{Header: "Jämförelsepris", Source: "ComparePrice"},
+	{Header: "Enhetstyp i jämförelsepris", Source: "CompareUnit"},
+	{Header: "Antal enheter i jfrpris", Source: "CompareQuantityInPrice"},
+	{Header: "Prisinformation", Source: "PriceInfo"},
+	{Header: "Extra beskrivningsfält", Source: "AddDesc"},
+	{Header: "Flöde*", Source: "ProcFlow"},
+	{Header: "Inre enhetstyp", Source: "InnerUnit"},
+	{Header: "Antal inre enheter i säljenhet", Source: "QuantityInUnit"},
+	{Header: "Riskbeskrivning", Source: "RiskClassification"},
+	{Header: "Kommentar till beställare", Source: "Comment"},
+	{Header: "Miljömärkning", Source: "EnvClassification"},
+	{Header: "Formulär", Source: "FormId"},
+	{Header: "Artikeltyp ", Source: "Article"},
+	{Header: "Bifoga filer", Source: "Attachments"},
+	{Header: "Produktgrupp", Source: "ItemGroup"},
+}
+
+/* Returns the position of the Proceedo column read from source, or -1 */
+func proceedoIndex(source string) int {
+	return slices.IndexFunc(proceedoColumns, func(c ExportColumn) bool { return c.Source == source })
+}
+
+/* Returns the built-in Proceedo profile, which ExportExcel and Export use */
+func ProceedoProfile() *ExportProfile {
+	return &ExportProfile{
+		Name:         "Proceedo",
+		SheetName:    "Data",
+		ItemStatusID: ItemStatusAvailable,
+		Columns:      slices.Clone(proceedoColumns),
+		BuiltIn:      true,
+	}
+}
+
+/* Returns a profile with the same layout as p that is not yet stored */
+func (p *ExportProfile) Copy(name string) *ExportProfile {
+	return &ExportProfile{
+		Name:         name,
+		SheetName:    p.SheetName,
+		ItemStatusID: p.ItemStatusID,
+		Columns:      slices.Clone(p.Columns),
+	}
+}
+
+/* Returns the built-in profile followed by the stored ones, sorted by name */
+func ExportProfiles() ([]*ExportProfile, error) {
+	profiles := []*ExportProfile{ProceedoProfile()}
+	rows, err := b.db.Query(`SELECT ProfileID FROM ExportProfile ORDER BY Name`)
+	if err != nil {
+		return profiles, fmt.Errorf("ExportProfiles() error: %w", err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		rows.Scan(&id)
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return profiles, fmt.Errorf("ExportProfiles() error: %w", err)
+	}
+	for _, id := range ids {
+		p, err := exportProfile(id)
+		if err != nil {
+			return profiles, fmt.Errorf("ExportProfiles() error: %w", err)
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, nil
+}
+
+/* Returns the profile called name, or sql.ErrNoRows */
+func ExportProfileFor(name string) (*ExportProfile, error) {
+	if builtin := ProceedoProfile(); strings.EqualFold(name, builtin.Name) {
+		return builtin, nil
+	}
+	var id int
+	if err := b.db.QueryRow(`SELECT ProfileID FROM ExportProfile WHERE Name = @0`, name).Scan(&id); err != nil {
+		return nil, fmt.Errorf("ExportProfileFor(%s) error: %w", name, err)
+	}
+	return exportProfile(id)
+}
+
+func exportProfile(id int) (*ExportProfile, error) {
+	p := &ExportProfile{ProfileID: id}
+	query := `SELECT Name, SheetName, ItemStatusID FROM ExportProfile WHERE ProfileID = @0`
+	if err := b.db.QueryRow(query, id).Scan(&p.Name, &p.SheetName, &p.ItemStatusID); err != nil {
+		return p, fmt.Errorf("exportProfile(%d) error: %w", id, err)
+	}
+	query = `SELECT Header, Source, Format FROM ExportProfile_Column WHERE ProfileID = @0 ORDER BY Position`
+	rows, err := b.db.Query(query, id)
+	if err != nil {
+		return p, fmt.Errorf("exportProfile(%d) error: %w", id, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c ExportColumn
+		rows.Scan(&c.Header, &c.Source, &c.Format)
+		p.Columns = append(p.Columns, c)
+	}
+	return p, rows.Err()
+}
+
+/* Returns every source a column can be read from except expressions */
+func ExportSources() ([]string, error) {
+	fields, err := ItemFields()
+	return append(slices.Clone(exportSources), fields...), err
+}
+
+/* Returns an error wrapping ErrInvalidValue that describes what is wrong with the profile, or nil */
+func (p *ExportProfile) Check() error {
+	var errs []error
+	if strings.TrimSpace(p.Name) == "" {
+		errs = append(errs, fmt.Errorf("namn saknas"))
+	} else if !p.BuiltIn && strings.EqualFold(p.Name, ProceedoProfile().Name) {
+		errs = append(errs, fmt.Errorf("namnet %s är upptaget", p.Name))
+	}
+	if strings.TrimSpace(p.SheetName) == "" {
+		errs = append(errs, fmt.Errorf("bladnamn saknas"))
+	}
+	if len(p.Columns) == 0 {
+		errs = append(errs, fmt.Errorf("inga kolumner"))
+	}
+	columns, err := itemColumns()
+	if err != nil {
+		return fmt.Errorf("ExportProfile.Check() error: %w", err)
+	}
+	for i, c := range p.Columns {
+		if !slices.Contains(CellFormats, c.Format) {
+			errs = append(errs, fmt.Errorf("kolumn %d: okänt format %q", i+1, c.Format))
+		}
+		switch {
+		case c.computed():
+			if err := checkExpression(c.Source[1:]); err != nil {
+				errs = append(errs, fmt.Errorf("kolumn %d: %w", i+1, err))
+			}
+		case slices.Contains(exportSources, c.Source):
+		case slices.ContainsFunc(columns, func(col [2]string) bool { return col[0] == c.Source }):
+		default:
+			errs = append(errs, fmt.Errorf("kolumn %d: okänt fält %q", i+1, c.Source))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidValue, err)
+	}
+	return nil
+}
+
+/* Expressions are evaluated inside a SELECT from Item, so they may not end the statement */
+func checkExpression(expr string) error {
+	if strings.TrimSpace(expr) == "" {
+		return fmt.Errorf("tomt uttryck")
+	}
+	if strings.Contains(expr, ";") {
+		return fmt.Errorf("uttrycket får inte innehålla ;")
+	}
+	stmt, err := b.db.Prepare(`SELECT (` + expr + `) FROM Item LIMIT 0`)
+	if err != nil {
+		return fmt.Errorf("ogiltigt uttryck %q: %w", expr, err)
+	}
+	return stmt.Close()
+}
+
+/* Store the profile, adding it if it has no ProfileID. The built-in profile cannot be changed. */
+func (p *ExportProfile) Save() error {
+	if p.BuiltIn {
+		return fmt.Errorf("ExportProfile.Save(%s) error: %w", p.Name, ErrInvalidValue)
+	}
+	if err := p.Check(); err != nil {
+		return fmt.Errorf("ExportProfile.Save(%s) error: %w", p.Name, err)
+	}
+	tx, err := b.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ExportProfile.Save(%s) error: %w", p.Name, err)
+	}
+	defer tx.Rollback()
+
+	id := p.ProfileID
+	if id == 0 {
+		r, err := tx.Exec(`INSERT INTO ExportProfile (Name, SheetName, ItemStatusID) VALUES (@0, @1, @2)`, p.Name, p.SheetName, p.ItemStatusID)
+		if err != nil {
+			return fmt.Errorf("ExportProfile.Save(%s) error: %w", p.Name, err)
+		}
+		i, err := r.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("ExportProfile.Save(%s) error: %w", p.Name, err)
+		}
+		id = int(i)
+	} else {
+		query := `UPDATE ExportProfile SET Name = @0, SheetName = @1, ItemStatusID = @2 WHERE ProfileID = @3`
+		if _, err := tx.Exec(query, p.Name, p.SheetName, p.ItemStatusID, id); err != nil {
+			return fmt.Errorf("ExportProfile.Save(%s) error: %w", p.Name, err)
+		}
+		if _, err := tx.Exec(`DELETE FROM ExportProfile_Column WHERE ProfileID = @0`, id); err != nil {
+			return fmt.Errorf("ExportProfile.Save(%s) error: %w", p.Name, err)
+		}
+	}
+	for i, c := range p.Columns {
+		query := `INSERT INTO ExportProfile_Column (ProfileID, Position, Header, Source, Format) VALUES (@0, @1, @2, @3, @4)`
+		if _, err := tx.Exec(query, id, i, c.Header, c.Source, c.Format); err != nil {
+			return fmt.Errorf("ExportProfile.Save(%s) error: %w", p.Name, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ExportProfile.Save(%s) error: %w", p.Name, err)
+	}
+	p.ProfileID = id
+	b.Journal.NewEntry(journal.Message, journal.Edit, fmt.Sprintf("Sparade exportprofilen %s.", p.Name))
+	return nil
+}
+
+/* Remove a stored profile */
+func (p *ExportProfile) Delete() error {
+	if p.BuiltIn || p.ProfileID == 0 {
+		return fmt.Errorf("ExportProfile.Delete(%s) error: %w", p.Name, ErrInvalidValue)
+	}
+	tx, err := b.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ExportProfile.Delete(%s) error: %w", p.Name, err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM ExportProfile_Column WHERE ProfileID = @0`, p.ProfileID); err != nil {
+		return fmt.Errorf("ExportProfile.Delete(%s) error: %w", p.Name, err)
+	}
+	if _, err := tx.Exec(`DELETE FROM ExportProfile WHERE ProfileID = @0`, p.ProfileID); err != nil {
+		return fmt.Errorf("ExportProfile.Delete(%s) error: %w", p.Name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ExportProfile.Delete(%s) error: %w", p.Name, err)
+	}
+	b.Journal.NewEntry(journal.Message, journal.Delete, fmt.Sprintf("Tog bort exportprofilen %s.", p.Name))
+	p.ProfileID = 0
+	return nil
+}
+
+/* Returns the IDs of all items the profile exports */
+func (p *ExportProfile) itemIDs() ([]ItemID, error) {
+	var ids []ItemID
+	query := `SELECT ItemID FROM Item WHERE ItemStatusID = @0`
+	args := []any{p.ItemStatusID}
+	if p.ItemStatusID == 0 {
+		query = `SELECT ItemID FROM Item WHERE ItemStatusID <> @0`
+		args = []any{ItemStatusDeleted}
+	}
+	rows, err := b.db.Query(query, args...)
+	if err != nil {
+		return ids, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id NullInt
+		rows.Scan(&id)
+		if id.Valid {
+			ids = append(ids, ItemID(id.Int))
+		}
+	}
+	return ids, rows.Err()
+}
+
+/* Returns the header row */
+func (p *ExportProfile) headers() []string {
+	var headers []string
+	for _, c := range p.Columns {
+		headers = append(headers, c.Header)
+	}
+	return headers
+}
+
+/* Returns the cells of the row for id. Item columns and expressions are read in one query. */
+func (p *ExportProfile) row(id ItemID, columns [][2]string) ([]any, error) {
+	row := make([]any, len(p.Columns))
+
+	types := make(map[string]string)
+	var keys []string
+	for _, c := range p.Columns {
+		if c.computed() {
+			keys = append(keys, "("+c.Source[1:]+")")
+		} else if i := slices.IndexFunc(columns, func(col [2]string) bool { return col[0] == c.Source }); i >= 0 {
+			types[c.Source] = columns[i][1]
+			keys = append(keys, c.Source)
+		}
+	}
+	values := make(map[string]any)
+	if len(keys) > 0 {
+		vals := make([]any, len(keys))
+		dest := make([]any, len(keys))
+		for i := range vals {
+			dest[i] = &vals[i]
+		}
+		query := `SELECT ` + strings.Join(keys, ", ") + ` FROM Item WHERE ItemID = @0`
+		if err := b.db.QueryRow(query, id).Scan(dest...); err != nil {
+			return row, fmt.Errorf("ExportProfile.row(%d) error: %w", id, err)
+		}
+		for i, key := range keys {
+			values[key] = vals[i]
+		}
+	}
+
+	var errs []error
+	for i, c := range p.Columns {
+		mandatory := strings.HasSuffix(c.Header, "*")
+		var typ string
+		var val any
+		switch {
+		case c.computed():
+			val = values["("+c.Source[1:]+")"]
+			switch val.(type) {
+			case int64:
+				typ = "INT"
+			case float64:
+				typ = "REAL"
+			default:
+				typ = "TEXT"
+			}
+		case c.Source == "ItemID":
+			typ, val = "ITEMID", int64(id)
+		case c.Source == "SearchWords":
+			words, err := id.SearchWords()
+			if err != nil {
+				errs = append(errs, err)
+			}
+			typ, val = "TEXT", strings.Join(words, ", ")
+		case c.Source == "SubItemOf":
+			parent, err := id.ParentItemID()
+			if err != nil {
+				errs = append(errs, err)
+			}
+			typ, val = "ITEMID", int64(parent)
+		case c.Source == "ReplacesItem":
+			typ, val = "ITEMID", values[c.Source]
+		default:
+			typ, val = types[c.Source], values[c.Source]
+		}
+		row[i] = c.Format.value(typ, val, mandatory)
+	}
+	return row, errors.Join(errs...)
+}
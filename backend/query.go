@@ -0,0 +1,124 @@
+package backend
+
+import (
+	"UppSpar/backend/domain"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+/* The queries returning the values a condition on each field can take */
+var queryValueQueries = map[string]string{
+	"cat":   `SELECT DISTINCT Name FROM Category ORDER BY Name`,
+	"mfr":   `SELECT DISTINCT Name FROM Manufacturer WHERE Deleted = false ORDER BY Name`,
+	"model": `SELECT DISTINCT Name FROM Model WHERE Deleted = false ORDER BY Name`,
+	"word":  `SELECT DISTINCT WordString FROM SearchWords_Vocabulary ORDER BY WordString`,
+}
+
+/*
+Returns the search terms term can be completed to, best first. A condition completes as queryCompletions says, other
+words to the item names, manufacturers, models, categories and search words they begin, the last two as conditions.
+*/
+func Completions(ctx context.Context, term string) ([]string, error) {
+	completions := queryCompletions(term)
+	i := strings.LastIndexAny(term, " \t") + 1
+	prefix, word := term[:i], term[i:]
+	if word == "" || strings.Contains(word, ":") || strings.Count(prefix, `"`)%2 == 1 {
+		return completions, nil
+	}
+	hits, err := b.Repository.Completions(ctx, strings.TrimPrefix(word, `"`), completionLimit)
+	if err != nil {
+		return completions, fmt.Errorf("Completions(%s) error: %w", term, err)
+	}
+	for _, hit := range hits {
+		completion := prefix + hit.Value
+		if hit.Field != "" {
+			completion = prefix + hit.Field + ":" + queryQuote(hit.Value)
+		}
+		if !slices.Contains(completions, completion) {
+			completions = append(completions, completion)
+		}
+	}
+	return completions, nil
+}
+
+/* Quotes a value of a condition if it contains spaces or a colon */
+func queryQuote(v string) string {
+	if strings.ContainsAny(v, " \t:") {
+		return `"` + v + `"`
+	}
+	return v
+}
+
+/*
+Returns the search terms the last word of term can be completed to. A word without a colon completes to the field names
+it begins, a condition completes to the values of its field, quoted if they contain spaces.
+*/
+func queryCompletions(term string) []string {
+	var completions []string
+	i := strings.LastIndexAny(term, " \t") + 1
+	prefix, word := term[:i], term[i:]
+	if word == "" || strings.Count(prefix, `"`)%2 == 1 {
+		return completions
+	}
+	key, val, ok := strings.Cut(word, ":")
+	if !ok {
+		for _, name := range domain.QueryFieldsFor(word) {
+			completions = append(completions, prefix+name+":")
+		}
+		return completions
+	}
+	field, ok := domain.QueryField(key)
+	if !ok {
+		return completions
+	}
+	val = strings.ToLower(strings.TrimPrefix(val, `"`))
+	for _, v := range queryValues(field) {
+		if !strings.HasPrefix(strings.ToLower(v), val) {
+			continue
+		}
+		completions = append(completions, prefix+key+":"+queryQuote(v))
+	}
+	return completions
+}
+
+/* Returns the values a condition on field can take, or none if it takes numbers or dates */
+func queryValues(field string) []string {
+	var values []string
+	if field == "status" {
+		for _, id := range []ItemStatusID{ItemStatusAvailable, ItemStatusReserved, ItemStatusSold, ItemStatusArchived} {
+			if s := id.LString(); !slices.Contains(values, s) {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	if field == "image" {
+		for flag := range domain.QueryFlags {
+			values = append(values, flag)
+		}
+		slices.Sort(values)
+		return values
+	}
+	query, ok := queryValueQueries[field]
+	if !ok {
+		return values
+	}
+	rows, err := b.db.Query(query)
+	if err != nil {
+		log.Println(err)
+		return values
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			log.Println(err)
+			return values
+		}
+		values = append(values, value)
+	}
+	return values
+}
@@ -0,0 +1,91 @@
+package backend
+
+import (
+	"UppSpar/backend/domain"
+	"UppSpar/backend/journal"
+	"fmt"
+	"time"
+)
+
+type Sale = domain.Sale
+type SalesReport = domain.SalesReport
+
+/*
+Records the sale of s.Quantity of the item and takes it from the stock, which sells the item when none is left. A sale
+is kept in the ledger rather than the undo stack, so the steps that edit the item are dropped from it, as undoing them
+could put back the stock or status the sale changed.
+*/
+func (id ItemID) Sell(s Sale) (*Sale, error) {
+	s.ItemID = id.Int()
+	sale, err := b.Repository.Sell(s)
+	if err != nil {
+		return nil, fmt.Errorf("ItemID(%d).Sell() error: %w", id, err)
+	}
+	msg := fmt.Sprintf("Sålde %g st av artikel %s för %.2f %s", sale.Quantity, id, sale.Amount, sale.Currency)
+	if sale.Buyer != "" {
+		msg += " till " + sale.Buyer
+	}
+	logSale(sale, msg)
+	return sale, nil
+}
+
+/* Records the return of quantity of sale id and puts it back in stock, which makes a sold item available again */
+func ReturnSale(id int, quantity float64, reason string) (*Sale, error) {
+	sale, err := b.Repository.ReturnSale(id, quantity, reason)
+	if err != nil {
+		return nil, fmt.Errorf("ReturnSale(%d) error: %w", id, err)
+	}
+	logSale(sale, fmt.Sprintf("Tog emot %g st i retur av försäljning %d av artikel %s, %.2f %s", -sale.Quantity, id, ItemID(sale.ItemID), -sale.Amount, sale.Currency))
+	return sale, nil
+}
+
+/* Records that sale c.SaleID should have been of c.Quantity at c.Price and c.Vat to c.Buyer */
+func CorrectSale(c Sale) (*Sale, error) {
+	sale, err := b.Repository.CorrectSale(c)
+	if err != nil {
+		return nil, fmt.Errorf("CorrectSale(%d) error: %w", c.SaleID, err)
+	}
+	logSale(sale, fmt.Sprintf("Rättade försäljning %d av artikel %s till %g st à %.2f %s", c.SaleID, ItemID(sale.ItemID), c.Quantity, c.Price, sale.Currency))
+	return sale, nil
+}
+
+/* Records the receipt of quantity of the item and adds it to the stock, which makes a sold item available again */
+func (id ItemID) Receive(quantity float64, reason string) (*Sale, error) {
+	sale, err := b.Repository.Receive(id.Int(), quantity, reason)
+	if err != nil {
+		return nil, fmt.Errorf("ItemID(%d).Receive() error: %w", id, err)
+	}
+	logSale(sale, fmt.Sprintf("Tog emot %g st av artikel %s till lagret", quantity, id))
+	return sale, nil
+}
+
+/* Returns sale id with its returns and corrections added */
+func GetSale(id int) (*Sale, error) {
+	return b.Repository.Sale(id)
+}
+
+/* Returns the sales, returns, corrections and receipts of the item, newest first */
+func (id ItemID) Sales() ([]Sale, error) {
+	return b.Repository.Sales(id.Int())
+}
+
+/* Returns what was sold from the start of the day from until the end of the day to */
+func GetSalesReport(from, to time.Time) (*SalesReport, error) {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.Local)
+	to = time.Date(to.Year(), to.Month(), to.Day()+1, 0, 0, 0, 0, time.Local)
+	return b.Repository.SalesReport(from, to)
+}
+
+/* Writes msg, with the reason for s, to the journal and shows the stock and status s left its item with */
+func logSale(s *Sale, msg string) {
+	if s.Reason != "" {
+		msg += ": " + s.Reason
+	}
+	b.Journal.NewEntry(journal.Message, journal.Edit, msg+".")
+	if b.Undo != nil {
+		b.Undo.forget("Item", s.ItemID)
+	}
+	if b.Items != nil {
+		reload(&domain.Change{Table: "Item", ID: s.ItemID})
+	}
+}
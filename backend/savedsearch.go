@@ -0,0 +1,176 @@
+package backend
+
+import (
+	"UppSpar/backend/domain"
+	"UppSpar/backend/journal"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+/* The search and filter of the items list saved under a name. Used as a smart list it shows how many items match it now. */
+type SavedSearch struct {
+	SearchID int
+	Name     string
+	Term     string
+	Match    SearchTermMatch
+	Scope    []string // The search columns in scope
+	Sort     SortSpec
+	Filter   FilterValues
+}
+
+/* Returns the current search and filter of the list as a SavedSearch called name that is not yet stored */
+func (m *Items) CurrentSearch(name string) *SavedSearch {
+	s := &SavedSearch{
+		Name:   name,
+		Term:   m.Search.term(),
+		Match:  m.Search.Match,
+		Sort:   m.Search.Sort,
+		Filter: m.Filter.Values(),
+	}
+	for _, key := range domain.SearchColumns {
+		if on, _ := m.Search.Scope[key].Get(); on {
+			s.Scope = append(s.Scope, key)
+		}
+	}
+	return s
+}
+
+/* Sets the search and filter of the list to s and lists the items once the changes have settled */
+func (m *Items) ApplySearch(s *SavedSearch) {
+	m.Search.Match = s.Match
+	m.Search.Sort = s.Sort
+	for _, key := range domain.SearchColumns {
+		m.Search.Scope[key].Set(slices.Contains(s.Scope, key))
+	}
+	m.Filter.SetValues(s.Filter)
+	m.Search.Term.Set(s.Term)
+	m.QueueItemIDs()
+}
+
+/* Returns the stored searches, sorted by name */
+func SavedSearches() ([]*SavedSearch, error) {
+	var searches []*SavedSearch
+	query := `SELECT SearchID, Name, Term, Match, Scope, SortBy, SortOrder,
+Category, Manufacturer, Model, Status, Width, Height, Depth, Volume, Weight FROM SavedSearch ORDER BY Name`
+	rows, err := b.db.Query(query)
+	if err != nil {
+		return searches, fmt.Errorf("SavedSearches() error: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		s := &SavedSearch{}
+		var match, scope, sortBy, order string
+		f := &s.Filter
+		err := rows.Scan(&s.SearchID, &s.Name, &s.Term, &match, &scope, &sortBy, &order,
+			&f.Category, &f.Manufacturer, &f.Model, &f.Status, &f.Width, &f.Height, &f.Depth, &f.Volume, &f.Weight)
+		if err != nil {
+			return searches, fmt.Errorf("SavedSearches() error: %w", err)
+		}
+		s.Match, _ = domain.SearchTermMatchFor(match)
+		if s.Sort, err = domain.ParseSortSpec(sortBy); err != nil || len(s.Sort) == 0 {
+			s.Sort = slices.Clone(DefaultSort)
+		}
+		/* Searches saved before sorting took several keys have the order of their one key apart */
+		if len(s.Sort) == 1 && order == SortDescending.String() {
+			s.Sort[0].Order = SortDescending
+		}
+		if scope != "" {
+			s.Scope = strings.Split(scope, ",")
+		}
+		searches = append(searches, s)
+	}
+	return searches, rows.Err()
+}
+
+/* Returns the stored search called name, or ErrNotFound */
+func SavedSearchFor(name string) (*SavedSearch, error) {
+	searches, err := SavedSearches()
+	if err != nil {
+		return nil, fmt.Errorf("SavedSearchFor(%s) error: %w", name, err)
+	}
+	for _, s := range searches {
+		if strings.EqualFold(s.Name, name) {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("SavedSearchFor(%s) error: %w", name, ErrNotFound)
+}
+
+/* Returns the search and filter s stands for, with the field conditions of the term moved to the filter */
+func (s *SavedSearch) query() (domain.Search, domain.Filter, error) {
+	e := domain.Search{
+		Term:  s.Term,
+		Scope: make(map[string]bool),
+		Match: s.Match,
+		Sort:  s.Sort,
+	}
+	for _, key := range s.Scope {
+		e.Scope[key] = true
+	}
+	f, err := s.Filter.complex()
+	if err != nil {
+		return e, f, err
+	}
+	return domain.ParseQuery(e, f)
+}
+
+/* Returns the IDs of the items that match s now, in its order */
+func (s *SavedSearch) ItemIDs() ([]ItemID, error) {
+	e, f, err := s.query()
+	if err != nil {
+		return nil, fmt.Errorf("SavedSearch.ItemIDs(%s) error: %w", s.Name, err)
+	}
+	return queryItemIDs(e, f)
+}
+
+/* Returns how many items match s now */
+func (s *SavedSearch) Count() (int, error) {
+	ids, err := s.ItemIDs()
+	return len(ids), err
+}
+
+/* Store the search, replacing any stored search with the same name */
+func (s *SavedSearch) Save() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("SavedSearch.Save() error: %w", ErrInvalidValue)
+	}
+	if _, err := s.ItemIDs(); err != nil {
+		return fmt.Errorf("SavedSearch.Save(%s) error: %w", s.Name, err)
+	}
+	query := `INSERT INTO SavedSearch (Name, Term, Match, Scope, SortBy, SortOrder,
+Category, Manufacturer, Model, Status, Width, Height, Depth, Volume, Weight)
+VALUES (@0, @1, @2, @3, @4, @5, @6, @7, @8, @9, @10, @11, @12, @13, @14)
+ON CONFLICT(Name) DO UPDATE SET Term = excluded.Term, Match = excluded.Match, Scope = excluded.Scope,
+SortBy = excluded.SortBy, SortOrder = excluded.SortOrder, Category = excluded.Category,
+Manufacturer = excluded.Manufacturer, Model = excluded.Model, Status = excluded.Status, Width = excluded.Width, Height = excluded.Height,
+Depth = excluded.Depth, Volume = excluded.Volume, Weight = excluded.Weight`
+	f := s.Filter
+	_, err := b.db.Exec(query, s.Name, s.Term, s.Match.String(), strings.Join(s.Scope, ","), s.Sort.String(), s.order().String(),
+		f.Category, f.Manufacturer, f.Model, f.Status, f.Width, f.Height, f.Depth, f.Volume, f.Weight)
+	if err != nil {
+		return fmt.Errorf("SavedSearch.Save(%s) error: %w", s.Name, err)
+	}
+	if err := b.db.QueryRow(`SELECT SearchID FROM SavedSearch WHERE Name = @0`, s.Name).Scan(&s.SearchID); err != nil {
+		return fmt.Errorf("SavedSearch.Save(%s) error: %w", s.Name, err)
+	}
+	b.Journal.NewEntry(journal.Message, journal.Edit, fmt.Sprintf("Sparade sökningen %s.", s.Name))
+	return nil
+}
+
+/* Returns the order of the first sort key, for the SortOrder column */
+func (s *SavedSearch) order() SortOrder {
+	if len(s.Sort) == 0 {
+		return SortAscending
+	}
+	return s.Sort[0].Order
+}
+
+/* Remove a stored search */
+func (s *SavedSearch) Delete() error {
+	if _, err := b.db.Exec(`DELETE FROM SavedSearch WHERE SearchID = @0`, s.SearchID); err != nil {
+		return fmt.Errorf("SavedSearch.Delete(%s) error: %w", s.Name, err)
+	}
+	b.Journal.NewEntry(journal.Message, journal.Delete, fmt.Sprintf("Tog bort sökningen %s.", s.Name))
+	return nil
+}
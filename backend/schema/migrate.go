@@ -0,0 +1,125 @@
+package schema
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+/* Versioned schema migrations, shared by the main database and the journal */
+
+var (
+	ErrNewerSchema = errors.New("database schema is newer than this program")
+)
+
+/* A Step upgrades the schema of a component from Version-1 to Version */
+type Step struct {
+	Version int
+	Name    string
+	Up      func(tx *sql.Tx) error
+}
+
+/* Returns an Up function that runs the statements in order */
+func Exec(stmts ...string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		for _, stmt := range stmts {
+			if _, err := tx.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+/* A Migrator keeps the schema version of one component in the SchemaVersion table */
+type Migrator struct {
+	db        *sql.DB
+	component string
+	steps     []Step
+	seeds     []Seed
+}
+
+func NewMigrator(db *sql.DB, component string, steps []Step) *Migrator {
+	for i, step := range steps {
+		if step.Version != i+1 {
+			panic(fmt.Sprintf("schema.NewMigrator(%s): step %d has version %d", component, i, step.Version))
+		}
+	}
+	return &Migrator{
+		db:        db,
+		component: component,
+		steps:     steps,
+	}
+}
+
+func (m *Migrator) Component() string {
+	return m.component
+}
+
+/* Returns the version of the newest step */
+func (m *Migrator) Latest() int {
+	return len(m.steps)
+}
+
+/* Returns the version currently stored in the database, 0 if none */
+func (m *Migrator) Version() (int, error) {
+	var v sql.NullInt64
+	if err := m.createVersionTable(); err != nil {
+		return 0, fmt.Errorf("Migrator(%s).Version() error: %w", m.component, err)
+	}
+	err := m.db.QueryRow(`SELECT Version FROM SchemaVersion WHERE Component = @0`, m.component).Scan(&v)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("Migrator(%s).Version() error: %w", m.component, err)
+	}
+	return int(v.Int64), nil
+}
+
+/* Run every step newer than the stored version, each in its own transaction. done is called after each committed step. */
+func (m *Migrator) Migrate(done func(Step)) error {
+	current, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if current > m.Latest() {
+		return fmt.Errorf("Migrator(%s).Migrate() version %d > %d: %w", m.component, current, m.Latest(), ErrNewerSchema)
+	}
+	for _, step := range m.steps[current:] {
+		if err := m.apply(step); err != nil {
+			return fmt.Errorf("Migrator(%s).Migrate() step %d (%s) error: %w", m.component, step.Version, step.Name, err)
+		}
+		if done != nil {
+			done(step)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) apply(step Step) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := step.Up(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO SchemaVersion (Component, Version) VALUES (@0, @1)
+ON CONFLICT(Component) DO UPDATE SET Version = excluded.Version, DateModified = datetime('now', 'subsec')`,
+		m.component, step.Version)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) createVersionTable() error {
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS SchemaVersion(
+Component TEXT PRIMARY KEY,
+Version INT DEFAULT 0,
+DateModified TEXT DEFAULT(datetime('now', 'subsec')))`)
+	return err
+}
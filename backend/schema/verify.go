@@ -0,0 +1,483 @@
+package schema
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+/* Verification of a database against the schema its migrations describe */
+
+type Kind int
+
+const (
+	MissingTable Kind = iota + 1
+	MissingColumn
+	ColumnMismatch
+	MissingTrigger
+	TriggerMismatch
+	ForeignKeyMismatch
+	MissingRows
+)
+
+func (k Kind) String() string {
+	switch k {
+	case MissingTable:
+		return "MissingTable"
+	case MissingColumn:
+		return "MissingColumn"
+	case ColumnMismatch:
+		return "ColumnMismatch"
+	case MissingTrigger:
+		return "MissingTrigger"
+	case TriggerMismatch:
+		return "TriggerMismatch"
+	case ForeignKeyMismatch:
+		return "ForeignKeyMismatch"
+	case MissingRows:
+		return "MissingRows"
+	}
+	return "Unknown"
+}
+
+/* A Difference is one way the database deviates from the expected schema */
+type Difference struct {
+	Kind       Kind
+	Table      string
+	Name       string // column or trigger name, empty for tables
+	Expected   string
+	Actual     string
+	Repairable bool
+	Repaired   bool
+}
+
+func (d Difference) String() string {
+	switch d.Kind {
+	case MissingTable:
+		return fmt.Sprintf("tabellen %s saknas", d.Table)
+	case MissingColumn:
+		return fmt.Sprintf("kolumnen %s.%s saknas", d.Table, d.Name)
+	case ColumnMismatch:
+		return fmt.Sprintf("kolumnen %s.%s är %q, förväntade %q", d.Table, d.Name, d.Actual, d.Expected)
+	case MissingTrigger:
+		return fmt.Sprintf("triggern %s på %s saknas", d.Name, d.Table)
+	case TriggerMismatch:
+		return fmt.Sprintf("triggern %s på %s skiljer sig från den förväntade", d.Name, d.Table)
+	case ForeignKeyMismatch:
+		return fmt.Sprintf("främmande nycklar i %s är [%s], förväntade [%s]", d.Table, d.Actual, d.Expected)
+	case MissingRows:
+		return fmt.Sprintf("%s saknar %s standardrader", d.Table, d.Actual)
+	}
+	return fmt.Sprintf("%s %s.%s", d.Kind, d.Table, d.Name)
+}
+
+/* A Report lists every Difference found for one component */
+type Report struct {
+	Component   string
+	Differences []Difference
+}
+
+func (r *Report) OK() bool {
+	return len(r.Differences) == 0
+}
+
+/* Returns the differences that have not been repaired */
+func (r *Report) Remaining() []Difference {
+	var diffs []Difference
+	for _, d := range r.Differences {
+		if !d.Repaired {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
+
+/* A Seed is a set of default rows a table is expected to contain. Rows is a VALUES list in the order of Columns, where the first column is the key. If IfEmpty is set the rows are only expected in an empty table. */
+type Seed struct {
+	Table   string
+	Columns []string
+	Rows    string
+	IfEmpty bool
+}
+
+func (s Seed) with() string {
+	return fmt.Sprintf("WITH seed(%s) AS (VALUES %s) ", strings.Join(s.Columns, ", "), s.Rows)
+}
+
+func (s Seed) where() string {
+	if s.IfEmpty {
+		return fmt.Sprintf(" WHERE NOT EXISTS (SELECT 1 FROM %s)", s.Table)
+	}
+	return fmt.Sprintf(" WHERE %s NOT IN (SELECT %s FROM %s)", s.Columns[0], s.Columns[0], s.Table)
+}
+
+func (s Seed) countMissing() string {
+	return s.with() + "SELECT count(*) FROM seed" + s.where()
+}
+
+func (s Seed) insertMissing() string {
+	cols := strings.Join(s.Columns, ", ")
+	return s.with() + fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM seed", s.Table, cols, cols) + s.where()
+}
+
+/* Sets the default rows checked by Verify and restored by Repair */
+func (m *Migrator) WithSeeds(seeds ...Seed) *Migrator {
+	m.seeds = seeds
+	return m
+}
+
+type column struct {
+	name    string
+	typ     string
+	notnull bool
+	dflt    sql.NullString
+	pk      int
+}
+
+func (c column) String() string {
+	s := strings.TrimSpace(c.typ)
+	if c.notnull {
+		s += " NOT NULL"
+	}
+	if c.dflt.Valid {
+		s += " DEFAULT " + c.dflt.String
+		if c.isExpr() {
+			s = strings.TrimSuffix(s, c.dflt.String) + "(" + c.dflt.String + ")"
+		}
+	}
+	if c.pk > 0 {
+		s += " PRIMARY KEY"
+	}
+	return s
+}
+
+/* A default other than a literal, e.g. datetime('now') */
+func (c column) isExpr() bool {
+	d := c.dflt.String
+	return strings.Contains(d, "(") && !strings.HasPrefix(d, "'") && !strings.HasPrefix(d, `"`)
+}
+
+/*
+Reports whether the column o is c. An expression default of c is only compared when o has a default, as addColumn cannot
+give a column it adds one.
+*/
+func (c column) equal(o column) bool {
+	return strings.EqualFold(normalize(c.typ), normalize(o.typ)) &&
+		c.notnull == o.notnull &&
+		(c.dflt == o.dflt || c.isExpr() && !o.dflt.Valid) &&
+		c.pk == o.pk
+}
+
+type object struct {
+	typ  string
+	name string
+	tbl  string
+	sql  string
+}
+
+/* Returns a copy of the expected schema, built by replaying every step in an in-memory database */
+func (m *Migrator) reference() (*sql.DB, error) {
+	ref, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	ref.SetMaxOpenConns(1)
+	r := &Migrator{db: ref, component: m.component, steps: m.steps}
+	if err := r.createVersionTable(); err != nil {
+		ref.Close()
+		return nil, err
+	}
+	for _, step := range r.steps {
+		if err := r.apply(step); err != nil {
+			ref.Close()
+			return nil, fmt.Errorf("step %d (%s): %w", step.Version, step.Name, err)
+		}
+	}
+	return ref, nil
+}
+
+/* Compare the tables, columns, triggers, foreign keys and default rows of the database with the schema described by the migrations. Tables that belong to other components are ignored. */
+func (m *Migrator) Verify() (*Report, error) {
+	ref, err := m.reference()
+	if err != nil {
+		return nil, fmt.Errorf("Migrator(%s).Verify() error: %w", m.component, err)
+	}
+	defer ref.Close()
+
+	report := &Report{Component: m.component}
+
+	want, err := objects(ref)
+	if err != nil {
+		return nil, fmt.Errorf("Migrator(%s).Verify() error: %w", m.component, err)
+	}
+	existing, err := objects(m.db)
+	if err != nil {
+		return nil, fmt.Errorf("Migrator(%s).Verify() error: %w", m.component, err)
+	}
+	have := make(map[string]object)
+	for _, o := range existing {
+		have[o.name] = o
+	}
+
+	for _, w := range want {
+		if w.typ != "table" || w.name == "SchemaVersion" || strings.HasPrefix(w.name, "sqlite_") {
+			continue
+		}
+		if _, ok := have[w.name]; !ok {
+			report.Differences = append(report.Differences, Difference{
+				Kind:       MissingTable,
+				Table:      w.name,
+				Expected:   w.sql,
+				Repairable: true,
+			})
+			continue
+		}
+		diffs, err := compareTable(ref, m.db, w.name)
+		if err != nil {
+			return nil, fmt.Errorf("Migrator(%s).Verify() error: %w", m.component, err)
+		}
+		report.Differences = append(report.Differences, diffs...)
+	}
+
+	for _, w := range want {
+		if w.typ != "trigger" {
+			continue
+		}
+		h, ok := have[w.name]
+		switch {
+		case !ok:
+			report.Differences = append(report.Differences, Difference{
+				Kind:       MissingTrigger,
+				Table:      w.tbl,
+				Name:       w.name,
+				Expected:   w.sql,
+				Repairable: true,
+			})
+		case normalize(h.sql) != normalize(w.sql):
+			report.Differences = append(report.Differences, Difference{
+				Kind:       TriggerMismatch,
+				Table:      w.tbl,
+				Name:       w.name,
+				Expected:   w.sql,
+				Actual:     h.sql,
+				Repairable: true,
+			})
+		}
+	}
+
+	for _, seed := range m.seeds {
+		if _, ok := have[seed.Table]; !ok {
+			report.Differences = append(report.Differences, Difference{
+				Kind:       MissingRows,
+				Table:      seed.Table,
+				Actual:     "alla",
+				Repairable: true,
+			})
+			continue
+		}
+		var n int
+		if err := m.db.QueryRow(seed.countMissing()).Scan(&n); err != nil {
+			return nil, fmt.Errorf("Migrator(%s).Verify() seed %s error: %w", m.component, seed.Table, err)
+		}
+		if n > 0 {
+			report.Differences = append(report.Differences, Difference{
+				Kind:       MissingRows,
+				Table:      seed.Table,
+				Actual:     fmt.Sprintf("%d", n),
+				Repairable: true,
+			})
+		}
+	}
+
+	return report, nil
+}
+
+/* Fix every repairable difference in the report, each kind in its own transaction, and mark it as repaired. Missing tables are created before columns, triggers and rows. */
+func (m *Migrator) Repair(report *Report) error {
+	for _, kind := range []Kind{MissingTable, MissingColumn, MissingTrigger, TriggerMismatch, MissingRows} {
+		tx, err := m.db.Begin()
+		if err != nil {
+			return fmt.Errorf("Migrator(%s).Repair() error: %w", m.component, err)
+		}
+		var fixed []int
+		for i, d := range report.Differences {
+			if d.Kind != kind || !d.Repairable || d.Repaired {
+				continue
+			}
+			if err := m.repair(tx, d); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("Migrator(%s).Repair() %s error: %w", m.component, d, err)
+			}
+			fixed = append(fixed, i)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("Migrator(%s).Repair() error: %w", m.component, err)
+		}
+		for _, i := range fixed {
+			report.Differences[i].Repaired = true
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) repair(tx *sql.Tx, d Difference) error {
+	switch d.Kind {
+	case MissingTable:
+		_, err := tx.Exec(d.Expected)
+		return err
+	case MissingColumn:
+		return addColumn(tx, d.Table, d.Name, d.Expected)
+	case MissingTrigger, TriggerMismatch:
+		if _, err := tx.Exec(fmt.Sprintf(`DROP TRIGGER IF EXISTS "%s"`, d.Name)); err != nil {
+			return err
+		}
+		_, err := tx.Exec(d.Expected)
+		return err
+	case MissingRows:
+		for _, seed := range m.seeds {
+			if seed.Table != d.Table {
+				continue
+			}
+			if _, err := tx.Exec(seed.insertMissing()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot repair %s", d.Kind)
+}
+
+/* ALTER TABLE only accepts constant defaults, so an expression default is left out of the column definition and applied to existing rows instead. Verify accepts the column without it. */
+func addColumn(tx *sql.Tx, table, name, definition string) error {
+	if typ, expr, ok := strings.Cut(definition, " DEFAULT ("); ok {
+		if _, err := tx.Exec(fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN "%s" %s`, table, name, typ)); err != nil {
+			return err
+		}
+		_, err := tx.Exec(fmt.Sprintf(`UPDATE "%s" SET "%s" = (%s`, table, name, expr))
+		return err
+	}
+	_, err := tx.Exec(fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN "%s" %s`, table, name, definition))
+	return err
+}
+
+func objects(db *sql.DB) ([]object, error) {
+	var objs []object
+	rows, err := db.Query(`SELECT type, name, tbl_name, ifnull(sql, '') FROM sqlite_master WHERE type IN ('table', 'trigger') ORDER BY rowid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var o object
+		if err := rows.Scan(&o.typ, &o.name, &o.tbl, &o.sql); err != nil {
+			return nil, err
+		}
+		objs = append(objs, o)
+	}
+	return objs, rows.Err()
+}
+
+func compareTable(ref, db *sql.DB, table string) ([]Difference, error) {
+	var diffs []Difference
+
+	want, err := columns(ref, table)
+	if err != nil {
+		return nil, err
+	}
+	have, err := columns(db, table)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range want {
+		i := slices.IndexFunc(have, func(h column) bool { return strings.EqualFold(h.name, w.name) })
+		if i < 0 {
+			diffs = append(diffs, Difference{
+				Kind:     MissingColumn,
+				Table:    table,
+				Name:     w.name,
+				Expected: w.String(),
+				// SQLite cannot add a PRIMARY KEY or NOT NULL column without a default
+				Repairable: w.pk == 0 && (!w.notnull || w.dflt.Valid),
+			})
+			continue
+		}
+		if !w.equal(have[i]) {
+			diffs = append(diffs, Difference{
+				Kind:     ColumnMismatch,
+				Table:    table,
+				Name:     w.name,
+				Expected: w.String(),
+				Actual:   have[i].String(),
+			})
+		}
+	}
+
+	wantFK, err := foreignKeys(ref, table)
+	if err != nil {
+		return nil, err
+	}
+	haveFK, err := foreignKeys(db, table)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Equal(wantFK, haveFK) {
+		diffs = append(diffs, Difference{
+			Kind:     ForeignKeyMismatch,
+			Table:    table,
+			Expected: strings.Join(wantFK, ", "),
+			Actual:   strings.Join(haveFK, ", "),
+		})
+	}
+
+	return diffs, nil
+}
+
+func columns(db *sql.DB, table string) ([]column, error) {
+	var cols []column
+	rows, err := db.Query(`SELECT name, type, "notnull", dflt_value, pk FROM pragma_table_info(@0)`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c column
+		if err := rows.Scan(&c.name, &c.typ, &c.notnull, &c.dflt, &c.pk); err != nil {
+			return nil, err
+		}
+		cols = append(cols, c)
+	}
+	return cols, rows.Err()
+}
+
+func foreignKeys(db *sql.DB, table string) ([]string, error) {
+	var keys []string
+	rows, err := db.Query(`SELECT "table", "from", ifnull("to", ''), on_update, on_delete FROM pragma_foreign_key_list(@0)`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var parent, from, to, onUpdate, onDelete string
+		if err := rows.Scan(&parent, &from, &to, &onUpdate, &onDelete); err != nil {
+			return nil, err
+		}
+		key := fmt.Sprintf("%s -> %s(%s)", from, parent, to)
+		if onUpdate != "NO ACTION" {
+			key += " ON UPDATE " + onUpdate
+		}
+		if onDelete != "NO ACTION" {
+			key += " ON DELETE " + onDelete
+		}
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys, rows.Err()
+}
+
+/* Collapse whitespace so that formatting does not count as a difference */
+func normalize(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -2,10 +2,8 @@ package backend
 
 import (
 	"UppSpar/backend/journal"
-	"database/sql"
 	"log"
 
-	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/data/binding"
 )
 
@@ -20,52 +18,34 @@ func newSetting(key string) *Setting {
 	return &Setting{key: key, value: binding.NewString()}
 }
 func (s *Setting) get() {
-	query := `SELECT ConfigVal FROM Config WHERE ConfigKey = @0`
-	stmt, err := b.db.Prepare(query)
+	val, err := b.Repository.Setting(s.key)
 	if err != nil {
-		panic(err)
+		log.Println(err)
 	}
-	defer stmt.Close()
-	var t sql.NullString
-	err = stmt.QueryRow(s.key).Scan(&t)
-	s.value.Set(t.String)
+	s.value.Set(val)
 }
 func (s *Setting) set() error {
 	val, err := s.value.Get()
-	query := `UPDATE Config SET ConfigVal = @0 WHERE ConfigKey = @1 AND ConfigVal <> @2`
-	stmt, err := b.db.Prepare(query)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	defer stmt.Close()
-	_, err = stmt.Exec(val, s.key, val)
-	return err
+	return b.Repository.SetSetting(s.key, val)
 }
 
 type Settings struct {
-	j                 *journal.Journal
-	m                 map[string]*Setting
-	ItemIDWidth       binding.Int
-	ResumeLastSession binding.Bool
+	j           *journal.Journal
+	m           map[string]*Setting
+	ItemIDWidth binding.Int
+	TrashDays   binding.Int // How long deleted items are kept before they are purged, 0 keeps them
 }
 
 func NewSettings() *Settings {
 	s := &Settings{
-		j:                 b.Journal,
-		m:                 make(map[string]*Setting),
-		ItemIDWidth:       binding.NewInt(),
-		ResumeLastSession: binding.NewBool(),
+		j: b.Journal,
+		m: make(map[string]*Setting),
 	}
-	s.initItemIDWidth()
-	s.ResumeLastSession.Set(fyne.CurrentApp().Preferences().BoolWithFallback("resume", false))
-	s.ResumeLastSession.AddListener(binding.NewDataListener(func() {
-		b, err := s.ResumeLastSession.Get()
-		if err != nil {
-			log.Println(err)
-			return
-		}
-		fyne.CurrentApp().Preferences().SetBool("resume", b)
-	}))
+	s.ItemIDWidth = s.initInt("ItemIDWidth")
+	s.TrashDays = s.initInt("TrashDays")
 	return s
 }
 
@@ -78,12 +58,13 @@ func (s *Settings) getSetting(key string) *Setting {
 	}
 	return t
 }
-func (s *Settings) initItemIDWidth() {
-	key := "ItemIDWidth"
+
+/* Returns a binding of the int setting key that stores what it is set to */
+func (s *Settings) initInt(key string) binding.Int {
 	s.m[key] = newSetting(key)
 	s.m[key].get()
-	s.ItemIDWidth = binding.StringToInt(s.m[key].value)
 	s.m[key].value.AddListener(binding.NewDataListener(func() {
 		s.m[key].set()
 	}))
+	return binding.StringToInt(s.m[key].value)
 }
@@ -0,0 +1,110 @@
+package backend
+
+import (
+	"UppSpar/backend/domain"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2/lang"
+)
+
+type StatusChange = domain.StatusChange
+
+/* Why a status was changed by something else than the status dialog, in the status log */
+const (
+	expiredReason = "Reservationen gick ut"
+	restoreReason = "Återställd från historiken"
+)
+
+/* Returns the statuses an item with status s can be given */
+func NextStatuses(s ItemStatusID) []ItemStatusID {
+	var next []ItemStatusID
+	for _, to := range domain.NextStatuses(int(s)) {
+		next = append(next, ItemStatusID(to))
+	}
+	return next
+}
+
+/*
+Gives item s.ItemID status s.To, recording the change with its reason, reservation or sale, in the status log and the
+journal. A sale without a date is dated now. Returns an error wrapping domain.ErrInvalidTransition if the item cannot
+go from its status to s.To. Status changes are kept off the undo stack, as undoing one would set the status back
+without the workflow and its log.
+*/
+func setStatus(s StatusChange, lead string) (*domain.Change, error) {
+	if s.To == int(ItemStatusSold) && s.Sold.IsZero() {
+		s.Sold = time.Now()
+	}
+	c, err := b.Repository.SetStatus(s)
+	if err != nil || c == nil {
+		return c, err
+	}
+	b.history.record(c, lead)
+	return c, nil
+}
+
+/*
+Gives the item status s.To as setStatus does and reloads what shows it. An item in stock is sold by selling all of it
+at s.Price to s.Contact, as Sell does.
+*/
+func (id ItemID) ChangeStatus(s StatusChange) error {
+	s.ItemID = id.Int()
+	if stock, _ := id.Stock(); s.To == int(ItemStatusSold) && stock > 0 {
+		vat, _ := id.Vat()
+		_, err := id.Sell(Sale{Quantity: stock, Price: s.Price, Vat: vat, Buyer: s.Contact, Reason: s.Reason, Time: s.Sold})
+		return err
+	}
+	c, err := setStatus(s, editLead)
+	if err != nil {
+		return fmt.Errorf("ItemID(%d).ChangeStatus(%d) error: %w", id, s.To, err)
+	}
+	if c != nil && b.Items != nil {
+		reload(c)
+	}
+	return nil
+}
+
+/* Returns the status changes of the item, newest first */
+func (id ItemID) StatusChanges() ([]StatusChange, error) {
+	return b.Repository.StatusChanges(id.Int())
+}
+
+/* Returns who the item is reserved for and until when, or its sale price and date, or "" if it is neither reserved nor sold */
+func (id ItemID) StatusInfo() string {
+	if r, err := b.Repository.Reservation(id.Int()); err != nil {
+		log.Println(err)
+	} else if r != nil {
+		var parts []string
+		if r.Contact != "" {
+			parts = append(parts, fmt.Sprintf(lang.X("status.info.contact", "status.info.contact"), r.Contact))
+		}
+		if !r.Expires.IsZero() {
+			parts = append(parts, fmt.Sprintf(lang.X("status.info.expires", "status.info.expires"), r.Expires.Local().Format(time.DateOnly)))
+		}
+		return strings.Join(parts, ", ")
+	}
+	if s, err := b.Repository.SoldStatus(id.Int()); err != nil {
+		log.Println(err)
+	} else if s != nil {
+		currency, _ := id.getString("Currency")
+		return fmt.Sprintf(lang.X("status.info.sold", "status.info.sold"), s.Sold.Local().Format(time.DateOnly), s.Price, currency)
+	}
+	return ""
+}
+
+/* Makes the reserved items whose reservations have run out available again */
+func expireReservations() {
+	ids, err := b.Repository.ExpiredReservations(time.Now())
+	if err != nil {
+		log.Printf("expireReservations() error: %s", err)
+		return
+	}
+	for _, id := range ids {
+		s := StatusChange{ItemID: id, To: int(ItemStatusAvailable), Reason: expiredReason}
+		if _, err := setStatus(s, expireLead); err != nil {
+			log.Printf("expireReservations() error: %s", err)
+		}
+	}
+}
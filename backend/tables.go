@@ -1,515 +1,100 @@
 package backend
 
 import (
+	"UppSpar/backend/domain"
+	"UppSpar/backend/journal"
+	"UppSpar/backend/schema"
+	"errors"
+	"fmt"
 	"log"
-	"slices"
+	"strings"
 )
 
-// TODO validation and repair functions
-
 /* Table initialisation, validation and repair */
-func (backend *Backend) listTables() []string {
-	var name string
-	var tables []string
-	stmt, err := backend.db.Prepare(`SELECT name FROM sqlite_master WHERE type='table'`)
-	if err != nil {
-		log.Println("listTables() panic!")
-		panic(err)
-	}
-	defer stmt.Close()
-	rows, err := stmt.Query()
-	if err != nil {
-		log.Println("listTables() panic!")
-		panic(err)
-	}
-	defer rows.Close()
-	for rows.Next() {
-		rows.Scan(&name)
-		tables = append(tables, name)
-	}
-	return tables
+
+func (backend *Backend) migrator() *schema.Migrator {
+	return schema.NewMigrator(backend.db, "main", domain.Migrations).WithSeeds(domain.Seeds...)
 }
-func (backend *Backend) createTables() {
-	j := backend.Journal
-	tables := backend.listTables()
-	touched := false
-	if !slices.Contains(tables, "Config") {
-		log.Printf("!slices.Contains(tables \"Config\")")
-		backend.db.Exec(`CREATE TABLE Config(
-ConfigKey TEXT PRIMARY KEY,
-ConfigVal TEXT)`)
-		backend.db.Exec(`INSERT INTO Config (ConfigKey, ConfigVal)
-VALUES ("ItemIDWidth", "7")`)
-		touched = true
-	}
 
-	if !slices.Contains(tables, "Item") {
-		log.Printf("!slices.Contains(tables \"Item\")")
-		backend.db.Exec(`CREATE TABLE Item(
--- Proceedo defined column names --
-ItemID                  INTEGER PRIMARY KEY AUTOINCREMENT, 
-Name                    TEXT DEFAULT 'Nytt föremål', 
-Price                   REAL DEFAULT 0, 
-Currency                TEXT DEFAULT 'SEK', 
-QuantityInPrice         REAL DEFAULT 1, 
-Unit                    TEXT DEFAULT 'st', 
-OrderMultiple           REAL DEFAULT 0, 
-MinOrder                REAL DEFAULT 0, 
-Vat                     REAL DEFAULT 0, 
-Eta                     INT DEFAULT 0, 
-EtaText                 TEXT DEFAULT '', 
-Priority                BOOL DEFAULT true, 
-Stock                   REAL DEFAULT 1, 
-ImgURL1                 TEXT DEFAULT '', 
-ImgURL2                 TEXT DEFAULT '', 
-ImgURL3                 TEXT DEFAULT '', 
-ImgURL4                 TEXT DEFAULT '', 
-ImgURL5                 TEXT DEFAULT '', 
-SpecsURL                TEXT DEFAULT '', 
-UNSPSC                  TEXT DEFAULT '', 
-LongDesc                TEXT DEFAULT '', 
-Manufacturer            TEXT DEFAULT '', 
-MfrItemId               TEXT DEFAULT '', 
-GlobId                  TEXT DEFAULT '', 
-GlobIdType              TEXT DEFAULT '', 
-ReplacesItem            INT DEFAULT 0, 
-Questions               TEXT DEFAULT '', 
-PackagingCode           BOOL DEFAULT false, 
-PresentationCode        BOOL DEFAULT false, 
-DeliveryAutoSign        BOOL DEFAULT false, 
-DeliveryOption          BOOL DEFAULT false, 
-ComparePrice            REAL DEFAULT 0, 
-CompareUnit             TEXT DEFAULT '', 
-CompareQuantityInPrice  REAL DEFAULT 0, 
-PriceInfo               TEXT DEFAULT '', 
-AddDesc                 TEXT DEFAULT '', 
-ProcFlow                TEXT DEFAULT '', 
-InnerUnit               TEXT DEFAULT '', 
-QuantityInUnit          REAL DEFAULT 0, 
-RiskClassification      TEXT DEFAULT '', 
-Comment                 TEXT DEFAULT '', 
-EnvClassification       TEXT DEFAULT '', 
-FormId                  TEXT DEFAULT '', 
-Article                 TEXT DEFAULT '', 
-Attachments             BOOL DEFAULT false, 
-ItemGroup               TEXT DEFAULT '', 
--- Custom defined fields --
-MfrID                   INT DEFAULT 0, 
-ModelID                 INT DEFAULT 0, 
-ModelName               TEXT DEFAULT '',
-ModelDesc               TEXT DEFAULT '', 
-ModelURL                TEXT DEFAULT '',
-Notes                   TEXT DEFAULT '', 
-Width                   REAL DEFAULT 0, 
-Height                  REAL DEFAULT 0, 
-Depth                   REAL DEFAULT 0, 
-Volume                  REAL DEFAULT 0, 
-Weight                  REAL DEFAULT 0, 
-LengthUnitID            INT DEFAULT 2, 
-VolumeUnitID            INT DEFAULT 11, 
-WeightUnitID            INT DEFAULT 7, 
-CatID                   INT DEFAULT 2, 
-GroupID                 INT DEFAULT 0, 
-StorageID               INT DEFAULT 0, 
-ItemStatusID            INT DEFAULT 1, 
-ItemConditionID         INT DEFAULT 0, 
-DateCreated             TEXT DEFAULT(datetime('now', 'subsec')), 
-DateModified            TEXT DEFAULT(datetime('now', 'subsec')), 
-FOREIGN KEY(MfrID) REFERENCES Manufacturer(MfrID), 
-FOREIGN KEY(ModelID) REFERENCES Model(ModelID), 
-FOREIGN KEY(LengthUnitID) REFERENCES Metric(UnitID), 
-FOREIGN KEY(VolumeUnitID) REFERENCES Metric(UnitID), 
-FOREIGN KEY(WeightUnitID) REFERENCES Metric(UnitID), 
-FOREIGN KEY(CatID) REFERENCES Category(CatID), 
-FOREIGN KEY(GroupID) REFERENCES Item_Group(GroupID), 
-FOREIGN KEY(ItemStatusID) REFERENCES ItemStatus(ItemStatusID),  
-FOREIGN KEY(StorageID) REFERENCES Storage(StorageID))`)
-		backend.db.Exec(`CREATE TRIGGER UpdateDateModified
-AFTER UPDATE ON Item FOR EACH ROW
-BEGIN
-    UPDATE Item SET DateModified = datetime('now', 'subsec') WHERE ItemID = old.ItemID;
-END`)
-		backend.db.Exec(`CREATE TRIGGER UpdateItemMfrID AFTER UPDATE OF MfrID ON Item
-FOR EACH ROW WHEN new.MfrID <> old.MfrID
-BEGIN
-    UPDATE Item SET
-    ModelID = 0
-    WHERE ItemID = old.ItemID;
-END`)
-		// TODO consider whether this trigger should overwrite all fields or not
-		backend.db.Exec(`CREATE TRIGGER UpdateModelData AFTER UPDATE OF ModelID ON Item
-FOR EACH ROW WHEN new.ModelID <> old.ModelID AND new.ModelID <> 0
-BEGIN
-    UPDATE Item SET
-    CatID =  (SELECT CatID FROM Model WHERE ModelID = new.ModelID),
-    ModelName = (SELECT Name FROM Model WHERE ModelID = new.ModelID),
-    ModelDesc = (SELECT Desc FROM Model WHERE ModelID = new.ModelID),
-    ModelURL = (SELECT ModelURL FROM Model WHERE ModelID = new.ModelID),
-    ImgURL1 = (CASE WHEN old.ImgURL1 = '' THEN (SELECT ImgURL1 FROM Model WHERE ModelID = new.ModelID) ELSE old.ImgURL1 END),
-    ImgURL2 = (CASE WHEN old.ImgURL2 = '' THEN (SELECT ImgURL2 FROM Model WHERE ModelID = new.ModelID) ELSE old.ImgURL2 END),
-    ImgURL3 = (CASE WHEN old.ImgURL3 = '' THEN (SELECT ImgURL3 FROM Model WHERE ModelID = new.ModelID) ELSE old.ImgURL3 END),
-    ImgURL4 = (CASE WHEN old.ImgURL4 = '' THEN (SELECT ImgURL4 FROM Model WHERE ModelID = new.ModelID) ELSE old.ImgURL4 END),
-    ImgURL5 = (CASE WHEN old.ImgURL5 = '' THEN (SELECT ImgURL5 FROM Model WHERE ModelID = new.ModelID) ELSE old.ImgURL5 END),
-    SpecsURL = (CASE WHEN old.SpecsURL = '' THEN (SELECT SpecsURL FROM Model WHERE ModelID = new.ModelID) ELSE old.SpecsURL END),
-    ModelURL = (CASE WHEN old.ModelURL = '' THEN (SELECT ModelURL FROM Model WHERE ModelID = new.ModelID) ELSE old.ModelURL END),
-    Width = (SELECT Width FROM Model WHERE ModelID = new.ModelID),
-    Height = (SELECT Height FROM Model WHERE ModelID = new.ModelID),
-    Depth = (SELECT Depth FROM Model WHERE ModelID = new.ModelID),
-    Volume = (SELECT Volume FROM Model WHERE ModelID = new.ModelID),
-    Weight = (SELECT Weight FROM Model WHERE ModelID = new.ModelID),
-    LengthUnitID = (SELECT LengthUnitID FROM Model WHERE ModelID = new.ModelID),
-    VolumeUnitID = (SELECT VolumeUnitID FROM Model WHERE ModelID = new.ModelID),
-    WeightUnitID = (SELECT WeightUnitID FROM Model WHERE ModelID = new.ModelID)
-    WHERE ItemID = old.ItemID;
-END`)
-		touched = true
-	}
-	if !slices.Contains(tables, "Temp_Item") {
-		log.Printf("!slices.Contains(tables \"Temp_Item\")")
-		backend.db.Exec(`CREATE TABLE Temp_Item(
--- Proceedo defined column names --
-ItemID                  INT, 
-Name                    TEXT, 
-Price                   REAL, 
-Currency                TEXT, 
-QuantityInPrice         REAL, 
-Unit                    TEXT, 
-OrderMultiple           REAL, 
-MinOrder                REAL, 
-Vat                     REAL, 
-Eta                     INT, 
-EtaText                 TEXT, 
-Priority                BOOL, 
-Stock                   REAL, 
-ImgURL1                 TEXT, 
-ImgURL2                 TEXT, 
-ImgURL3                 TEXT, 
-ImgURL4                 TEXT, 
-ImgURL5                 TEXT, 
-SpecsURL                TEXT, 
-UNSPSC                  TEXT, 
-LongDesc                TEXT, 
-Manufacturer            TEXT, 
-MfrItemId               TEXT, 
-GlobId                  TEXT, 
-GlobIdType              TEXT, 
-ReplacesItem            INT, 
-Questions               TEXT, 
-PackagingCode           BOOL, 
-PresentationCode        BOOL, 
-DeliveryAutoSign        BOOL, 
-DeliveryOption          BOOL, 
-ComparePrice            REAL, 
-CompareUnit             TEXT, 
-CompareQuantityInPrice  REAL, 
-PriceInfo               TEXT, 
-AddDesc                 TEXT, 
-ProcFlow                TEXT, 
-InnerUnit               TEXT, 
-QuantityInUnit          REAL, 
-RiskClassification      TEXT, 
-Comment                 TEXT, 
-EnvClassification       TEXT, 
-FormId                  TEXT, 
-Article                 TEXT, 
-Attachments             BOOL, 
-ItemGroup               TEXT, 
--- Custom defined fields --
-MfrID                   INT, 
-ModelID                 INT, 
-ModelName               TEXT,
-ModelDesc               TEXT, 
-ModelURL                TEXT,
-Notes                   TEXT, 
-Width                   REAL, 
-Height                  REAL, 
-Depth                   REAL, 
-Volume                  REAL, 
-Weight                  REAL, 
-LengthUnitID            INT, 
-VolumeUnitID            INT, 
-WeightUnitID            INT, 
-CatID                   INT, 
-GroupID                 INT, 
-StorageID               INT, 
-ItemStatusID            INT, 
-ItemConditionID         INT, 
-DateCreated             TEXT, 
-DateModified            TEXT, 
-FOREIGN KEY(MfrID) REFERENCES Manufacturer(MfrID), 
-FOREIGN KEY(ModelID) REFERENCES Model(ModelID), 
-FOREIGN KEY(LengthUnitID) REFERENCES Metric(UnitID), 
-FOREIGN KEY(VolumeUnitID) REFERENCES Metric(UnitID), 
-FOREIGN KEY(WeightUnitID) REFERENCES Metric(UnitID), 
-FOREIGN KEY(CatID) REFERENCES Category(CatID), 
-FOREIGN KEY(GroupID) REFERENCES Item_Group(GroupID), 
-FOREIGN KEY(ItemStatusID) REFERENCES ItemStatus(ItemStatusID),  
-FOREIGN KEY(StorageID) REFERENCES Storage(StorageID))`)
-		backend.db.Exec(`CREATE TRIGGER Temp_UpdateDateModified
-AFTER UPDATE ON Temp_Item FOR EACH ROW
-BEGIN
-UPDATE Temp_Item SET DateModified = datetime('now', 'subsec') WHERE ItemID = old.ItemID;
-END`)
-		touched = true
+func (backend *Backend) createTables() error {
+	j := backend.Journal
+	m := backend.migrator()
+	err := m.Migrate(func(step schema.Step) {
+		j.NewEntry(journal.Message, journal.SQL, fmt.Sprintf("Uppdaterade databasen till version %d (%s).", step.Version, step.Name))
+	})
+	if err != nil {
+		j.NewEntry(journal.Error, journal.SQL, fmt.Sprintf("Kunde inte uppdatera databasen: %s", err))
+		return fmt.Errorf("Backend.createTables() error: %w", err)
 	}
+	return nil
+}
 
-	if !slices.Contains(tables, "Item_Condition") {
-		log.Printf("!slices.Contains(tables \"Item_Condition\")")
-		backend.db.Exec(`CREATE TABLE Item_Condition(
-ItemID INT, 
-Rate INT, 
-Comment TEXT, 
-FOREIGN KEY(ItemID) REFERENCES Item(ItemID) ON DELETE CASCADE)`)
-		touched = true
-	}
-	if !slices.Contains(tables, "Item_Group") {
-		log.Printf("!slices.Contains(tables \"Item_Group\")")
-		backend.db.Exec(`CREATE TABLE Item_Group(
-GroupID INTEGER PRIMARY KEY AUTOINCREMENT,
-ParentID INT DEFAULT 0,
-Name TEXT DEFAULT '',
-Deleted BOOL DEFAULT false)`)
-		touched = true
-	}
-	if !slices.Contains(tables, "Item_Function") {
-		log.Printf("!slices.Contains(tables \"Item_Function\")")
-		backend.db.Exec(`CREATE TABLE Item_Function(
-ItemID INT, 
-FuncID INT, 
-IsTested BOOL, 
-IsWorking BOOL, 
-Comment TEXT, 
-FOREIGN KEY(ItemID) REFERENCES Item(ItemID) ON DELETE CASCADE, 
-FOREIGN KEY(FuncID) REFERENCES Function_Data(FuncID))`)
-		touched = true
-	}
-	if !slices.Contains(tables, "Function_Data") {
-		log.Printf("!slices.Contains(tables \"Function_Data\")")
-		backend.db.Exec(`CREATE TABLE Function_Data(
-FuncID INTEGER PRIMARY KEY AUTOINCREMENT, 
-Name TEXT)`)
-		touched = true
-	}
-	if !slices.Contains(tables, "ItemStatus") {
-		log.Printf("!slices.Contains(tables \"ItemStatus\")")
-		backend.db.Exec(`CREATE TABLE ItemStatus(
-ItemStatusID INTEGER PRIMARY KEY AUTOINCREMENT, 
-Name TEXT)`)
-		backend.db.Exec(`INSERT INTO ItemStatus (Name) 
-VALUES ("available"), ("sold"), ("archived"), ("deleted")`)
-		touched = true
-	}
-	if !slices.Contains(tables, "Manufacturer") {
-		log.Printf("!slices.Contains(tables \"Manufacturer\")")
-		backend.db.Exec(`CREATE TABLE Manufacturer(
-MfrID INTEGER PRIMARY KEY AUTOINCREMENT, 
-Name TEXT DEFAULT 'Ny tillverkare',
-Deleted BOOL DEFAULT false)`)
-		backend.db.Exec(`INSERT INTO Manufacturer (Name) 
-VALUES ("UppSpar"), ("IKEA"), ("Kinnarps")`)
-		touched = true
-	}
-	if !slices.Contains(tables, "Model") {
-		log.Printf("!slices.Contains(tables \"Model\")")
-		backend.db.Exec(`CREATE TABLE Model(
-ModelID      INTEGER PRIMARY KEY AUTOINCREMENT, 
-Name         TEXT DEFAULT 'Ny modell', 
-Manufacturer TEXT DEFAULT '',
-MfrID        INT DEFAULT 0, 
-Desc         TEXT DEFAULT '', 
-ImgURL1      TEXT DEFAULT '', 
-ImgURL2      TEXT DEFAULT '', 
-ImgURL3      TEXT DEFAULT '', 
-ImgURL4      TEXT DEFAULT '', 
-ImgURL5      TEXT DEFAULT '', 
-SpecsURL     TEXT DEFAULT '', 
-ModelURL     TEXT DEFAULT '', 
-Width        REAL DEFAULT 0, 
-Height       REAL DEFAULT 0, 
-Depth        REAL DEFAULT 0, 
-Volume       REAL DEFAULT 0, 
-Weight       REAL DEFAULT 0, 
-LengthUnitID INT DEFAULT 2, 
-VolumeUnitID INT DEFAULT 11, 
-WeightUnitID INT DEFAULT 7, 
-CatID        INT DEFAULT 1, 
-Deleted      BOOL DEFAULT false, 
-FOREIGN KEY(MfrID) REFERENCES Manufacturer(MfrID)
-FOREIGN KEY(LengthUnitID) REFERENCES Metric(UnitID), 
-FOREIGN KEY(VolumeUnitID) REFERENCES Metric(UnitID), 
-FOREIGN KEY(WeightUnitID) REFERENCES Metric(UnitID), 
-FOREIGN KEY(CatID) REFERENCES Category(CatID))`)
-		touched = true
-	}
+func (backend *Backend) verifyTables() (*schema.Report, error) {
+	return backend.migrator().Verify()
+}
 
-	if !slices.Contains(tables, "Category") {
-		log.Printf("!slices.Contains(tables \"Category\")")
-		backend.createCategoryTable()
-		touched = true
-	}
-	if !slices.Contains(tables, "Category_Config") {
-		log.Printf("!slices.Contains(tables \"Category_Config\")")
-		backend.db.Exec(`CREATE TABLE Category_Config(
-CatID INT, 
-ConfigKey TEXT, 
-ConfigVal BOOL, 
-FOREIGN KEY(CatID) REFERENCES Category(CatID) ON DELETE CASCADE)`)
-		touched = true
-	}
-	if !slices.Contains(tables, "Category_Data") {
-		log.Printf("!slices.Contains(tables \"Category_Data\")")
-		backend.db.Exec(`CREATE TABLE Category_Data(
-CatID INT, 
-DataKey TEXT, 
-DataVal TEXT, 
-FOREIGN KEY(CatID) REFERENCES Category(CatID) ON DELETE CASCADE)`)
-		touched = true
-	}
+func (backend *Backend) repairTables(report *schema.Report) error {
+	return backend.migrator().Repair(report)
+}
 
-	if !slices.Contains(tables, "Image") {
-		backend.db.Exec(`CREATE TABLE Image(
-ImgID INTEGER PRIMARY KEY AUTOINCREMENT, 
-ImgData BLOB, 
-ImgFileDate TEXT,
-ImgSHA1 TEXT,
-ImgSize INT,
-ImgThumb BLOB, 
-ImgURL TEXT DEFAULT '', 
-Deleted BOOL DEFAULT false),`)
-		touched = true
-	}
+/* Compare the main database and the journal with the expected schema, optionally repair them, and record the outcome in the Journal */
+func (backend *Backend) CheckSchema(repair bool) error {
+	return backend.checkSchema(repair, false)
+}
 
-	if !slices.Contains(tables, "Metric") {
-		log.Printf("!slices.Contains(tables \"Metric\")")
-		backend.createMetricTable()
-		touched = true
-	}
+/* quiet leaves out the entry saying that the schema is as expected */
+func (backend *Backend) checkSchema(repair, quiet bool) error {
+	var errs []error
+	j := backend.Journal
 
-	if !slices.Contains(tables, "SearchWords_Association") {
-		log.Printf("!slices.Contains(tables \"SearchWords_Association\")")
-		backend.db.Exec(`CREATE TABLE SearchWords_Association(
-ItemID INT, 
-WordID INT, 
-FOREIGN KEY(ItemID) REFERENCES SearchWords_Vocabulary(WordID) ON DELETE CASCADE, 
-FOREIGN KEY(WordID) REFERENCES Item(ItemID))`)
-		touched = true
-	}
-	if !slices.Contains(tables, "SearchWords_Vocabulary") {
-		log.Printf("!slices.Contains(tables \"SearchWords_Vocabulary\")")
-		backend.db.Exec(`CREATE TABLE SearchWords_Vocabulary(
-WordID INTEGER PRIMARY KEY, 
-WordString TEXT)`)
-		touched = true
+	report, err := j.CheckTables(repair)
+	if report != nil {
+		backend.logSchemaReport(report, repair, quiet)
 	}
-
-	if !slices.Contains(tables, "Storage") {
-		log.Printf("!slices.Contains(tables \"Storage\")")
-		backend.db.Exec(`CREATE TABLE Storage(
-StorageID INTEGER PRIMARY KEY, 
-Place TEXT, 
-Comment TEXT)`)
-		touched = true
+	if err != nil {
+		j.NewEntry(journal.Error, journal.SQL, fmt.Sprintf("Kunde inte kontrollera Journalens tabeller: %s", err))
+		errs = append(errs, err)
 	}
 
-	if !slices.Contains(tables, "WishList") {
-		log.Printf("!slices.Contains(tables \"WishList\")")
-		backend.db.Exec(`CREATE TABLE WishList(
-WishID INTEGER PRIMARY KEY, 
-ContactID TEXT, 
-WishItemID TEXT, 
-Stock INT 
-DateCreated TEXT DEFAULT(datetime('now', 'subsec')), 
-DateModified TEXT DEFAULT(datetime('now', 'subsec')), 
-DateExpires TEXT, 
-FOREIGN KEY(ContactID) REFERENCES WishList_Contact(ContactID), 
-FOREIGN KEY(WishItemID) REFERENCES WishList_Item(WishItemID))`)
-		touched = true
+	report, err = backend.verifyTables()
+	if err == nil && repair {
+		err = backend.repairTables(report)
 	}
-	if !slices.Contains(tables, "WishList_Contact") {
-		log.Printf("!slices.Contains(tables \"WishList_Contact\")")
-		backend.db.Exec(`CREATE TABLE WishList_Contact(
-ContactID INTEGER PRIMARY KEY, 
-FirstName TEXT DEFAULT 'Förnamn', 
-LastName TEXT DEFAULT 'Efternamn', 
-Email TEXT, 
-Phone TEXT, 
-Comment TEXT, 
-DateCreated TEXT DEFAULT(datetime('now', 'subsec')), 
-DateModified TEXT DEFAULT(datetime('now', 'subsec')), 
-DateExpires TEXT)`)
-		touched = true
+	if report != nil {
+		backend.logSchemaReport(report, repair, quiet)
 	}
-	if !slices.Contains(tables, "WishList_Item") {
-		log.Printf("!slices.Contains(tables \"WishList_Item\")")
-		backend.db.Exec(`CREATE TABLE WishList_Item(
-WishItemID INTEGER PRIMARY KEY AUTOINCREMENT, 
-Name TEXT DEFAULT 'Nytt föremål', 
-Comment TEXT, 
-Width REAL, 
-Height REAL, 
-Depth REAL, 
-Weight REAL, 
-LengthUnitID INT, 
-WeightUnitID INT, 
-CatID INT, 
-DateCreated TEXT DEFAULT(datetime('now', 'subsec')), 
-DateModified TEXT DEFAULT(datetime('now', 'subsec')), 
-DateExpires TEXT, 
-FOREIGN KEY(LengthUnitID) REFERENCES Metric(UnitID), 
-FOREIGN KEY(WeightUnitID) REFERENCES Metric(UnitID), 
-FOREIGN KEY(CatID) REFERENCES Category(CatID))`)
-		touched = true
-	}
-	if !slices.Contains(tables, "WishList_Item_Function") {
-		log.Printf("!slices.Contains(tables \"WishList_Item_Function\")")
-		backend.db.Exec(`CREATE TABLE WishList_Item_Function(
-WishItemID INT, 
-FuncID INT, 
-Comment TEXT, 
-FOREIGN KEY(WishItemID) REFERENCES WishList_Item(WishItemID) ON DELETE CASCADE,  
-FOREIGN KEY(FuncID) REFERENCES Function_Data(FuncID))`)
-		touched = true
+	if err != nil {
+		j.NewEntry(journal.Error, journal.SQL, fmt.Sprintf("Kunde inte kontrollera databasen: %s", err))
+		errs = append(errs, err)
 	}
 
-	if touched {
-		log.Printf("touched!")
-		j.NewMessage("Skapade nya tabeller för föremål i databasen.")
-		j.Refresh()
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("Backend.CheckSchema() error: %w", err)
 	}
+	return nil
 }
 
-func (backend *Backend) createCategoryTable() {
-	backend.db.Exec(`CREATE TABLE Category(
-CatID INTEGER PRIMARY KEY AUTOINCREMENT, 
-ParentID INT DEFAULT 0,
-Name TEXT DEFAULT 'Ny kategori')`)
-	backend.db.Exec(`INSERT INTO Category (Name, ParentID) 
-VALUES  ("Administration", 0), 
-        ("Hushåll", 0), 
-        ("Kontor", 0), 
-        ("Tjänster", 0), 
-        ("Övrigt", 0),
-        ("Badrum", 2), 
-        ("Belysning", 5), 
-        ("Bord", 2), 
-        ("Dekor", 5), 
-        ("Elektronik", 3),  
-        ("Förvaring", 3), 
-        ("Husgeråd", 2), 
-        ("Hylla", 3), 
-        ("Kök & vitvaror", 2), 
-        ("Textilier & mattor", 5), 
-        ("Skrivbord", 3), 
-        ("Skåp", 3), 
-        ("Soffor & fåtöljer", 2), 
-        ("Stolar", 3), 
-        ("Tvätt & städ", 5), 
-        ("Sängar & madrasser", 2)`)
-}
+/* The Config key holding the differences last written to the Journal for a component of the schema */
+const schemaReportKey = "SchemaReport:"
 
-func (backend *Backend) createMetricTable() {
-	backend.db.Exec(`CREATE TABLE Metric(
-UnitID INTEGER PRIMARY KEY, 
-Text TEXT)`)
-	backend.db.Exec(`INSERT INTO Metric (Text) VALUES ("mm"), ("cm"), ("dm"), ("m"), ("g"), ("hg"), ("kg"), ("ml"), ("cl"), ("dl"), ("l")`)
+/*
+Writes report to the Journal and remembers its differences. A quiet report is left out if it is as expected or has the
+same differences as the last one, so that a known difference is not written again every time the database is opened.
+*/
+func (backend *Backend) logSchemaReport(report *schema.Report, repair, quiet bool) {
+	var diffs []string
+	for _, d := range report.Remaining() {
+		diffs = append(diffs, d.String())
+	}
+	key, val := schemaReportKey+report.Component, strings.Join(diffs, "\n")
+	var last string
+	backend.db.QueryRow(`SELECT ConfigVal FROM Config WHERE ConfigKey = ?`, key).Scan(&last)
+	if !quiet || (!report.OK() && val != last) {
+		backend.Journal.LogSchemaReport(report, repair)
+	}
+	if val != last {
+		if _, err := backend.db.Exec(`INSERT OR REPLACE INTO Config (ConfigKey, ConfigVal) VALUES (?, ?)`, key, val); err != nil {
+			log.Printf("Backend.logSchemaReport() error: %s", err)
+		}
+	}
 }
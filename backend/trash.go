@@ -0,0 +1,90 @@
+package backend
+
+import (
+	"UppSpar/backend/domain"
+	"UppSpar/backend/journal"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TrashedItem = domain.TrashedItem
+
+/* Returns the deleted items, the most recently deleted first */
+func Trash() ([]TrashedItem, error) {
+	return b.Repository.Trash()
+}
+
+/* Gives deleted item id back the status it had before it was deleted */
+func (m *Items) RestoreItem(id ItemID) error {
+	status, err := b.Repository.TrashedStatus(id.Int())
+	if err != nil {
+		return fmt.Errorf("Items.RestoreItem(%d) error: %w", id, err)
+	}
+	c, err := setStatus(StatusChange{ItemID: id.Int(), To: status}, restoreLead)
+	if err != nil {
+		return fmt.Errorf("Items.RestoreItem(%d) error: %w", id, err)
+	}
+	if c != nil {
+		reload(c)
+	}
+	return nil
+}
+
+/* Removes the deleted items among ids for good, with their conditions, functions, search words and parents */
+func (m *Items) PurgeItems(ids []ItemID) (int, error) {
+	var keys []int
+	for _, id := range ids {
+		keys = append(keys, id.Int())
+	}
+	purged, err := b.Repository.PurgeItems(keys)
+	if err != nil {
+		return 0, fmt.Errorf("Items.PurgeItems() error: %w", err)
+	}
+	for _, id := range purged {
+		delete(m.data, ItemID(id))
+		if b.Undo != nil {
+			b.Undo.forget("Item", id)
+		}
+	}
+	logPurge(purged, "")
+	return len(purged), nil
+}
+
+/* Purges the items that have been deleted longer than the TrashDays setting says, if it is set */
+func purgeExpiredTrash() {
+	s, err := b.Repository.Settings()
+	if err != nil || s.TrashDays <= 0 {
+		return
+	}
+	ids, err := b.Repository.ExpiredTrash(time.Now().AddDate(0, 0, -s.TrashDays))
+	if err == nil && len(ids) > 0 {
+		ids, err = b.Repository.PurgeItems(ids)
+	}
+	if err != nil {
+		log.Printf("purgeExpiredTrash() error: %s", err)
+		return
+	}
+	days := "dagar"
+	if s.TrashDays == 1 {
+		days = "dag"
+	}
+	logPurge(ids, fmt.Sprintf(" efter %d %s", s.TrashDays, days))
+}
+
+func logPurge(ids []int, after string) {
+	var s []string
+	for _, id := range ids {
+		s = append(s, strconv.Itoa(id))
+	}
+	switch len(ids) {
+	case 0:
+		return
+	case 1:
+		b.Journal.NewEntry(journal.Message, journal.Delete, fmt.Sprintf("Raderade artikel %s ur papperskorgen%s.", s[0], after))
+	default:
+		b.Journal.NewEntry(journal.Message, journal.Delete, fmt.Sprintf("Raderade %d artiklar ur papperskorgen%s: %s.", len(ids), after, strings.Join(s, ", ")))
+	}
+}
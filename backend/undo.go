@@ -0,0 +1,149 @@
+package backend
+
+import (
+	"UppSpar/backend/domain"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2/data/binding"
+)
+
+/* The most undo steps kept */
+const undoLimit = 200
+
+/* The edits of items, models, manufacturers and categories that can be undone and redone, newest last. The steps are kept whatever is selected. */
+type UndoStack struct {
+	mu      sync.Mutex
+	undo    []*domain.Change
+	redo    []*domain.Change
+	last    time.Time // Of the last edit recorded, zero after an undo or redo
+	CanUndo binding.Bool
+	CanRedo binding.Bool
+}
+
+func NewUndoStack() *UndoStack {
+	return &UndoStack{
+		CanUndo: binding.NewBool(),
+		CanRedo: binding.NewBool(),
+	}
+}
+
+/* Adds c as the newest step, or to the newest step if it changes the same row soon after. New edits make the undone steps impossible to redo. */
+func (u *UndoStack) record(c *domain.Change) {
+	if _, ok := tableNouns[c.Table]; !ok {
+		return
+	}
+	u.mu.Lock()
+	now := time.Now()
+	if n := len(u.undo); n > 0 && u.undo[n-1].Table == c.Table && u.undo[n-1].ID == c.ID && now.Sub(u.last) < editMergeDelay {
+		u.undo[n-1].Merge(c)
+		if u.undo[n-1].Empty() {
+			u.undo = u.undo[:n-1]
+		}
+	} else {
+		u.undo = append(u.undo, c)
+		if len(u.undo) > undoLimit {
+			u.undo = u.undo[len(u.undo)-undoLimit:]
+		}
+	}
+	u.redo = nil
+	u.last = now
+	u.mu.Unlock()
+	u.update()
+}
+
+/* Sets the newest step back, ErrNotFound if there is none */
+func (u *UndoStack) Undo() error {
+	u.mu.Lock()
+	n := len(u.undo)
+	if n == 0 {
+		u.mu.Unlock()
+		return fmt.Errorf("UndoStack.Undo() error: %w", ErrNotFound)
+	}
+	c := u.undo[n-1]
+	if err := b.Repository.Revert(c); err != nil {
+		u.mu.Unlock()
+		return fmt.Errorf("UndoStack.Undo() error: %w", err)
+	}
+	u.undo = u.undo[:n-1]
+	u.redo = append(u.redo, c)
+	u.last = time.Time{}
+	u.mu.Unlock()
+
+	b.history.record(reverseChange(c), undoLead)
+	u.update()
+	reload(c)
+	return nil
+}
+
+/* Makes the newest undone step again, ErrNotFound if there is none */
+func (u *UndoStack) Redo() error {
+	u.mu.Lock()
+	n := len(u.redo)
+	if n == 0 {
+		u.mu.Unlock()
+		return fmt.Errorf("UndoStack.Redo() error: %w", ErrNotFound)
+	}
+	c := u.redo[n-1]
+	if err := b.Repository.Reapply(c); err != nil {
+		u.mu.Unlock()
+		return fmt.Errorf("UndoStack.Redo() error: %w", err)
+	}
+	u.redo = u.redo[:n-1]
+	u.undo = append(u.undo, c)
+	u.last = time.Time{}
+	u.mu.Unlock()
+
+	b.history.record(c, redoLead)
+	u.update()
+	reload(c)
+	return nil
+}
+
+/* Drops the steps that change row id of table, which is gone */
+func (u *UndoStack) forget(table string, id int) {
+	u.mu.Lock()
+	gone := func(c *domain.Change) bool { return c.Table == table && c.ID == id }
+	u.undo = slices.DeleteFunc(u.undo, gone)
+	u.redo = slices.DeleteFunc(u.redo, gone)
+	u.mu.Unlock()
+	u.update()
+}
+
+func (u *UndoStack) update() {
+	u.mu.Lock()
+	canUndo, canRedo := len(u.undo) > 0, len(u.redo) > 0
+	u.mu.Unlock()
+	u.CanUndo.Set(canUndo)
+	u.CanRedo.Set(canRedo)
+}
+
+/* Loads the row c changed into the bindings that show it, if it is loaded, and the lists it is in */
+func reload(c *domain.Change) {
+	switch c.Table {
+	case "Item":
+		if t := b.Items.data[ItemID(c.ID)]; t != nil {
+			t.FetchAllFields()
+		}
+		b.Items.QueueItemIDs()
+	case "Model":
+		if mdl := b.Metadata.modelData[ModelID(c.ID)]; mdl != nil {
+			mdl.fetch()
+		}
+		b.Metadata.GetProductTree()
+	case "Manufacturer":
+		if mfr := b.Metadata.mfrData[MfrID(c.ID)]; mfr != nil {
+			name, _ := MfrID(c.ID).Name()
+			mfr.Name.Set(name)
+		}
+		b.Metadata.GetMfrIDs()
+		b.Metadata.GetProductTree()
+	case "Category":
+		if cat := b.Metadata.categoryData[CatID(c.ID)]; cat != nil {
+			cat.getNameStrings()
+		}
+		b.Metadata.UpdateCatList()
+	}
+}
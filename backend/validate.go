@@ -0,0 +1,221 @@
+package backend
+
+import (
+	"UppSpar/backend/journal"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2/lang"
+)
+
+/* Checks run on the Proceedo rows before they are exported */
+
+var ErrValidation = errors.New("items failed validation")
+
+/* VAT rates accepted by Proceedo, in percent */
+var proceedoVatRates = []float64{0, 6, 12, 25}
+
+/* ISO 4217 codes of the currencies we trade in */
+var proceedoCurrencies = []string{"SEK", "EUR", "USD", "NOK", "DKK", "GBP", "CHF", "PLN", "ISK"}
+
+/* Maximum length in characters of the text columns Proceedo limits */
+var proceedoMaxLength = map[string]int{
+	"Name":               100,
+	"Unit":               10,
+	"EtaText":            50,
+	"SearchWords":        250,
+	"LongDesc":           4000,
+	"Manufacturer":       100,
+	"MfrItemId":          50,
+	"GlobId":             50,
+	"PriceInfo":          250,
+	"AddDesc":            1000,
+	"Comment":            250,
+	"RiskClassification": 250,
+	"EnvClassification":  250,
+}
+
+/* Mandatory columns that new items leave at 0 or empty, missing values are warnings so that a new item can be exported */
+var proceedoDefaultedColumns = []string{"OrderMultiple", "MinOrder", "ProcFlow"}
+
+var unspscFormat = regexp.MustCompile(`^\d{2}\.\d{2}\.\d{2}\.\d{2}$`)
+
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+/* Returns a localized string */
+func (s Severity) LString() string {
+	if s == SeverityError {
+		return lang.X("validation.error", "validation.error")
+	}
+	return lang.X("validation.warning", "validation.warning")
+}
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+/* A problem with one column of one item, Header is the column header in the sheet */
+type Issue struct {
+	Severity Severity
+	Header   string
+	Message  string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", strings.TrimSuffix(i.Header, "*"), i.Message)
+}
+
+type ItemValidation struct {
+	ItemID ItemID
+	Name   string
+	Issues []Issue
+}
+
+/* The result of validating all items about to be exported. Only items with issues are listed. */
+type ValidationReport struct {
+	Checked int
+	Items   []ItemValidation
+}
+
+/* Returns the number of issues with severity s */
+func (r *ValidationReport) Count(s Severity) int {
+	n := 0
+	for _, item := range r.Items {
+		for _, issue := range item.Issues {
+			if issue.Severity == s {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+/* Reports whether there is nothing that would make Proceedo reject the export */
+func (r *ValidationReport) OK() bool {
+	return r.Count(SeverityError) == 0
+}
+
+/* Check every item that Export would export */
+func ValidateExport() (*ValidationReport, error) {
+	ids, err := ProceedoProfile().itemIDs()
+	if err != nil {
+		return &ValidationReport{}, fmt.Errorf("ValidateExport() error: %w", err)
+	}
+	return ValidateItems(ids)
+}
+
+/* Check the Proceedo rows of the items ids */
+func ValidateItems(ids []ItemID) (*ValidationReport, error) {
+	report := &ValidationReport{}
+	profile := ProceedoProfile()
+	columns, err := itemColumns()
+	if err != nil {
+		return report, fmt.Errorf("ValidateItems() error: %w", err)
+	}
+	for _, id := range ids {
+		row, err := profile.row(id, columns)
+		if err != nil {
+			return report, fmt.Errorf("ValidateItems() error: %w", err)
+		}
+		report.Checked++
+		if issues := validateRow(row); len(issues) > 0 {
+			name, _ := row[1].(string)
+			report.Items = append(report.Items, ItemValidation{ItemID: id, Name: name, Issues: issues})
+		}
+	}
+	return report, nil
+}
+
+/* Write a summary of the report to the journal */
+func logValidationReport(report *ValidationReport) {
+	level := journal.Message
+	if report.Count(SeverityError) > 0 {
+		level = journal.Error
+	} else if report.Count(SeverityWarning) > 0 {
+		level = journal.Warning
+	}
+	msg := fmt.Sprintf("Kontrollerade %d artiklar inför export: %d fel och %d varningar.", report.Checked, report.Count(SeverityError), report.Count(SeverityWarning))
+	for _, item := range report.Items {
+		var issues []string
+		for _, issue := range item.Issues {
+			issues = append(issues, issue.String())
+		}
+		msg += fmt.Sprintf("\n<ItemId>%d</ItemId> %s: %s", item.ItemID, item.Name, strings.Join(issues, "; "))
+	}
+	b.Journal.NewEntry(level, journal.Log, msg)
+}
+
+func validateRow(row []any) []Issue {
+	var issues []Issue
+	add := func(s Severity, header, format string, a ...any) {
+		issues = append(issues, Issue{Severity: s, Header: header, Message: fmt.Sprintf(format, a...)})
+	}
+	for c, column := range proceedoColumns {
+		val := row[c]
+		severity := SeverityError
+		if slices.Contains(proceedoDefaultedColumns, column.Source) {
+			severity = SeverityWarning
+		}
+		if val == nil {
+			if strings.HasSuffix(column.Header, "*") {
+				add(severity, column.Header, "obligatoriskt fält saknas")
+			}
+			continue
+		}
+		switch column.Source {
+		case "Price":
+			if f, _ := val.(float64); f < 0 {
+				add(SeverityError, column.Header, "negativt pris")
+			} else if f == 0 {
+				add(SeverityWarning, column.Header, "priset är 0")
+			}
+		case "QuantityInPrice", "OrderMultiple", "MinOrder":
+			if f, _ := val.(float64); f <= 0 {
+				add(severity, column.Header, "måste vara större än 0")
+			}
+		case "Vat":
+			if f, _ := val.(float64); !slices.Contains(proceedoVatRates, f) {
+				add(SeverityError, column.Header, "%v är ingen tillåten momssats (%s)", f, strings.Trim(fmt.Sprint(proceedoVatRates), "[]"))
+			}
+		case "Currency":
+			if s, _ := val.(string); !slices.Contains(proceedoCurrencies, s) {
+				add(SeverityError, column.Header, "okänd valutakod %q", s)
+			}
+		case "UNSPSC":
+			if s, _ := val.(string); !unspscFormat.MatchString(s) {
+				add(SeverityError, column.Header, "%q har inte formatet 00.00.00.00", s)
+			}
+		case "ImgURL1", "ImgURL2", "ImgURL3", "ImgURL4", "ImgURL5", "SpecsURL":
+			if s, _ := val.(string); !validURL(s) {
+				add(SeverityError, column.Header, "%q är ingen giltig webbadress", s)
+			}
+		}
+		if max, ok := proceedoMaxLength[column.Source]; ok {
+			if s, _ := val.(string); utf8.RuneCountInString(s) > max {
+				add(SeverityError, column.Header, "%d tecken, högst %d tillåts", utf8.RuneCountInString(s), max)
+			}
+		}
+	}
+	if row[proceedoIndex("ImgURL1")] == nil {
+		add(SeverityWarning, "Webblänk till bild", "bild saknas")
+	}
+	return issues
+}
+
+func validURL(s string) bool {
+	u, err := url.ParseRequestURI(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"UppSpar/backend"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+/* Command line interface to the database, for scripts and scheduled jobs. Never starts a Fyne app. */
+
+const usage = `Usage: uppspar -db FILE COMMAND [ARGS]
+
+Commands:
+  list                      list all items
+  search TERM               list items whose name, model or descriptions
+                            contain TERM, also inside words, best match first
+                            with full-text search, TERM may hold conditions
+                            like mfr:IKEA width:40..60
+  saved [NAME]              list the saved searches with the number of
+                            items matching each, or the items of search NAME
+  get ID FIELD              print one field of an item
+  set ID FIELD VALUE        change one field of an item, ItemStatusID only
+                            to a status the item can go to, not Stock,
+                            which sell, return and receive change
+  fields                    list the fields of an item
+  validate                  check available items for problems Proceedo rejects
+  export-excel [-force] [-profile NAME] [-format F] FILE
+                            export available items to a Proceedo spreadsheet,
+                            -force exports even if validation finds errors,
+                            -profile uses a stored export profile instead,
+                            F is xlsx, csv, json or ods (default from the
+                            extension of FILE, otherwise xlsx)
+  profiles                  list the export profiles
+  import [-mode M] [-dry-run] FILE
+                            import items from a Proceedo spreadsheet, M is
+                            create (default), update or skip for rows whose
+                            Artikelnummer already exists
+  sell ID QTY PRICE [BUYER] sell QTY of item ID at PRICE each, with the VAT
+                            of the item, which is sold when none is left
+  return SALE QTY [REASON]  return QTY of sale SALE to stock
+  receive ID QTY [REASON]   add QTY of item ID to stock, as a receipt in the
+                            ledger
+  sales ID                  list the sales, returns, corrections and
+                            receipts of item ID
+  report FROM TO            print the revenue, items sold per category and
+                            average days to sale between the dates FROM and
+                            TO, written as 2006-01-02
+  journal tail [N]          print the N most recent journal entries (default 20)
+  backup FILE               copy the database to FILE
+`
+
+var errUsage = errors.New("invalid arguments")
+
+func main() {
+	file := flag.String("db", "", "database file")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+	}
+	flag.Parse()
+
+	if *file == "" || flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if _, err := os.Stat(*file); err != nil {
+		fmt.Fprintf(os.Stderr, "uppspar: %s\n", err)
+		os.Exit(1)
+	}
+
+	bk, err := backend.NewHeadlessBackend(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "uppspar: %s\n", err)
+		os.Exit(1)
+	}
+	defer bk.Close()
+
+	err = run(bk, flag.Arg(0), flag.Args()[1:])
+	if errors.Is(err, errUsage) {
+		flag.Usage()
+		bk.Close()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "uppspar: %s\n", err)
+		bk.Close()
+		os.Exit(1)
+	}
+}
+
+func run(bk *backend.Backend, cmd string, args []string) error {
+	switch cmd {
+	case "list":
+		if len(args) != 0 {
+			return errUsage
+		}
+		return list("")
+	case "search":
+		if len(args) != 1 {
+			return errUsage
+		}
+		return list(args[0])
+	case "saved":
+		switch len(args) {
+		case 0:
+			searches, err := backend.SavedSearches()
+			if err != nil {
+				return err
+			}
+			for _, search := range searches {
+				n, err := search.Count()
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s\t%d\t%s\n", search.Name, n, search.Term)
+			}
+		case 1:
+			search, err := backend.SavedSearchFor(args[0])
+			if err != nil {
+				return err
+			}
+			ids, err := search.ItemIDs()
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				name, _ := id.Name()
+				fmt.Printf("%s\t%s\n", id, name)
+			}
+		default:
+			return errUsage
+		}
+	case "get":
+		if len(args) != 2 {
+			return errUsage
+		}
+		id, err := itemID(args[0])
+		if err != nil {
+			return err
+		}
+		val, err := id.GetField(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Println(val)
+	case "set":
+		if len(args) != 3 {
+			return errUsage
+		}
+		id, err := itemID(args[0])
+		if err != nil {
+			return err
+		}
+		return id.SetField(args[1], args[2])
+	case "fields":
+		fields, err := backend.ItemFields()
+		if err != nil {
+			return err
+		}
+		for _, field := range fields {
+			fmt.Println(field)
+		}
+	case "validate":
+		if len(args) != 0 {
+			return errUsage
+		}
+		report, err := backend.ValidateExport()
+		if err != nil {
+			return err
+		}
+		printValidation(report)
+		if !report.OK() {
+			return backend.ErrValidation
+		}
+	case "export-excel":
+		fs := flag.NewFlagSet("export-excel", flag.ContinueOnError)
+		fs.Usage = func() {}
+		force := fs.Bool("force", false, "export even if validation finds errors")
+		name := fs.String("profile", "", "export profile")
+		formatName := fs.String("format", "", "xlsx, csv, json or ods")
+		if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+			return errUsage
+		}
+		format, _ := backend.ExportFormatForPath(fs.Arg(0))
+		if *formatName != "" {
+			f, err := backend.ExportFormatFor(*formatName)
+			if err != nil {
+				return errUsage
+			}
+			format = f
+		}
+		if *name != "" {
+			profile, err := backend.ExportProfileFor(*name)
+			if err != nil {
+				return err
+			}
+			if !profile.BuiltIn {
+				return profile.Export(fs.Arg(0), format)
+			}
+		}
+		report, err := backend.Export(fs.Arg(0), format, *force)
+		if report != nil {
+			printValidation(report)
+		}
+		return err
+	case "profiles":
+		if len(args) != 0 {
+			return errUsage
+		}
+		profiles, err := backend.ExportProfiles()
+		if err != nil {
+			return err
+		}
+		for _, profile := range profiles {
+			fmt.Printf("%s\t%s\t%d columns\n", profile.Name, profile.SheetName, len(profile.Columns))
+		}
+	case "import":
+		fs := flag.NewFlagSet("import", flag.ContinueOnError)
+		fs.Usage = func() {}
+		mode := fs.String("mode", "create", "create, update or skip")
+		dryRun := fs.Bool("dry-run", false, "only report what would be imported")
+		if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+			return errUsage
+		}
+		m, err := backend.ImportModeFor(*mode)
+		if err != nil {
+			return errUsage
+		}
+		report, err := backend.ImportExcel(fs.Arg(0), m, *dryRun)
+		for _, row := range report.Rows {
+			msg := row.Name
+			if row.Err != nil {
+				msg = row.Err.Error()
+			}
+			fmt.Printf("%d\t%s\t%s\t%s\n", row.Row, row.Action, row.ItemID, msg)
+		}
+		fmt.Printf("%d created, %d updated, %d skipped, %d failed\n",
+			report.Count(backend.ImportCreated), report.Count(backend.ImportUpdated),
+			report.Count(backend.ImportSkipped), report.Count(backend.ImportFailed))
+		if err != nil {
+			return fmt.Errorf("%d rows failed", report.Count(backend.ImportFailed))
+		}
+	case "sell":
+		if len(args) < 3 || len(args) > 4 {
+			return errUsage
+		}
+		id, err := itemID(args[0])
+		if err != nil {
+			return err
+		}
+		quantity, err1 := strconv.ParseFloat(args[1], 64)
+		price, err2 := strconv.ParseFloat(args[2], 64)
+		if err1 != nil || err2 != nil {
+			return errUsage
+		}
+		vat, _ := id.Vat()
+		sale := backend.Sale{Quantity: quantity, Price: price, Vat: vat}
+		if len(args) == 4 {
+			sale.Buyer = args[3]
+		}
+		s, err := id.Sell(sale)
+		if err != nil {
+			return err
+		}
+		printSale(*s)
+	case "return":
+		if len(args) < 2 || len(args) > 3 {
+			return errUsage
+		}
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return errUsage
+		}
+		quantity, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			return errUsage
+		}
+		var reason string
+		if len(args) == 3 {
+			reason = args[2]
+		}
+		s, err := backend.ReturnSale(id, quantity, reason)
+		if err != nil {
+			return err
+		}
+		printSale(*s)
+	case "receive":
+		if len(args) < 2 || len(args) > 3 {
+			return errUsage
+		}
+		id, err := itemID(args[0])
+		if err != nil {
+			return err
+		}
+		quantity, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			return errUsage
+		}
+		var reason string
+		if len(args) == 3 {
+			reason = args[2]
+		}
+		s, err := id.Receive(quantity, reason)
+		if err != nil {
+			return err
+		}
+		printSale(*s)
+	case "sales":
+		if len(args) != 1 {
+			return errUsage
+		}
+		id, err := itemID(args[0])
+		if err != nil {
+			return err
+		}
+		sales, err := id.Sales()
+		if err != nil {
+			return err
+		}
+		for _, s := range sales {
+			printSale(s)
+		}
+	case "report":
+		if len(args) != 2 {
+			return errUsage
+		}
+		from, err1 := time.ParseInLocation(time.DateOnly, args[0], time.Local)
+		to, err2 := time.ParseInLocation(time.DateOnly, args[1], time.Local)
+		if err1 != nil || err2 != nil {
+			return errUsage
+		}
+		report, err := backend.GetSalesReport(from, to)
+		if err != nil {
+			return err
+		}
+		for _, r := range report.Revenue {
+			fmt.Printf("revenue\t%s\t%.2f\t%.2f VAT\n", r.Currency, r.Amount, r.VatAmount)
+		}
+		for _, c := range report.Categories {
+			fmt.Printf("category\t%s\t%g\n", c.Name, c.Quantity)
+		}
+		fmt.Printf("%g items sold in %d sales, on average %.1f days after they were added\n", report.Quantity, report.Sales, report.AverageDays)
+	case "journal":
+		if len(args) < 1 || len(args) > 2 || args[0] != "tail" {
+			return errUsage
+		}
+		n := 20
+		if len(args) == 2 {
+			var err error
+			if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+				return errUsage
+			}
+		}
+		for _, entry := range bk.Journal.Tail(n) {
+			_, level, event, msg, tme := entry.Strings()
+			fmt.Printf("%s\t%s\t%s\t%s\n", tme, level, event, msg)
+		}
+	case "backup":
+		if len(args) != 1 {
+			return errUsage
+		}
+		return bk.Backup(args[0])
+	default:
+		return errUsage
+	}
+	return nil
+}
+
+func list(term string) error {
+	ids, err := backend.SearchItemIDs(term)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		name, _ := id.Name()
+		if snippet, _ := backend.SearchSnippet(term, id); snippet != "" {
+			fmt.Printf("%s\t%s\t%s\n", id, name, snippet)
+		} else {
+			fmt.Printf("%s\t%s\n", id, name)
+		}
+	}
+	return nil
+}
+
+func printValidation(report *backend.ValidationReport) {
+	for _, item := range report.Items {
+		for _, issue := range item.Issues {
+			fmt.Printf("%s\t%s\t%s\n", item.ItemID, issue.Severity, issue)
+		}
+	}
+	fmt.Printf("%d items checked, %d errors, %d warnings\n",
+		report.Checked, report.Count(backend.SeverityError), report.Count(backend.SeverityWarning))
+}
+
+func printSale(s backend.Sale) {
+	fmt.Printf("%d\t%s\t%d\t%s\t%g\t%.2f\t%.2f %s\t%s\t%s\n", s.ID, s.Time.Local().Format("2006-01-02 15:04"),
+		s.SaleID, s.Kind, s.Quantity, s.Price, s.Amount, s.Currency, s.Buyer, s.Reason)
+}
+
+func itemID(s string) (backend.ItemID, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 1 {
+		return 0, fmt.Errorf("invalid item ID %q", s)
+	}
+	return backend.ItemID(i), nil
+}
@@ -153,6 +153,7 @@ func (a *App) openDatabase(file string) {
 	a.app.Preferences().SetString("file", file)
 
 	a.newGui()
+	a.window.SetMainMenu(a.newMainMenu())
 
 	canvas := a.window.Canvas()
 	content := a.gui.tabs // TODO <-- is this the culprit?
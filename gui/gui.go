@@ -1,7 +1,15 @@
 package gui
 
 import (
+	"UppSpar/backend"
+	"UppSpar/backend/bridge"
+	"errors"
+	"log"
+
+	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/theme"
 )
@@ -10,7 +18,9 @@ type gui struct {
 	items    *items
 	journal  *journalView
 	metadata *metadataView
+	sales    *bridge.SalesReport
 	settings *settingsView
+	trash    *bridge.Trash
 	wishlist *wishlistView
 	tabs     *container.AppTabs
 }
@@ -20,7 +30,9 @@ func (a *App) newGui() {
 	a.gui.items = newItems(a)
 	a.gui.journal = newJournalView(a.backend)
 	a.gui.metadata = newMetadataView(a.backend)
+	a.gui.sales = bridge.NewSalesReport(a.backend, a.window)
 	a.gui.settings = newSettingsView(a.backend)
+	a.gui.trash = bridge.NewTrashList(a.backend, a.window)
 	a.gui.wishlist = newWishlistView(a.backend)
 	a.newAppTabs()
 }
@@ -28,9 +40,40 @@ func (a *App) newAppTabs() {
 	a.gui.tabs = container.NewAppTabs(
 		container.NewTabItemWithIcon(lang.L("Items"), theme.ListIcon(), a.gui.items.container),
 		container.NewTabItemWithIcon(lang.L("Metadata"), theme.StorageIcon(), a.gui.metadata.tabs),
+		container.NewTabItemWithIcon(lang.L("Sales"), theme.DocumentIcon(), a.gui.sales.Container),
+		container.NewTabItemWithIcon(lang.L("Trash"), theme.DeleteIcon(), a.gui.trash.Container),
 		container.NewTabItemWithIcon(lang.L("Journal"), theme.InfoIcon(), a.gui.journal.container),
 		// container.NewTabItemWithIcon(lang.L("Wishlist"), theme.MenuIcon(), a.gui.wishlist.container),
 		container.NewTabItemWithIcon(lang.L("Settings"), theme.SettingsIcon(), a.gui.settings.container),
 	)
 	a.gui.tabs.SetTabLocation(container.TabLocationLeading)
 }
+
+/* The main menu, whose Edit menu undoes and redoes edits with Ctrl+Z and Ctrl+Shift+Z even while an entry has focus */
+func (a *App) newMainMenu() *fyne.MainMenu {
+	u := a.backend.Undo
+	undo := fyne.NewMenuItem(lang.L("Undo"), func() {
+		if err := u.Undo(); err != nil && !errors.Is(err, backend.ErrNotFound) {
+			log.Println(err)
+		}
+	})
+	undo.Shortcut = &fyne.ShortcutUndo{}
+	redo := fyne.NewMenuItem(lang.L("Redo"), func() {
+		if err := u.Redo(); err != nil && !errors.Is(err, backend.ErrNotFound) {
+			log.Println(err)
+		}
+	})
+	redo.Shortcut = &desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift}
+	edit := fyne.NewMenu(lang.L("Edit"), undo, redo)
+	menu := fyne.NewMainMenu(edit)
+
+	update := func() {
+		canUndo, _ := u.CanUndo.Get()
+		canRedo, _ := u.CanRedo.Get()
+		undo.Disabled, redo.Disabled = !canUndo, !canRedo
+		edit.Refresh()
+	}
+	u.CanUndo.AddListener(binding.NewDataListener(update))
+	u.CanRedo.AddListener(binding.NewDataListener(update))
+	return menu
+}
@@ -7,6 +7,7 @@ import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/lang"
 )
 
 // TODO redo with maps just like metadata
@@ -14,7 +15,11 @@ import (
 type items struct {
 	container *fyne.Container
 	form      *bridge.Form
+	history   *bridge.History
 	list      *bridge.List
+	sales     *bridge.Sales
+	saved     *bridge.SavedSearchList
+	filter    *bridge.Tools
 	search    *bridge.Tools
 }
 
@@ -23,10 +28,14 @@ func newItems(a *App) *items {
 	b := a.backend
 
 	v := &items{
-		form:   bridge.NewItemForm(b, w),
-		list:   bridge.NewList(b, w),
-		search: bridge.NewSearchBar(b, w),
+		form:    bridge.NewItemForm(b, w),
+		history: bridge.NewHistoryPanel(b, w),
+		list:    bridge.NewList(b, w),
+		sales:   bridge.NewSalesPanel(b, w),
+		search:  bridge.NewSearchBar(b, w),
+		filter:  bridge.NewFilterPanel(b, w),
 	}
+	v.saved = bridge.NewSavedSearchList(b, w, v.search)
 
 	b.Items.ItemIDSelection.AddListener(binding.NewDataListener(func() {
 		ids, err := b.Items.ItemIDSelection.Get()
@@ -38,27 +47,26 @@ func newItems(a *App) *items {
 		}
 		ItemID := ids[0].(backend.ItemID)
 		v.form.LoadItem(b, ItemID)
+		v.history.LoadItem(ItemID)
+		v.sales.LoadItem(ItemID)
 	}))
 
-	split := container.NewHSplit(v.list.Container, v.form.Container)
+	tabs := container.NewAppTabs(
+		container.NewTabItem(lang.L("Saved searches"), v.saved.Container),
+		container.NewTabItem(lang.L("Filter"), v.filter.Container),
+	)
+	lists := container.NewVSplit(tabs, v.list.Container)
+	lists.SetOffset(0.3)
+	forms := container.NewAppTabs(
+		container.NewTabItem(lang.L("Item"), v.form.Container),
+		container.NewTabItem(lang.L("History"), v.history.Container),
+		container.NewTabItem(lang.L("Sales"), v.sales.Container),
+	)
+	split := container.NewHSplit(lists, forms)
 	split.SetOffset(0.2)
 
 	v.container = container.NewBorder(v.search.Container, nil, nil, nil, split)
 
-	// searchEntry := xwidget.NewCompletionEntry([]string{})
-	// searchEntry.Bind(b.Items.Search.Term)
-	// searchEntry.OnChanged = func(s string) {
-	// 	if len(s) < 3 {
-	// 		searchEntry.HideCompletion()
-	// 		return
-	// 	}
-	// 	results, err := b.Items.Search.Completions.Get()
-	// 	if err != nil {
-	// 		panic(err)
-	// 	}
-	// 	searchEntry.SetOptions(results)
-	// 	searchEntry.ShowCompletion()
-	// }
 	// searchKeys := make(bridge.Checks)
 	// searchKeys["Name"] = ttw.NewCheckWithData("Art", b.Items.Search.Scope["Name"])
 	// searchKeys["Manufacturer"] = ttw.NewCheckWithData("Mfr", b.Items.Search.Scope["Manufacturer"])
@@ -58,6 +58,7 @@ func newJournalView(b *backend.Backend) *journalView {
 		}
 	})
 	j.List.AddListener(scrollToNewEntry)
+	j.Changes.AddListener(binding.NewDataListener(list.Refresh))
 	sortAscending := func() {
 		j.SortAscending()
 		list.Refresh()
@@ -5,6 +5,7 @@ import (
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
@@ -23,15 +24,32 @@ func newSettingsView(b *backend.Backend) *settingsView {
 	ItemIDSubtext := lang.X("settings.itemid.subtext", "settings.itemid.subtext")
 	ItemIDTooltip := lang.X("settings.itemid.tooltip", "settings.itemid.tooltip")
 
+	TrashText := lang.X("settings.trash.text", "settings.trash.text")
+	TrashSubtext := lang.X("settings.trash.subtext", "settings.trash.subtext")
+	TrashTooltip := lang.X("settings.trash.tooltip", "settings.trash.tooltip")
+
 	ResumeText := lang.X("settings.resume.text", "settings.resume.text")
 	// ResumeSubtext := lang.X("settings.resume.subtext", "settings.resume.subtext")
 	// ResumeTooltip := lang.X("settings.resume.tooltip", "settings.resume.tooltip")
 
+	SchemaText := lang.X("settings.schema.text", "settings.schema.text")
+	SchemaSubtext := lang.X("settings.schema.subtext", "settings.schema.subtext")
+	SchemaTooltip := lang.X("settings.schema.tooltip", "settings.schema.tooltip")
+	SchemaVerify := lang.X("settings.schema.verify", "settings.schema.verify")
+	SchemaRepair := lang.X("settings.schema.repair", "settings.schema.repair")
+
 	f := container.New(layout.NewFormLayout(),
 		layout.NewSpacer(),
-		ttw.NewCheckWithData(ResumeText, b.Settings.ResumeLastSession),
+		ttw.NewCheckWithData(ResumeText, binding.BindPreferenceBool("resume", fyne.CurrentApp().Preferences())),
 		midget.NewLabel(ItemIDText, ItemIDSubtext, ItemIDTooltip),
 		midget.NewIntEntryWithData(b.Settings.ItemIDWidth),
+		midget.NewLabel(TrashText, TrashSubtext, TrashTooltip),
+		midget.NewIntEntryWithData(b.Settings.TrashDays),
+		midget.NewLabel(SchemaText, SchemaSubtext, SchemaTooltip),
+		container.NewHBox(
+			widget.NewButton(SchemaVerify, func() { b.CheckSchema(false) }),
+			widget.NewButton(SchemaRepair, func() { b.CheckSchema(true) }),
+		),
 	)
 
 	f.Objects[1].(*ttw.Check).Disable() // TODO fix the crash before enabling !